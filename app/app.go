@@ -1,12 +1,17 @@
 package app
 
 import (
+	"api/app/authentication"
 	"api/app/config"
 	"api/app/database"
 	"api/app/database/migration"
+	"api/app/jobs"
+	"api/app/mailer"
 	"api/app/middleware"
+	"api/app/models"
 	"api/app/repositories"
 	"api/app/routers"
+	"api/app/storage"
 	"context"
 	"log"
 	"net/http"
@@ -53,6 +58,41 @@ func (app *App) StartApp() {
 
 	repositories.UserRepo.Init()
 	repositories.TaskRepo.Init()
+	repositories.CommentRepo.Init()
+	repositories.AttachmentRepo.Init()
+	repositories.ChecklistRepo.Init()
+	repositories.DependencyRepo.Init()
+	repositories.ScheduleRepo.Init()
+	repositories.LeaseRepo.Init()
+	repositories.WorkLogRepo.Init()
+	repositories.TagRepo.Init()
+	repositories.AssetRepo.Init()
+	repositories.LocationRepo.Init()
+	repositories.TemplateRepo.Init()
+	repositories.InvitationRepo.Init()
+	repositories.TokenRepo.Init()
+	repositories.PasswordRepo.Init()
+
+	storage.Init()
+	mailer.Init()
+
+	// the first Admin comes from the configuration, it can then invite the others
+	if len(config.ADMIN_EMAIL) > 0 {
+		bootstrapAdmin(config.ADMIN_EMAIL)
+	}
+
+	if err := authentication.InitKeys(); err != nil {
+		log.Fatal("it's not possible to load the token signing keys ", err)
+	}
+
+	// background jobs stop when the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	jobs.StartTrashPurge(jobsCtx)
+	jobs.StartOverdueCheck(jobsCtx)
+	jobs.StartScheduler(jobsCtx)
+	jobs.StartTokenPurge(jobsCtx)
 
 	router := gin.New()
 	router.Use(middleware.Logger())
@@ -112,3 +152,22 @@ func (app *App) StartApp() {
 		log.Println("Server exiting now")
 	}
 }
+
+// bootstrapAdmin makes the user of the email the first Admin, the failures are logged and the server starts anyway.
+func bootstrapAdmin(email string) {
+	admin, err := models.NewBootstrapAdmin(email)
+	if err != nil {
+		log.Println("it's not possible to bootstrap the admin", err)
+		return
+	}
+
+	bootstrapped, errBootstrap := repositories.UserRepo.BootstrapAdmin(admin)
+	if errBootstrap != nil {
+		log.Println("it's not possible to bootstrap the admin", errBootstrap.Message())
+		return
+	}
+
+	if bootstrapped != nil {
+		log.Println("the user", bootstrapped.ID, "is now the first admin")
+	}
+}
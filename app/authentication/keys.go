@@ -0,0 +1,233 @@
+package authentication
+
+import (
+	"api/app/config"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Keys signs and verifies the tokens, without it they are signed with the secret key.
+var Keys *KeySet
+
+// SigningKey is a private key of the key set, it signs from its activation and verifies until it is removed.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	ActiveFrom time.Time
+}
+
+// KeySet holds the keys ordered by activation, the last active one signs and all of them verify,
+// so a key can be published before it signs and an old key keeps verifying the tokens it signed.
+type KeySet struct {
+	keys []*SigningKey
+}
+
+// JWK is the public part of a key as published in the JWKS.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// InitKeys loads the keys of JWT_SIGNING_KEYS, each entry is kid:path with an optional @activation in RFC 3339.
+func InitKeys() error {
+	if len(config.JWT_SIGNING_KEYS) == 0 {
+		Keys = nil
+		return nil
+	}
+
+	keys := []*SigningKey{}
+	for _, entry := range config.JWT_SIGNING_KEYS {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return fmt.Errorf("the signing key %s should be kid:path", entry)
+		}
+
+		path, activeFrom := parts[1], time.Time{}
+		if at := strings.LastIndex(path, "@"); at >= 0 {
+			var err error
+			if activeFrom, err = time.Parse(time.RFC3339, path[at+1:]); err != nil {
+				return fmt.Errorf("the activation of the signing key %s is invalid: %s", parts[0], err.Error())
+			}
+			path = path[:at]
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		key, err := ParseSigningKey(parts[0], content, activeFrom)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	keySet, err := NewKeySet(keys)
+	if err != nil {
+		return err
+	}
+
+	Keys = keySet
+	return nil
+}
+
+// ParseSigningKey reads a PEM private key, the algorithm follows the key: RS256, ES256/ES384/ES512 or EdDSA.
+func ParseSigningKey(keyID string, content []byte, activeFrom time.Time) (*SigningKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("the signing key %s isn't a PEM file", keyID)
+	}
+
+	var privateKey interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("the signing key %s can't be read: %s", keyID, err.Error())
+	}
+
+	key := &SigningKey{ID: keyID, ActiveFrom: activeFrom}
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if privateKey.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("the RSA signing key %s should have at least 2048 bits", keyID)
+		}
+		key.Method, key.PrivateKey = jwt.SigningMethodRS256, privateKey
+	case *ecdsa.PrivateKey:
+		switch privateKey.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("the curve of the signing key %s isn't supported", keyID)
+		}
+		key.PrivateKey = privateKey
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey = SigningMethodEdDSA, privateKey
+	default:
+		return nil, fmt.Errorf("the type of the signing key %s isn't supported", keyID)
+	}
+
+	return key, nil
+}
+
+func NewKeySet(keys []*SigningKey) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("the key set needs at least one key")
+	}
+
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("the kid %s is used by more than one signing key", key.ID)
+		}
+		seen[key.ID] = true
+	}
+
+	// the keys with the same activation keep their order, the last one listed signs
+	sorted := append([]*SigningKey{}, keys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom)
+	})
+
+	return &KeySet{keys: sorted}, nil
+}
+
+// Signing returns the key that signs at the given time.
+func (keySet *KeySet) Signing(now time.Time) (*SigningKey, error) {
+	for i := len(keySet.keys) - 1; i >= 0; i-- {
+		if !keySet.keys[i].ActiveFrom.After(now) {
+			return keySet.keys[i], nil
+		}
+	}
+
+	return nil, errors.New("none of the signing keys is active yet")
+}
+
+func (keySet *KeySet) Get(keyID string) (*SigningKey, bool) {
+	for _, key := range keySet.keys {
+		if key.ID == keyID {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// JWKS publishes the public keys, including the ones that will sign later so they are known before they are used.
+func (keySet *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range keySet.keys {
+		jwks.Keys = append(jwks.Keys, key.JWK())
+	}
+
+	return jwks
+}
+
+func (key *SigningKey) PublicKey() crypto.PublicKey {
+	return key.PrivateKey.Public()
+}
+
+func (key *SigningKey) JWK() JWK {
+	jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+
+	switch publicKey := key.PublicKey().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = encodeJWKNumber(publicKey.N.Bytes())
+		jwk.E = encodeJWKNumber(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = publicKey.Curve.Params().Name
+		jwk.X = encodeJWKNumber(publicKey.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeJWKNumber(publicKey.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = encodeJWKNumber(publicKey)
+	}
+
+	return jwk
+}
+
+func encodeJWKNumber(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
@@ -3,8 +3,11 @@ package authentication
 import (
 	"api/app/config"
 	"api/app/constants"
+	"api/app/models"
+	"api/app/repositories"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -13,15 +16,59 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// AccessToken is a signed token with the id it can be revoked by.
+type AccessToken struct {
+	Token     string
+	ID        string
+	ExpiresAt time.Time
+}
+
+// TokenInfo identifies an access token and the session that issued it.
+type TokenInfo struct {
+	ID        string
+	SessionID string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// CreateToken returns an access token outside of any session, it can't be refreshed.
 func CreateToken(userID uint64, userType string) (string, error) {
+	accessToken, err := CreateAccessToken(userID, userType, "")
+	if err != nil {
+		return "", err
+	}
+
+	return accessToken.Token, nil
+}
+
+// CreateAccessToken returns a short lived token of the session, its jti is the id the token can be revoked by.
+func CreateAccessToken(userID uint64, userType string, sessionID string) (*AccessToken, error) {
+	tokenID, err := models.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.ACCESS_TOKEN_TTL)
 	permissionsByType := constants.GetSupportedPermissionsByUserType()
 	permissions := jwt.MapClaims{}
 	permissions["authorized"] = true
-	permissions["exp"] = time.Now().Add(time.Hour * 6).Unix()
+	// the date is kept to the millisecond so a token issued right before a password change is refused
+	permissions["iat"] = float64(now.UnixNano()/int64(time.Millisecond)) / 1000
+	permissions["exp"] = expiresAt.Unix()
+	permissions["jti"] = tokenID
 	permissions["user_id"] = userID
 	permissions["permissions"] = permissionsByType[userType]
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissions)
-	return token.SignedString([]byte(config.SECRETKEY))
+	if len(sessionID) > 0 {
+		permissions["sid"] = sessionID
+	}
+
+	signedToken, err := signToken(permissions, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessToken{Token: signedToken, ID: tokenID, ExpiresAt: expiresAt}, nil
 }
 
 func ValidateToken(c *gin.Context) error {
@@ -32,13 +79,51 @@ func ValidateToken(c *gin.Context) error {
 		return err
 	}
 
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		// the tokens issued before the revocation list have no id, they can only expire
+		if tokenID, ok := claims["jti"].(string); ok && len(tokenID) > 0 {
+			revoked, errRevoked := repositories.TokenRepo.IsRevoked(tokenID)
+			if errRevoked != nil {
+				return errors.New(errRevoked.Message())
+			}
+
+			if revoked {
+				return errors.New("the token was revoked")
+			}
+		}
+
 		return nil
 	}
 
 	return errors.New("invalid token")
 }
 
+// ExtractTokenInfo returns the id, the session and the dates of the access token.
+func ExtractTokenInfo(c *gin.Context) (*TokenInfo, error) {
+	tokenString := extractToken(c)
+	token, err := jwt.Parse(tokenString, returnVerificationKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		info := &TokenInfo{}
+		info.ID, _ = claims["jti"].(string)
+		info.SessionID, _ = claims["sid"].(string)
+		if iat, ok := claims["iat"].(float64); ok {
+			info.IssuedAt = time.Unix(0, int64(math.Round(iat*1000))*int64(time.Millisecond))
+		}
+		if exp, ok := claims["exp"].(float64); ok {
+			info.ExpiresAt = time.Unix(int64(exp), 0)
+		}
+
+		return info, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
 func ExtractUserId(c *gin.Context) (uint64, error) {
 	tokenString := extractToken(c)
 	token, err := jwt.Parse(tokenString, returnVerificationKey)
@@ -92,10 +177,41 @@ func extractToken(c *gin.Context) string {
 	return ""
 }
 
+// signToken signs with the active key of the key set and names it in the kid header.
+func signToken(claims jwt.MapClaims, now time.Time) (string, error) {
+	if Keys == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.SECRETKEY))
+	}
+
+	key, err := Keys.Signing(now)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// returnVerificationKey picks the key named by the kid, the algorithm of the token has to be the one of the key.
 func returnVerificationKey(token *jwt.Token) (interface{}, error) {
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	if Keys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signature method! %v", token.Header["alg"])
+		}
+
+		return []byte(config.SECRETKEY), nil
+	}
+
+	keyID, _ := token.Header["kid"].(string)
+	key, ok := Keys.Get(keyID)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %v", token.Header["kid"])
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
 		return nil, fmt.Errorf("unexpected signature method! %v", token.Header["alg"])
 	}
 
-	return []byte(config.SECRETKEY), nil
+	return key.PublicKey(), nil
 }
@@ -2,7 +2,11 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
@@ -20,6 +24,42 @@ var (
 	GOOGLE_TOKEN_URI                   = ""
 	GOOGLE_AUTH_PROVIDER_x509_CERT_URL = ""
 	GOOGLE_CLIENT_x509_CERT_URL        = ""
+	TRASH_RETENTION                    = 30 * 24 * time.Hour
+	TRASH_PURGE_INTERVAL               = time.Hour
+	SLA_LOW                            = 7 * 24 * time.Hour
+	SLA_MEDIUM                         = 3 * 24 * time.Hour
+	SLA_HIGH                           = 24 * time.Hour
+	SLA_CRITICAL                       = 4 * time.Hour
+	OVERDUE_CHECK_INTERVAL             = 5 * time.Minute
+	SCHEDULE_LEAD_TIME                 = 24 * time.Hour
+	SCHEDULE_CHECK_INTERVAL            = time.Minute
+	STORAGE_DRIVER                     = "local"
+	STORAGE_LOCAL_PATH                 = "./uploads"
+	S3_ENDPOINT                        = ""
+	S3_REGION                          = ""
+	S3_BUCKET                          = ""
+	S3_ACCESS_KEY_ID                   = ""
+	S3_SECRET_ACCESS_KEY               = ""
+	ATTACHMENT_MAX_SIZE                = int64(10 << 20)
+	ATTACHMENT_ALLOWED_TYPES           = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"}
+	SELF_REGISTRATION                  = "technician"
+	INVITATION_TTL                     = 72 * time.Hour
+	INVITATION_MAX_TTL                 = 30 * 24 * time.Hour
+	INVITATION_URL                     = ""
+	MAILER_DRIVER                      = "log"
+	MAIL_FROM                          = "no-reply@localhost"
+	SMTP_HOST                          = ""
+	SMTP_PORT                          = "587"
+	SMTP_USERNAME                      = ""
+	SMTP_PASSWORD                      = ""
+	ACCESS_TOKEN_TTL                   = 15 * time.Minute
+	REFRESH_TOKEN_TTL                  = 30 * 24 * time.Hour
+	TOKEN_PURGE_INTERVAL               = time.Hour
+	JWT_SIGNING_KEYS                   = []string{}
+	PASSWORD_RESET_TTL                 = time.Hour
+	PASSWORD_RESET_COOLDOWN            = 5 * time.Minute
+	PASSWORD_RESET_URL                 = ""
+	ADMIN_EMAIL                        = ""
 )
 
 func LoadEnv() {
@@ -51,6 +91,50 @@ func LoadEnv() {
 		GOOGLE_TOKEN_URI = os.Getenv("GOOGLE_TOKEN_URI")
 		GOOGLE_AUTH_PROVIDER_x509_CERT_URL = os.Getenv("GOOGLE_AUTH_PROVIDER_x509_CERT_URL")
 		GOOGLE_CLIENT_x509_CERT_URL = os.Getenv("GOOGLE_CLIENT_x509_CERT_URL")
+
+		TRASH_RETENTION = getDuration("TRASH_RETENTION", TRASH_RETENTION)
+		TRASH_PURGE_INTERVAL = getDuration("TRASH_PURGE_INTERVAL", TRASH_PURGE_INTERVAL)
+
+		SLA_LOW = getDuration("SLA_LOW", SLA_LOW)
+		SLA_MEDIUM = getDuration("SLA_MEDIUM", SLA_MEDIUM)
+		SLA_HIGH = getDuration("SLA_HIGH", SLA_HIGH)
+		SLA_CRITICAL = getDuration("SLA_CRITICAL", SLA_CRITICAL)
+		OVERDUE_CHECK_INTERVAL = getDuration("OVERDUE_CHECK_INTERVAL", OVERDUE_CHECK_INTERVAL)
+
+		SCHEDULE_LEAD_TIME = getDuration("SCHEDULE_LEAD_TIME", SCHEDULE_LEAD_TIME)
+		SCHEDULE_CHECK_INTERVAL = getDuration("SCHEDULE_CHECK_INTERVAL", SCHEDULE_CHECK_INTERVAL)
+
+		STORAGE_DRIVER = getString("STORAGE_DRIVER", STORAGE_DRIVER)
+		STORAGE_LOCAL_PATH = getString("STORAGE_LOCAL_PATH", STORAGE_LOCAL_PATH)
+		S3_ENDPOINT = os.Getenv("S3_ENDPOINT")
+		S3_REGION = os.Getenv("S3_REGION")
+		S3_BUCKET = os.Getenv("S3_BUCKET")
+		S3_ACCESS_KEY_ID = os.Getenv("S3_ACCESS_KEY_ID")
+		S3_SECRET_ACCESS_KEY = os.Getenv("S3_SECRET_ACCESS_KEY")
+		ATTACHMENT_MAX_SIZE = getInt64("ATTACHMENT_MAX_SIZE", ATTACHMENT_MAX_SIZE)
+		ATTACHMENT_ALLOWED_TYPES = getList("ATTACHMENT_ALLOWED_TYPES", ATTACHMENT_ALLOWED_TYPES)
+
+		SELF_REGISTRATION = getString("SELF_REGISTRATION", SELF_REGISTRATION)
+		INVITATION_TTL = getDuration("INVITATION_TTL", INVITATION_TTL)
+		INVITATION_MAX_TTL = getDuration("INVITATION_MAX_TTL", INVITATION_MAX_TTL)
+		INVITATION_URL = os.Getenv("INVITATION_URL")
+		MAILER_DRIVER = getString("MAILER_DRIVER", MAILER_DRIVER)
+		MAIL_FROM = getString("MAIL_FROM", MAIL_FROM)
+		SMTP_HOST = os.Getenv("SMTP_HOST")
+		SMTP_PORT = getString("SMTP_PORT", SMTP_PORT)
+		SMTP_USERNAME = os.Getenv("SMTP_USERNAME")
+		SMTP_PASSWORD = os.Getenv("SMTP_PASSWORD")
+
+		ACCESS_TOKEN_TTL = getDuration("ACCESS_TOKEN_TTL", ACCESS_TOKEN_TTL)
+		REFRESH_TOKEN_TTL = getDuration("REFRESH_TOKEN_TTL", REFRESH_TOKEN_TTL)
+		TOKEN_PURGE_INTERVAL = getDuration("TOKEN_PURGE_INTERVAL", TOKEN_PURGE_INTERVAL)
+		JWT_SIGNING_KEYS = getList("JWT_SIGNING_KEYS", JWT_SIGNING_KEYS)
+
+		PASSWORD_RESET_TTL = getDuration("PASSWORD_RESET_TTL", PASSWORD_RESET_TTL)
+		PASSWORD_RESET_COOLDOWN = getDuration("PASSWORD_RESET_COOLDOWN", PASSWORD_RESET_COOLDOWN)
+		PASSWORD_RESET_URL = os.Getenv("PASSWORD_RESET_URL")
+
+		ADMIN_EMAIL = os.Getenv("ADMIN_EMAIL")
 	} else {
 		username = os.Getenv("TEST_DB_USER")
 		password = os.Getenv("TEST_DB_PASSWORD")
@@ -70,6 +154,50 @@ func LoadEnv() {
 		GOOGLE_TOKEN_URI = os.Getenv("TEST_GOOGLE_TOKEN_URI")
 		GOOGLE_AUTH_PROVIDER_x509_CERT_URL = os.Getenv("TEST_GOOGLE_AUTH_PROVIDER_x509_CERT_URL")
 		GOOGLE_CLIENT_x509_CERT_URL = os.Getenv("TEST_GOOGLE_CLIENT_x509_CERT_URL")
+
+		TRASH_RETENTION = getDuration("TEST_TRASH_RETENTION", TRASH_RETENTION)
+		TRASH_PURGE_INTERVAL = getDuration("TEST_TRASH_PURGE_INTERVAL", TRASH_PURGE_INTERVAL)
+
+		SLA_LOW = getDuration("TEST_SLA_LOW", SLA_LOW)
+		SLA_MEDIUM = getDuration("TEST_SLA_MEDIUM", SLA_MEDIUM)
+		SLA_HIGH = getDuration("TEST_SLA_HIGH", SLA_HIGH)
+		SLA_CRITICAL = getDuration("TEST_SLA_CRITICAL", SLA_CRITICAL)
+		OVERDUE_CHECK_INTERVAL = getDuration("TEST_OVERDUE_CHECK_INTERVAL", OVERDUE_CHECK_INTERVAL)
+
+		SCHEDULE_LEAD_TIME = getDuration("TEST_SCHEDULE_LEAD_TIME", SCHEDULE_LEAD_TIME)
+		SCHEDULE_CHECK_INTERVAL = getDuration("TEST_SCHEDULE_CHECK_INTERVAL", SCHEDULE_CHECK_INTERVAL)
+
+		STORAGE_DRIVER = getString("TEST_STORAGE_DRIVER", STORAGE_DRIVER)
+		STORAGE_LOCAL_PATH = getString("TEST_STORAGE_LOCAL_PATH", STORAGE_LOCAL_PATH)
+		S3_ENDPOINT = os.Getenv("TEST_S3_ENDPOINT")
+		S3_REGION = os.Getenv("TEST_S3_REGION")
+		S3_BUCKET = os.Getenv("TEST_S3_BUCKET")
+		S3_ACCESS_KEY_ID = os.Getenv("TEST_S3_ACCESS_KEY_ID")
+		S3_SECRET_ACCESS_KEY = os.Getenv("TEST_S3_SECRET_ACCESS_KEY")
+		ATTACHMENT_MAX_SIZE = getInt64("TEST_ATTACHMENT_MAX_SIZE", ATTACHMENT_MAX_SIZE)
+		ATTACHMENT_ALLOWED_TYPES = getList("TEST_ATTACHMENT_ALLOWED_TYPES", ATTACHMENT_ALLOWED_TYPES)
+
+		SELF_REGISTRATION = getString("TEST_SELF_REGISTRATION", SELF_REGISTRATION)
+		INVITATION_TTL = getDuration("TEST_INVITATION_TTL", INVITATION_TTL)
+		INVITATION_MAX_TTL = getDuration("TEST_INVITATION_MAX_TTL", INVITATION_MAX_TTL)
+		INVITATION_URL = os.Getenv("TEST_INVITATION_URL")
+		MAILER_DRIVER = getString("TEST_MAILER_DRIVER", MAILER_DRIVER)
+		MAIL_FROM = getString("TEST_MAIL_FROM", MAIL_FROM)
+		SMTP_HOST = os.Getenv("TEST_SMTP_HOST")
+		SMTP_PORT = getString("TEST_SMTP_PORT", SMTP_PORT)
+		SMTP_USERNAME = os.Getenv("TEST_SMTP_USERNAME")
+		SMTP_PASSWORD = os.Getenv("TEST_SMTP_PASSWORD")
+
+		ACCESS_TOKEN_TTL = getDuration("TEST_ACCESS_TOKEN_TTL", ACCESS_TOKEN_TTL)
+		REFRESH_TOKEN_TTL = getDuration("TEST_REFRESH_TOKEN_TTL", REFRESH_TOKEN_TTL)
+		TOKEN_PURGE_INTERVAL = getDuration("TEST_TOKEN_PURGE_INTERVAL", TOKEN_PURGE_INTERVAL)
+		JWT_SIGNING_KEYS = getList("TEST_JWT_SIGNING_KEYS", JWT_SIGNING_KEYS)
+
+		PASSWORD_RESET_TTL = getDuration("TEST_PASSWORD_RESET_TTL", PASSWORD_RESET_TTL)
+		PASSWORD_RESET_COOLDOWN = getDuration("TEST_PASSWORD_RESET_COOLDOWN", PASSWORD_RESET_COOLDOWN)
+		PASSWORD_RESET_URL = os.Getenv("TEST_PASSWORD_RESET_URL")
+
+		ADMIN_EMAIL = os.Getenv("TEST_ADMIN_EMAIL")
 	}
 
 	DBURL = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8&parseTime=True&loc=Local",
@@ -80,3 +208,70 @@ func LoadEnv() {
 		database,
 	)
 }
+
+// GetSLAByPriority returns how long a task of each priority has to be done after it is created.
+func GetSLAByPriority() map[string]time.Duration {
+	return map[string]time.Duration{
+		"low":      SLA_LOW,
+		"medium":   SLA_MEDIUM,
+		"high":     SLA_HIGH,
+		"critical": SLA_CRITICAL,
+	}
+}
+
+// getDuration reads a duration like 720h from the environment, keeping the default when it's missing or invalid.
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("the %s value %s is not a valid duration, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return duration
+}
+
+func getString(key string, defaultValue string) string {
+	if value := os.Getenv(key); len(value) > 0 {
+		return value
+	}
+
+	return defaultValue
+}
+
+// getInt64 reads a number from the environment, keeping the default when it's missing or invalid.
+func getInt64(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return defaultValue
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("the %s value %s is not a valid number, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return number
+}
+
+// getList reads a comma separated list from the environment, keeping the default when it's missing.
+func getList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return defaultValue
+	}
+
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
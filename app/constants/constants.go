@@ -2,7 +2,8 @@ package constants
 
 func GetSupportedPermissionsByUserType() map[string][]string {
 	return map[string][]string{
-		"Technician": {"create", "update", "get_one", "list_own_tasks"},
-		"Manager": {"list", "delete", "notified"},
+		"Technician": {"create", "update", "get_one", "list_own_tasks", "update_status", "history", "comment", "attach", "checklist", "dependencies", "track_time", "tag", "view_assets", "view_locations", "view_templates"},
+		"Manager":    {"list", "delete", "notified", "update_status", "assign", "history", "restore", "comment", "attach", "checklist", "dependencies", "schedule", "track_time", "tag", "manage_tags", "assets", "view_assets", "locations", "view_locations", "templates", "view_templates", "users", "invite"},
+		"Admin":      {"list", "delete", "notified", "update_status", "assign", "history", "restore", "comment", "attach", "checklist", "dependencies", "schedule", "track_time", "tag", "manage_tags", "assets", "view_assets", "locations", "view_locations", "templates", "view_templates", "users", "manage_users", "invite"},
 	}
 }
\ No newline at end of file
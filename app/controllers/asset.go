@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateAsset(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canManageAssets, err := checkIsMethodAllowed("assets", c); err != nil || !canManageAssets {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to create an asset")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	var asset models.Asset
+	if err := c.ShouldBindJSON(&asset); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := asset.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	if errLocation := checkLocationExists(asset.LocationID); errLocation != nil {
+		c.JSON(errLocation.Status(), errLocation)
+		return
+	}
+
+	asset.ID = 0
+	asset.CreatedBy = userID
+
+	dbAsset, errCreateAsset := repositories.AssetRepo.Create(&asset)
+	if errCreateAsset != nil {
+		c.JSON(errCreateAsset.Status(), errCreateAsset)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbAsset)
+}
+
+func GetAssets(c *gin.Context) {
+	if canViewAssets, err := checkIsMethodAllowed("view_assets", c); err != nil || !canViewAssets {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the assets")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	assets, errAssets := repositories.AssetRepo.GetAll(c.Query("category"))
+	if errAssets != nil {
+		c.JSON(errAssets.Status(), errAssets)
+		return
+	}
+
+	list := make([]*models.Asset, len(assets))
+	for i := range assets {
+		list[i] = &assets[i]
+	}
+
+	if errStats := fillAssetStats(list...); errStats != nil {
+		c.JSON(errStats.Status(), errStats)
+		return
+	}
+
+	c.JSON(http.StatusOK, assets)
+}
+
+func GetAsset(c *gin.Context) {
+	if canViewAssets, err := checkIsMethodAllowed("view_assets", c); err != nil || !canViewAssets {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see an asset")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	assetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	dbAsset, errFindAsset := repositories.AssetRepo.Get(assetID)
+	if errFindAsset != nil {
+		c.JSON(errFindAsset.Status(), errFindAsset)
+		return
+	}
+
+	if errStats := fillAssetStats(dbAsset); errStats != nil {
+		c.JSON(errStats.Status(), errStats)
+		return
+	}
+
+	c.JSON(http.StatusOK, dbAsset)
+}
+
+func UpdateAsset(c *gin.Context) {
+	if canManageAssets, err := checkIsMethodAllowed("assets", c); err != nil || !canManageAssets {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to update an asset")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	assetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var asset models.Asset
+	if err := c.ShouldBindJSON(&asset); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := asset.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	if errLocation := checkLocationExists(asset.LocationID); errLocation != nil {
+		c.JSON(errLocation.Status(), errLocation)
+		return
+	}
+
+	dbAsset, errFindAsset := repositories.AssetRepo.Get(assetID)
+	if errFindAsset != nil {
+		c.JSON(errFindAsset.Status(), errFindAsset)
+		return
+	}
+
+	dbAsset.Name = asset.Name
+	dbAsset.SerialNumber = asset.SerialNumber
+	dbAsset.Location = asset.Location
+	dbAsset.LocationID = asset.LocationID
+	dbAsset.Category = asset.Category
+
+	updatedAsset, errUpdateAsset := repositories.AssetRepo.Update(dbAsset)
+	if errUpdateAsset != nil {
+		c.JSON(errUpdateAsset.Status(), errUpdateAsset)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedAsset)
+}
+
+func DeleteAsset(c *gin.Context) {
+	if canManageAssets, err := checkIsMethodAllowed("assets", c); err != nil || !canManageAssets {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to delete an asset")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	assetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if errDeleteAsset := repositories.AssetRepo.Delete(assetID); errDeleteAsset != nil {
+		c.JSON(errDeleteAsset.Status(), errDeleteAsset)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetAssetTasks returns the whole maintenance history of an asset, resolved tasks included.
+// A technician only sees their own tasks and a manager scoped to sites only the tasks located in them.
+func GetAssetTasks(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canViewAssets, err := checkIsMethodAllowed("view_assets", c); err != nil || !canViewAssets {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the tasks of an asset")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	assetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errFindAsset := repositories.AssetRepo.Get(assetID); errFindAsset != nil {
+		c.JSON(errFindAsset.Status(), errFindAsset)
+		return
+	}
+
+	query := models.TaskQuery{}
+	if canList, err := checkIsMethodAllowed("list", c); err != nil {
+		c.JSON(err.Status(), err)
+		return
+	} else if !canList {
+		query.UserID = userID
+	}
+
+	siteIDs, errSites := repositories.LocationRepo.GetUserSites(userID)
+	if errSites != nil {
+		c.JSON(errSites.Status(), errSites)
+		return
+	}
+	query.SiteIDs = siteIDs
+
+	tasks, errTasks := repositories.AssetRepo.Tasks(assetID, query)
+	if errTasks != nil {
+		c.JSON(errTasks.Status(), errTasks)
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// fillAssetStats sets when the assets were last serviced and how many of their tasks are still open.
+func fillAssetStats(assets ...*models.Asset) error_utils.MessageErr {
+	assetIDs := make([]uint64, len(assets))
+	for i, asset := range assets {
+		assetIDs[i] = asset.ID
+	}
+
+	stats, errStats := repositories.AssetRepo.Stats(assetIDs)
+	if errStats != nil {
+		return errStats
+	}
+
+	for _, asset := range assets {
+		asset.LastServicedAt = stats[asset.ID].LastServicedAt
+		asset.OpenTasks = stats[asset.ID].OpenTasks
+	}
+
+	return nil
+}
+
+// checkAssetExists makes sure a task is only linked to a registered asset.
+func checkAssetExists(assetID *uint64) error_utils.MessageErr {
+	if assetID == nil {
+		return nil
+	}
+
+	if _, errFindAsset := repositories.AssetRepo.Get(*assetID); errFindAsset != nil {
+		if errFindAsset.Status() == http.StatusNotFound {
+			return error_utils.NewBadRequestError(fmt.Sprintf("the asset %d doesn't exist", *assetID))
+		}
+		return errFindAsset
+	}
+
+	return nil
+}
@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/storage"
+	"api/app/utils/error_utils"
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is the room left for the multipart boundaries and headers around the file.
+const multipartOverhead = 1 << 20
+
+// sniffLength is how many bytes http.DetectContentType looks at.
+const sniffLength = 512
+
+const attachmentForbiddenMessage = "Not possible to access the files of a task that does not belong to you"
+
+var errAttachmentTooLarge = errors.New("attachment too large")
+
+func UploadAttachment(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canAttach, err := checkIsMethodAllowed("attach", c); err != nil || !canAttach {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to attach a file to a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, attachmentForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	// the file is streamed from the request to the storage, it is never held in memory as a whole
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.ATTACHMENT_MAX_SIZE+multipartOverhead)
+
+	multipartReader, err := c.Request.MultipartReader()
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError("the request should be a multipart form with a file field")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var filePart io.Reader
+	var fileName string
+	for {
+		part, err := multipartReader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			errBadRequest := error_utils.NewBadRequestError("the request should be a multipart form with a file field")
+			c.JSON(errBadRequest.Status(), errBadRequest)
+			return
+		}
+
+		if part.FormName() == "file" {
+			filePart, fileName = part, part.FileName()
+			break
+		}
+	}
+
+	if filePart == nil {
+		errBadRequest := error_utils.NewBadRequestError("the field file is required can't be empty")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	// the type is sniffed from the content, the one declared by the client can't be trusted
+	fileReader := bufio.NewReaderSize(filePart, sniffLength)
+	head, err := fileReader.Peek(sniffLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		errBadRequest := error_utils.NewBadRequestError("it's not possible to read the file")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if len(head) == 0 {
+		errBadRequest := error_utils.NewBadRequestError("the file is empty")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !isAllowedAttachmentType(contentType) {
+		errUnsupported := error_utils.NewUnsupportedMediaTypeError(fmt.Sprintf("the file type %s is not allowed, use one of %s", contentType, strings.Join(config.ATTACHMENT_ALLOWED_TYPES, ", ")))
+		c.JSON(errUnsupported.Status(), errUnsupported)
+		return
+	}
+
+	storageKey, err := newStorageKey(taskID)
+	if err != nil {
+		errInternal := error_utils.NewInternalServerError(err.Error())
+		c.JSON(errInternal.Status(), errInternal)
+		return
+	}
+
+	size, err := storage.Files.Put(c.Request.Context(), storageKey, &sizeLimitedReader{reader: fileReader, remaining: config.ATTACHMENT_MAX_SIZE}, contentType)
+	if err != nil {
+		if errors.Is(err, errAttachmentTooLarge) || strings.Contains(err.Error(), "request body too large") {
+			errTooLarge := error_utils.NewPayloadTooLargeError(fmt.Sprintf("the file is too large, the maximum size is %d bytes", config.ATTACHMENT_MAX_SIZE))
+			c.JSON(errTooLarge.Status(), errTooLarge)
+			return
+		}
+
+		errInternal := error_utils.NewInternalServerError(fmt.Sprintf("it's not possible to store the file: %s", err.Error()))
+		c.JSON(errInternal.Status(), errInternal)
+		return
+	}
+
+	attachment := models.Attachment{
+		TaskID:      taskID,
+		UploaderID:  userID,
+		FileName:    models.SanitizeFileName(fileName),
+		ContentType: contentType,
+		Size:        size,
+		StorageKey:  storageKey,
+	}
+
+	dbAttachment, errCreateAttachment := repositories.AttachmentRepo.Create(&attachment)
+	if errCreateAttachment != nil {
+		if err := storage.Files.Delete(c.Request.Context(), storageKey); err != nil {
+			log.Println("it's not possible to remove the orphan file", storageKey, err)
+		}
+		c.JSON(errCreateAttachment.Status(), errCreateAttachment)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbAttachment)
+}
+
+func GetAttachments(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canAttach, err := checkIsMethodAllowed("attach", c); err != nil || !canAttach {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the files of a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, attachmentForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	attachments, errAttachments := repositories.AttachmentRepo.GetAllByTaskID(taskID)
+	if errAttachments != nil {
+		c.JSON(errAttachments.Status(), errAttachments)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+func DownloadAttachment(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canAttach, err := checkIsMethodAllowed("attach", c); err != nil || !canAttach {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to download the files of a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	dbAttachment, errAttachment := getTaskAttachment(userID, c)
+	if errAttachment != nil {
+		c.JSON(errAttachment.Status(), errAttachment)
+		return
+	}
+
+	content, err := storage.Files.Get(c.Request.Context(), dbAttachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			errNotFound := error_utils.NewNotFoundError("the content of the file is no longer available")
+			c.JSON(errNotFound.Status(), errNotFound)
+			return
+		}
+
+		errInternal := error_utils.NewInternalServerError(fmt.Sprintf("it's not possible to read the file: %s", err.Error()))
+		c.JSON(errInternal.Status(), errInternal)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, dbAttachment.Size, dbAttachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": dbAttachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func DeleteAttachment(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canAttach, err := checkIsMethodAllowed("attach", c); err != nil || !canAttach {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to delete the files of a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	dbAttachment, errAttachment := getTaskAttachment(userID, c)
+	if errAttachment != nil {
+		c.JSON(errAttachment.Status(), errAttachment)
+		return
+	}
+
+	// managers can remove any file, everybody else only the ones they uploaded
+	if canGetList, err := checkIsMethodAllowed("list", c); dbAttachment.UploaderID != userID && (err != nil || !canGetList) {
+		errForbidden := error_utils.NewForbiddenError("Only the uploader can delete a file")
+		c.JSON(errForbidden.Status(), errForbidden)
+		return
+	}
+
+	if errDeleteAttachment := repositories.AttachmentRepo.Delete(dbAttachment.ID); errDeleteAttachment != nil {
+		c.JSON(errDeleteAttachment.Status(), errDeleteAttachment)
+		return
+	}
+
+	if err := storage.Files.Delete(c.Request.Context(), dbAttachment.StorageKey); err != nil {
+		log.Println("it's not possible to remove the file", dbAttachment.StorageKey, err)
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// getTaskAttachment returns the attachment of the route when it belongs to its task and the user can reach the task.
+func getTaskAttachment(userID uint64, ctx *gin.Context) (*models.Attachment, error_utils.MessageErr) {
+	taskID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", ctx.Param("id")))
+	}
+
+	attachmentID, err := strconv.ParseUint(ctx.Param("attachmentId"), 10, 64)
+	if err != nil {
+		return nil, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", ctx.Param("attachmentId")))
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, ctx, attachmentForbiddenMessage); errTask != nil {
+		return nil, errTask
+	}
+
+	dbAttachment, errFindAttachment := repositories.AttachmentRepo.Get(attachmentID)
+	if errFindAttachment != nil {
+		return nil, errFindAttachment
+	}
+
+	if dbAttachment.TaskID != taskID {
+		return nil, error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	return dbAttachment, nil
+}
+
+func isAllowedAttachmentType(contentType string) bool {
+	for _, allowed := range config.ATTACHMENT_ALLOWED_TYPES {
+		if strings.EqualFold(allowed, contentType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newStorageKey builds a random key so the uploaded file names never reach the storage.
+func newStorageKey(taskID uint64) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(random)), nil
+}
+
+// sizeLimitedReader fails with errAttachmentTooLarge as soon as more than the remaining bytes are read.
+type sizeLimitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (limited *sizeLimitedReader) Read(p []byte) (int, error) {
+	if limited.remaining < 0 {
+		return 0, errAttachmentTooLarge
+	}
+
+	if int64(len(p)) > limited.remaining+1 {
+		p = p[:limited.remaining+1]
+	}
+
+	n, err := limited.reader.Read(p)
+	limited.remaining -= int64(n)
+	if limited.remaining < 0 {
+		return n, errAttachmentTooLarge
+	}
+
+	return n, err
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const checklistForbiddenMessage = "Not possible to change the checklist of a task that does not belong to you"
+
+func GetChecklist(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canChecklist, err := checkIsMethodAllowed("checklist", c); err != nil || !canChecklist {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the checklist of a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, checklistForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	items, errItems := repositories.ChecklistRepo.GetAllByTaskID(taskID)
+	if errItems != nil {
+		c.JSON(errItems.Status(), errItems)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func CreateChecklistItem(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canChecklist, err := checkIsMethodAllowed("checklist", c); err != nil || !canChecklist {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to add an item to a checklist")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, checklistForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	var item models.ChecklistItem
+	if err := c.ShouldBindJSON(&item); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	item.ID = 0
+	item.TaskID = taskID
+	done := item.Done
+	item.Done, item.CompletedAt, item.CompletedBy = false, nil, nil
+	item.Apply(models.ChecklistItemUpdate{Done: &done}, userID)
+
+	if err := item.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	dbItem, errCreateItem := repositories.ChecklistRepo.Create(&item)
+	if errCreateItem != nil {
+		c.JSON(errCreateItem.Status(), errCreateItem)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbItem)
+}
+
+func UpdateChecklistItem(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canChecklist, err := checkIsMethodAllowed("checklist", c); err != nil || !canChecklist {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to update a checklist item")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	dbItem, errItem := getTaskChecklistItem(userID, c)
+	if errItem != nil {
+		c.JSON(errItem.Status(), errItem)
+		return
+	}
+
+	var update models.ChecklistItemUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	dbItem.Apply(update, userID)
+
+	if err := dbItem.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	updatedItem, errUpdateItem := repositories.ChecklistRepo.Update(dbItem)
+	if errUpdateItem != nil {
+		c.JSON(errUpdateItem.Status(), errUpdateItem)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedItem)
+}
+
+func DeleteChecklistItem(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canChecklist, err := checkIsMethodAllowed("checklist", c); err != nil || !canChecklist {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to delete a checklist item")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	dbItem, errItem := getTaskChecklistItem(userID, c)
+	if errItem != nil {
+		c.JSON(errItem.Status(), errItem)
+		return
+	}
+
+	if errDeleteItem := repositories.ChecklistRepo.Delete(dbItem.ID); errDeleteItem != nil {
+		c.JSON(errDeleteItem.Status(), errDeleteItem)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// getTaskChecklistItem returns the item of the route when it belongs to a task the user can act on.
+func getTaskChecklistItem(userID uint64, ctx *gin.Context) (*models.ChecklistItem, error_utils.MessageErr) {
+	taskID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", ctx.Param("id")))
+	}
+
+	itemID, err := strconv.ParseUint(ctx.Param("itemId"), 10, 64)
+	if err != nil {
+		return nil, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", ctx.Param("itemId")))
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, ctx, checklistForbiddenMessage); errTask != nil {
+		return nil, errTask
+	}
+
+	dbItem, errFindItem := repositories.ChecklistRepo.Get(itemID)
+	if errFindItem != nil {
+		return nil, errFindItem
+	}
+
+	if dbItem.TaskID != taskID {
+		return nil, error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	return dbItem, nil
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/message"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateComment(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canComment, err := checkIsMethodAllowed("comment", c); err != nil || !canComment {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to comment a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, "Not possible to comment a task that does not belong to you"); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	var comment models.Comment
+	if err := c.ShouldBindJSON(&comment); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	comment.ID = 0
+	comment.TaskID = taskID
+	comment.AuthorID = userID
+
+	if err := comment.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	dbComment, errCreateComment := repositories.CommentRepo.Create(&comment)
+	if errCreateComment != nil {
+		c.JSON(errCreateComment.Status(), errCreateComment)
+		return
+	}
+
+	//publish the message here
+	msg := fmt.Sprintf("The user %d commented on the task %d", userID, taskID)
+	message.Publish(c.Writer, config.GOOGLE_PROJECT_ID, config.GOOGLE_TOPIC_ID, msg)
+
+	c.JSON(http.StatusCreated, dbComment)
+}
+
+func GetComments(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canComment, err := checkIsMethodAllowed("comment", c); err != nil || !canComment {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the comments of a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, "Not possible to comment a task that does not belong to you"); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	comments, errComments := repositories.CommentRepo.GetAllByTaskID(taskID)
+	if errComments != nil {
+		c.JSON(errComments.Status(), errComments)
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+func UpdateComment(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canComment, err := checkIsMethodAllowed("comment", c); err != nil || !canComment {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to update a comment")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	dbComment, errComment := getOwnComment(userID, c)
+	if errComment != nil {
+		c.JSON(errComment.Status(), errComment)
+		return
+	}
+
+	var comment models.Comment
+	if err := c.ShouldBindJSON(&comment); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := comment.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	dbComment.Body = comment.Body
+
+	updatedComment, errUpdateComment := repositories.CommentRepo.Update(dbComment)
+	if errUpdateComment != nil {
+		c.JSON(errUpdateComment.Status(), errUpdateComment)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedComment)
+}
+
+func DeleteComment(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canComment, err := checkIsMethodAllowed("comment", c); err != nil || !canComment {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to delete a comment")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	dbComment, errComment := getOwnComment(userID, c)
+	if errComment != nil {
+		c.JSON(errComment.Status(), errComment)
+		return
+	}
+
+	if errDeleteComment := repositories.CommentRepo.Delete(dbComment.ID); errDeleteComment != nil {
+		c.JSON(errDeleteComment.Status(), errDeleteComment)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// getOwnComment returns the comment of the route when it belongs to its task and was written by the user.
+func getOwnComment(userID uint64, ctx *gin.Context) (*models.Comment, error_utils.MessageErr) {
+	taskID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", ctx.Param("id")))
+	}
+
+	commentID, err := strconv.ParseUint(ctx.Param("commentId"), 10, 64)
+	if err != nil {
+		return nil, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", ctx.Param("commentId")))
+	}
+
+	dbComment, errFindComment := repositories.CommentRepo.Get(commentID)
+	if errFindComment != nil {
+		return nil, errFindComment
+	}
+
+	if dbComment.TaskID != taskID {
+		return nil, error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	if dbComment.AuthorID != userID {
+		return nil, error_utils.NewForbiddenError("Only the author can change a comment")
+	}
+
+	return dbComment, nil
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/message"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dependencyForbiddenMessage = "Not possible to change the dependencies of a task that does not belong to you"
+
+func GetDependencies(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canLink, err := checkIsMethodAllowed("dependencies", c); err != nil || !canLink {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the dependencies of a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, dependencyForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	dependencies, errDependencies := repositories.DependencyRepo.GetAllByTaskID(taskID)
+	if errDependencies != nil {
+		c.JSON(errDependencies.Status(), errDependencies)
+		return
+	}
+
+	c.JSON(http.StatusOK, dependencies)
+}
+
+func LinkTask(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canLink, err := checkIsMethodAllowed("dependencies", c); err != nil || !canLink {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to link tasks")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, dependencyForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	var dependency models.TaskDependency
+	if err := c.ShouldBindJSON(&dependency); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	dependency.TaskID = taskID
+	dependency.CreatedBy = userID
+
+	if err := dependency.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	if _, errFindBlocker := repositories.TaskRepo.Get(dependency.BlockedByID); errFindBlocker != nil {
+		c.JSON(errFindBlocker.Status(), errFindBlocker)
+		return
+	}
+
+	dbDependency, errCreateDependency := repositories.DependencyRepo.Create(&dependency)
+	if errCreateDependency != nil {
+		c.JSON(errCreateDependency.Status(), errCreateDependency)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbDependency)
+}
+
+func UnlinkTask(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canLink, err := checkIsMethodAllowed("dependencies", c); err != nil || !canLink {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to unlink tasks")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	blockerID, err := strconv.ParseUint(c.Param("blockerId"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("blockerId")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, dependencyForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	if errDeleteDependency := repositories.DependencyRepo.Delete(taskID, blockerID); errDeleteDependency != nil {
+		c.JSON(errDeleteDependency.Status(), errDeleteDependency)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// markBlockedTasks flags the tasks that still wait on a pending blocker.
+func markBlockedTasks(tasks ...*models.Task) error_utils.MessageErr {
+	taskIDs := make([]uint64, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = task.ID
+	}
+
+	blocked, errBlocked := repositories.DependencyRepo.BlockedTaskIDs(taskIDs)
+	if errBlocked != nil {
+		return errBlocked
+	}
+
+	for _, task := range tasks {
+		task.Blocked = blocked[task.ID]
+	}
+
+	return nil
+}
+
+// markBlockedPage flags the blocked tasks of a listing page.
+func markBlockedPage(page *models.TaskPage) error_utils.MessageErr {
+	return markBlockedTasks(pageTasks(page)...)
+}
+
+// pageTasks points to the tasks of a listing page so they can be filled in place.
+func pageTasks(page *models.TaskPage) []*models.Task {
+	tasks := make([]*models.Task, len(page.Data))
+	for i := range page.Data {
+		tasks[i] = &page.Data[i]
+	}
+
+	return tasks
+}
+
+// publishUnblocked tells the tasks waiting on the resolved blocker that they can move on.
+func publishUnblocked(blocker *models.Task, ctx *gin.Context) {
+	taskIDs, errUnblocked := repositories.DependencyRepo.Unblocked(blocker.ID)
+	if errUnblocked != nil {
+		log.Println("it's not possible to find the tasks unblocked by", blocker.ID, errUnblocked.Message())
+		return
+	}
+
+	for _, taskID := range taskIDs {
+		msg := fmt.Sprintf("unblocked: the task %d is no longer blocked, the task %d is %s", taskID, blocker.ID, blocker.Status)
+		message.Publish(ctx.Writer, config.GOOGLE_PROJECT_ID, config.GOOGLE_TOPIC_ID, msg)
+	}
+}
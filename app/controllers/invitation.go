@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/mailer"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// the invitation isn't kept when its email takes longer than this to be accepted by the mailer
+const invitationSendTimeout = 15 * time.Second
+
+func CreateInvitation(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canInvite, err := checkIsMethodAllowed("invite", c); err != nil || !canInvite {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to invite a user")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	var request models.InvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := request.Prepare(time.Now(), config.INVITATION_TTL, config.INVITATION_MAX_TTL); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	if request.Type == "Admin" {
+		if canManageUsers, err := checkIsMethodAllowed("manage_users", c); err != nil || !canManageUsers {
+			if err != nil {
+				c.JSON(err.Status(), err)
+			} else {
+				errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to invite an Admin")
+				c.JSON(errForbidden.Status(), errForbidden)
+			}
+			return
+		}
+	}
+
+	invitation, token, err := request.NewInvitation(userID)
+	if err != nil {
+		errInternal := error_utils.NewInternalServerError(err.Error())
+		c.JSON(errInternal.Status(), errInternal)
+		return
+	}
+
+	dbInvitation, errCreateInvitation := repositories.InvitationRepo.Create(invitation, func(invitation *models.Invitation) error {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), invitationSendTimeout)
+		defer cancel()
+
+		subject, body := invitation.InvitationEmail(token, config.INVITATION_URL)
+		return mailer.Mail.Send(ctx, mailer.Message{To: invitation.Email, Subject: subject, Body: body})
+	})
+	if errCreateInvitation != nil {
+		c.JSON(errCreateInvitation.Status(), errCreateInvitation)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbInvitation)
+}
+
+func GetInvitations(c *gin.Context) {
+	if canInvite, err := checkIsMethodAllowed("invite", c); err != nil || !canInvite {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to list the invitations")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	invitations, errGetInvitations := repositories.InvitationRepo.GetAll()
+	if errGetInvitations != nil {
+		c.JSON(errGetInvitations.Status(), errGetInvitations)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// DeleteInvitation revokes an invitation, an accepted one can't be revoked.
+func DeleteInvitation(c *gin.Context) {
+	if canInvite, err := checkIsMethodAllowed("invite", c); err != nil || !canInvite {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to revoke an invitation")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	invitationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if errDeleteInvitation := repositories.InvitationRepo.Delete(invitationID); errDeleteInvitation != nil {
+		c.JSON(errDeleteInvitation.Status(), errDeleteInvitation)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// AcceptInvitation creates the user of the invitation, it is public since the token proves the invitation.
+func AcceptInvitation(c *gin.Context) {
+	var request models.InvitationAcceptRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := request.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	dbUser, errAcceptInvitation := repositories.InvitationRepo.Accept(request, time.Now())
+	if errAcceptInvitation != nil {
+		c.JSON(errAcceptInvitation.Status(), errAcceptInvitation)
+		return
+	}
+
+	dbUser.Password = ""
+	c.JSON(http.StatusCreated, dbUser)
+}
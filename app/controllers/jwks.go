@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public keys of the tokens, it is empty while the tokens are signed with the secret key.
+func GetJWKS(c *gin.Context) {
+	jwks := authentication.JWKS{Keys: []authentication.JWK{}}
+	if authentication.Keys != nil {
+		jwks = authentication.Keys.JWKS()
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
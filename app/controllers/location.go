@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateLocation(c *gin.Context) {
+	if canManageLocations, err := checkIsMethodAllowed("locations", c); err != nil || !canManageLocations {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to create a location")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	var location models.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := location.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	location.ID = 0
+
+	dbLocation, errCreateLocation := repositories.LocationRepo.Create(&location)
+	if errCreateLocation != nil {
+		c.JSON(errCreateLocation.Status(), errCreateLocation)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbLocation)
+}
+
+func GetLocations(c *gin.Context) {
+	if canViewLocations, err := checkIsMethodAllowed("view_locations", c); err != nil || !canViewLocations {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the locations")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	locations, errLocations := repositories.LocationRepo.GetAll()
+	if errLocations != nil {
+		c.JSON(errLocations.Status(), errLocations)
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+func GetLocation(c *gin.Context) {
+	if canViewLocations, err := checkIsMethodAllowed("view_locations", c); err != nil || !canViewLocations {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see a location")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	dbLocation, errFindLocation := repositories.LocationRepo.Get(locationID)
+	if errFindLocation != nil {
+		c.JSON(errFindLocation.Status(), errFindLocation)
+		return
+	}
+
+	c.JSON(http.StatusOK, dbLocation)
+}
+
+// UpdateLocation renames a location, its place in the hierarchy doesn't change.
+func UpdateLocation(c *gin.Context) {
+	if canManageLocations, err := checkIsMethodAllowed("locations", c); err != nil || !canManageLocations {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to update a location")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var location models.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	dbLocation, errFindLocation := repositories.LocationRepo.Get(locationID)
+	if errFindLocation != nil {
+		c.JSON(errFindLocation.Status(), errFindLocation)
+		return
+	}
+
+	dbLocation.Name = location.Name
+	if err := dbLocation.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	updatedLocation, errUpdateLocation := repositories.LocationRepo.Update(dbLocation)
+	if errUpdateLocation != nil {
+		c.JSON(errUpdateLocation.Status(), errUpdateLocation)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedLocation)
+}
+
+func DeleteLocation(c *gin.Context) {
+	if canManageLocations, err := checkIsMethodAllowed("locations", c); err != nil || !canManageLocations {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to delete a location")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if errDeleteLocation := repositories.LocationRepo.Delete(locationID); errDeleteLocation != nil {
+		c.JSON(errDeleteLocation.Status(), errDeleteLocation)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// SetUserSites scopes a manager to some sites, only a manager that isn't scoped himself can do it.
+func SetUserSites(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canManageLocations, err := checkIsMethodAllowed("locations", c); err != nil || !canManageLocations {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to scope a manager to sites")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	managerID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var request models.UserSitesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	request.Prepare()
+
+	callerSites, errCallerSites := repositories.LocationRepo.GetUserSites(userID)
+	if errCallerSites != nil {
+		c.JSON(errCallerSites.Status(), errCallerSites)
+		return
+	}
+
+	if len(callerSites) > 0 {
+		errForbidden := error_utils.NewForbiddenError("A manager scoped to sites can't change the sites of a manager")
+		c.JSON(errForbidden.Status(), errForbidden)
+		return
+	}
+
+	manager, errFindUser := repositories.UserRepo.Get(managerID)
+	if errFindUser != nil {
+		c.JSON(errFindUser.Status(), errFindUser)
+		return
+	}
+
+	if manager.Type != "Manager" {
+		errBadRequest := error_utils.NewBadRequestError("only a Manager can be scoped to sites")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if errSetSites := repositories.LocationRepo.SetUserSites(managerID, request.SiteIDs); errSetSites != nil {
+		c.JSON(errSetSites.Status(), errSetSites)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// checkLocationExists makes sure a task or an asset is only placed in a known location.
+func checkLocationExists(locationID *uint64) error_utils.MessageErr {
+	if locationID == nil {
+		return nil
+	}
+
+	if _, errFindLocation := repositories.LocationRepo.Get(*locationID); errFindLocation != nil {
+		if errFindLocation.Status() == http.StatusNotFound {
+			return error_utils.NewBadRequestError(fmt.Sprintf("the location %d doesn't exist", *locationID))
+		}
+		return errFindLocation
+	}
+
+	return nil
+}
@@ -1,13 +1,11 @@
 package controllers
 
 import (
-	"api/app/authentication"
 	"api/app/models"
 	"api/app/repositories"
 	"api/app/security"
 	"api/app/utils/error_utils"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -33,14 +31,17 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	token, err := authentication.CreateToken(dbUser.ID, dbUser.Type)
-	if err != nil {
-		errInternalServer := error_utils.NewInternalServerError(err.Error())
-		c.JSON(errInternalServer.Status(), errInternalServer)
+	if !dbUser.IsActive() {
+		errForbidden := error_utils.NewForbiddenError("the user is deactivated")
+		c.JSON(errForbidden.Status(), errForbidden)
 		return
 	}
 
-	userID := strconv.FormatUint(dbUser.ID, 10)
+	authenticationData, errSession := createSession(dbUser)
+	if errSession != nil {
+		c.JSON(errSession.Status(), errSession)
+		return
+	}
 
-	c.JSON(http.StatusOK, models.AuthenticationData{ID: userID, Token: token})
+	c.JSON(http.StatusOK, authenticationData)
 }
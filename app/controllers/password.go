@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/mailer"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/security"
+	"api/app/utils/error_utils"
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// the reset isn't kept when its email takes longer than this to be accepted by the mailer
+	passwordResetSendTimeout = 15 * time.Second
+	// the resets sent at the same time, the requests made while they are all busy are dropped
+	passwordResetSenders = 4
+)
+
+var passwordResetSlots = make(chan struct{}, passwordResetSenders)
+
+// ForgotPassword emails a reset token to the user, the answer is the same whether the email is known or not.
+func ForgotPassword(c *gin.Context) {
+	var request models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := request.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	dbUser, errGetByEmail := repositories.UserRepo.GetByEmail(request.Email)
+	if errGetByEmail != nil && errGetByEmail.Status() != http.StatusNotFound {
+		c.JSON(errGetByEmail.Status(), errGetByEmail)
+		return
+	}
+
+	// the email is sent in the background, so a known email doesn't take longer to answer than an unknown one
+	if dbUser != nil && dbUser.IsActive() {
+		select {
+		case passwordResetSlots <- struct{}{}:
+			go func() {
+				defer func() { <-passwordResetSlots }()
+				sendPasswordReset(dbUser)
+			}()
+		default:
+			log.Println("too many password resets are being sent, the reset of the user", dbUser.ID, "is dropped")
+		}
+	}
+
+	c.JSON(http.StatusAccepted, nil)
+}
+
+// sendPasswordReset creates the reset of the user and emails its token, nothing is sent while the previous reset is within the cooldown.
+// The failures are only logged since the request was already answered.
+func sendPasswordReset(user *models.User) {
+	now := time.Now()
+	reset, token, err := models.NewPasswordReset(user.ID, now.Add(config.PASSWORD_RESET_TTL))
+	if err != nil {
+		log.Println("it's not possible to create the password reset of the user", user.ID, err)
+		return
+	}
+
+	errCreateReset := repositories.PasswordRepo.CreateReset(reset, now.Add(-config.PASSWORD_RESET_COOLDOWN), func(reset *models.PasswordReset) error {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetSendTimeout)
+		defer cancel()
+
+		subject, body := reset.PasswordResetEmail(token, config.PASSWORD_RESET_URL)
+		return mailer.Mail.Send(ctx, mailer.Message{To: user.Email, Subject: subject, Body: body})
+	})
+	if errCreateReset != nil {
+		log.Println("it's not possible to create the password reset of the user", user.ID, errCreateReset.Message())
+	}
+}
+
+// ResetPassword sets the password with a reset token, the user is signed out of every session.
+func ResetPassword(c *gin.Context) {
+	var request models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := request.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	if _, errReset := repositories.PasswordRepo.Reset(models.HashPasswordResetToken(request.Token), request.Password, time.Now()); errReset != nil {
+		c.JSON(errReset.Status(), errReset)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ChangePassword sets a new password after checking the current one, the other sessions are closed and a new one is returned.
+func ChangePassword(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	var request models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := request.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	dbUser, errFindUser := repositories.UserRepo.Get(userID)
+	if errFindUser != nil {
+		c.JSON(errFindUser.Status(), errFindUser)
+		return
+	}
+
+	if err := security.VerifyPassword(dbUser.Password, request.CurrentPassword); err != nil {
+		errForbidden := error_utils.NewForbiddenError("the current password is wrong")
+		c.JSON(errForbidden.Status(), errForbidden)
+		return
+	}
+
+	hashedPassword, err := security.Hash(request.NewPassword)
+	if err != nil {
+		errInternal := error_utils.NewInternalServerError(err.Error())
+		c.JSON(errInternal.Status(), errInternal)
+		return
+	}
+
+	dbUser.SetPassword(string(hashedPassword), time.Now())
+	if _, errUpdate := repositories.UserRepo.Update(dbUser); errUpdate != nil {
+		c.JSON(errUpdate.Status(), errUpdate)
+		return
+	}
+
+	authenticationData, errSession := createSession(dbUser)
+	if errSession != nil {
+		c.JSON(errSession.Status(), errSession)
+		return
+	}
+
+	c.JSON(http.StatusOK, authenticationData)
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateSchedule(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canSchedule, err := checkIsMethodAllowed("schedule", c); err != nil || !canSchedule {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to create a schedule")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	var request models.ScheduleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	schedule := models.Schedule{CreatedBy: userID}
+	if err := schedule.Apply(request, time.Now()); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	if errAssignee := checkIsTechnician(schedule.AssigneeID, "a schedule can only be assigned to a Technician"); errAssignee != nil {
+		c.JSON(errAssignee.Status(), errAssignee)
+		return
+	}
+
+	dbSchedule, errCreateSchedule := repositories.ScheduleRepo.Create(&schedule)
+	if errCreateSchedule != nil {
+		c.JSON(errCreateSchedule.Status(), errCreateSchedule)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbSchedule)
+}
+
+func GetSchedules(c *gin.Context) {
+	if canSchedule, err := checkIsMethodAllowed("schedule", c); err != nil || !canSchedule {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the schedules")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	schedules, errSchedules := repositories.ScheduleRepo.GetAll()
+	if errSchedules != nil {
+		c.JSON(errSchedules.Status(), errSchedules)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func GetSchedule(c *gin.Context) {
+	if canSchedule, err := checkIsMethodAllowed("schedule", c); err != nil || !canSchedule {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see a schedule")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	scheduleID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	dbSchedule, errFindSchedule := repositories.ScheduleRepo.Get(scheduleID)
+	if errFindSchedule != nil {
+		c.JSON(errFindSchedule.Status(), errFindSchedule)
+		return
+	}
+
+	c.JSON(http.StatusOK, dbSchedule)
+}
+
+func UpdateSchedule(c *gin.Context) {
+	if canSchedule, err := checkIsMethodAllowed("schedule", c); err != nil || !canSchedule {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to update a schedule")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	scheduleID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var request models.ScheduleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	dbSchedule, errFindSchedule := repositories.ScheduleRepo.Get(scheduleID)
+	if errFindSchedule != nil {
+		c.JSON(errFindSchedule.Status(), errFindSchedule)
+		return
+	}
+
+	// the occurrences already created keep their task, the next ones follow the new recurrence
+	if err := dbSchedule.Apply(request, time.Now()); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	if errAssignee := checkIsTechnician(dbSchedule.AssigneeID, "a schedule can only be assigned to a Technician"); errAssignee != nil {
+		c.JSON(errAssignee.Status(), errAssignee)
+		return
+	}
+
+	updatedSchedule, errUpdateSchedule := repositories.ScheduleRepo.Update(dbSchedule)
+	if errUpdateSchedule != nil {
+		c.JSON(errUpdateSchedule.Status(), errUpdateSchedule)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedSchedule)
+}
+
+func DeleteSchedule(c *gin.Context) {
+	if canSchedule, err := checkIsMethodAllowed("schedule", c); err != nil || !canSchedule {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to delete a schedule")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	scheduleID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if errDeleteSchedule := repositories.ScheduleRepo.Delete(scheduleID); errDeleteSchedule != nil {
+		c.JSON(errDeleteSchedule.Status(), errDeleteSchedule)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
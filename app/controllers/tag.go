@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateTag(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canManageTags, err := checkIsMethodAllowed("manage_tags", c); err != nil || !canManageTags {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to create a tag")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	var tag models.Tag
+	if err := c.ShouldBindJSON(&tag); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := tag.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	tag.ID = 0
+	tag.CreatedBy = userID
+
+	dbTag, errCreateTag := repositories.TagRepo.Create(&tag)
+	if errCreateTag != nil {
+		c.JSON(errCreateTag.Status(), errCreateTag)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbTag)
+}
+
+// GetTags lists the tags with the number of tasks using them, technicians only count their own tasks.
+func GetTags(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canTag, err := checkIsMethodAllowed("tag", c); err != nil || !canTag {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the tags")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	// managers count the tags of every task of their sites
+	var siteIDs []uint64
+	if canList, err := checkIsMethodAllowed("list", c); err == nil && canList {
+		var errSites error_utils.MessageErr
+		if siteIDs, errSites = repositories.LocationRepo.GetUserSites(userID); errSites != nil {
+			c.JSON(errSites.Status(), errSites)
+			return
+		}
+		userID = 0
+	}
+
+	counts, errCounts := repositories.TagRepo.Counts(userID, siteIDs)
+	if errCounts != nil {
+		c.JSON(errCounts.Status(), errCounts)
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+func UpdateTag(c *gin.Context) {
+	if canManageTags, err := checkIsMethodAllowed("manage_tags", c); err != nil || !canManageTags {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to rename a tag")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var tag models.Tag
+	if err := c.ShouldBindJSON(&tag); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := tag.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	dbTag, errFindTag := repositories.TagRepo.Get(tagID)
+	if errFindTag != nil {
+		c.JSON(errFindTag.Status(), errFindTag)
+		return
+	}
+
+	dbTag.Name = tag.Name
+
+	updatedTag, errUpdateTag := repositories.TagRepo.Update(dbTag)
+	if errUpdateTag != nil {
+		c.JSON(errUpdateTag.Status(), errUpdateTag)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedTag)
+}
+
+func DeleteTag(c *gin.Context) {
+	if canManageTags, err := checkIsMethodAllowed("manage_tags", c); err != nil || !canManageTags {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to delete a tag")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if errDeleteTag := repositories.TagRepo.Delete(tagID); errDeleteTag != nil {
+		c.JSON(errDeleteTag.Status(), errDeleteTag)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// SetTaskTags replaces the tags of a task, an empty list removes them all.
+func SetTaskTags(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canTag, err := checkIsMethodAllowed("tag", c); err != nil || !canTag {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to tag a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var request models.TaskTagsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := request.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, "Not possible to tag a task that does not belong to you"); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	tags, errSetTags := repositories.TagRepo.SetTaskTags(taskID, request.TagIDs)
+	if errSetTags != nil {
+		c.JSON(errSetTags.Status(), errSetTags)
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// loadTaskTags fills the tags of the tasks.
+func loadTaskTags(tasks ...*models.Task) error_utils.MessageErr {
+	taskIDs := make([]uint64, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = task.ID
+	}
+
+	tagsByTask, errTags := repositories.TagRepo.GetByTaskIDs(taskIDs)
+	if errTags != nil {
+		return errTags
+	}
+
+	for _, task := range tasks {
+		task.Tags = tagsByTask[task.ID]
+	}
+
+	return nil
+}
@@ -7,13 +7,19 @@ import (
 	"api/app/models"
 	"api/app/repositories"
 	"api/app/utils/error_utils"
+	"api/app/utils/search_utils"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// searchSnippetRadius is how many bytes of context are kept around the first match of a search result.
+const searchSnippetRadius = 80
+
 func CreateTask(c *gin.Context) {
 	userID, err := authentication.ExtractUserId(c)
 	if err != nil {
@@ -40,6 +46,12 @@ func CreateTask(c *gin.Context) {
 	}
 
 	task.UserID = userID
+	task.AssigneeID = &userID
+	task.Status = models.TaskStatusOpen
+
+	if len(task.Priority) == 0 {
+		task.Priority = models.TaskPriorityMedium
+	}
 
 	if err := task.Prepare(); err != nil {
 		errPrepare := error_utils.NewBadRequestError(err.Error())
@@ -47,6 +59,22 @@ func CreateTask(c *gin.Context) {
 		return
 	}
 
+	if errAsset := checkAssetExists(task.AssetID); errAsset != nil {
+		c.JSON(errAsset.Status(), errAsset)
+		return
+	}
+
+	if errLocation := checkLocationExists(task.LocationID); errLocation != nil {
+		c.JSON(errLocation.Status(), errLocation)
+		return
+	}
+
+	// without an explicit due date the task gets the SLA of its priority
+	if task.DueAt == nil {
+		dueAt := time.Now().Add(config.GetSLAByPriority()[task.Priority])
+		task.DueAt = &dueAt
+	}
+
 	dbTask, errCreateTask := repositories.TaskRepo.Create(&task)
 
 	if errCreateTask != nil {
@@ -64,6 +92,7 @@ func CreateTask(c *gin.Context) {
 	)
 	message.Publish(c.Writer, config.GOOGLE_PROJECT_ID, config.GOOGLE_TOPIC_ID, msg)
 
+	c.Header("ETag", taskETag(dbTask))
 	c.JSON(http.StatusCreated, dbTask)
 }
 
@@ -102,7 +131,7 @@ func UpdateTask(c *gin.Context) {
 	fmt.Println(dbTask.UserID)
 	fmt.Println(userID)
 
-	if dbTask.UserID != userID {
+	if !dbTask.IsAssignedTo(userID) {
 		errForbidden := error_utils.NewForbiddenError("Not possible to update a task that does not belong to you")
 		c.JSON(errForbidden.Status(), errForbidden)
 		return
@@ -123,15 +152,200 @@ func UpdateTask(c *gin.Context) {
 		return
 	}
 
-	_, errUpdateTask := repositories.TaskRepo.Update(&task)
+	if errAsset := checkAssetExists(task.AssetID); errAsset != nil {
+		c.JSON(errAsset.Status(), errAsset)
+		return
+	}
+
+	if errLocation := checkLocationExists(task.LocationID); errLocation != nil {
+		c.JSON(errLocation.Status(), errLocation)
+		return
+	}
+
+	version, errIfMatch := parseIfMatch(c)
+	if errIfMatch != nil {
+		c.JSON(errIfMatch.Status(), errIfMatch)
+		return
+	}
+
+	if version != dbTask.Version {
+		errPrecondition := error_utils.NewPreconditionFailedError("the task was changed by someone else, get it again before changing it")
+		c.JSON(errPrecondition.Status(), errPrecondition)
+		return
+	}
+
+	task.ID = taskID
+	task.Version = version
+
+	updatedTask, errUpdateTask := repositories.TaskRepo.Update(&task, userID)
 	if errUpdateTask != nil {
 		c.JSON(errUpdateTask.Status(), errUpdateTask)
 		return
 	}
 
+	c.Header("ETag", taskETag(updatedTask))
 	c.JSON(http.StatusNoContent, nil)
 }
 
+func UpdateTaskStatus(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canUpdateStatus, err := checkIsMethodAllowed("update_status", c); err != nil || !canUpdateStatus {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to update the status of a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var statusUpdate models.TaskStatusUpdate
+	if err := c.ShouldBindJSON(&statusUpdate); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if !models.IsValidTaskStatus(statusUpdate.Status) {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("the status %s is not a valid task status", statusUpdate.Status))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	dbTask, errFindTask := getAccessibleTask(taskID, userID, c, "Not possible to change the status of a task that does not belong to you")
+
+	if errFindTask != nil {
+		c.JSON(errFindTask.Status(), errFindTask)
+		return
+	}
+
+	if err := dbTask.TransitionTo(statusUpdate.Status); err != nil {
+		errConflict := error_utils.NewConflictError(err.Error())
+		c.JSON(errConflict.Status(), errConflict)
+		return
+	}
+
+	if dbTask.Status == models.TaskStatusDone {
+		pending, errPending := repositories.ChecklistRepo.CountPendingMandatory(taskID)
+		if errPending != nil {
+			c.JSON(errPending.Status(), errPending)
+			return
+		}
+
+		if pending > 0 {
+			errConflict := error_utils.NewConflictError(fmt.Sprintf("the task still has %d mandatory checklist items unchecked", pending))
+			c.JSON(errConflict.Status(), errConflict)
+			return
+		}
+	}
+
+	updatedTask, errUpdateStatus := repositories.TaskRepo.UpdateStatus(dbTask, userID)
+	if errUpdateStatus != nil {
+		c.JSON(errUpdateStatus.Status(), errUpdateStatus)
+		return
+	}
+
+	if updatedTask.IsResolved() {
+		publishUnblocked(updatedTask, c)
+	}
+
+	c.Header("ETag", taskETag(updatedTask))
+	c.JSON(http.StatusOK, updatedTask)
+}
+
+func AssignTask(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canAssign, err := checkIsMethodAllowed("assign", c); err != nil || !canAssign {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to assign a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var assignment models.TaskAssignmentRequest
+	if err := c.ShouldBindJSON(&assignment); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if assignment.AssigneeID == 0 {
+		errBadRequest := error_utils.NewBadRequestError("the field assigneeId is required can't be empty")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	assignee, errFindUser := repositories.UserRepo.Get(assignment.AssigneeID)
+	if errFindUser != nil {
+		c.JSON(errFindUser.Status(), errFindUser)
+		return
+	}
+
+	if assignee.Type != "Technician" {
+		errBadRequest := error_utils.NewBadRequestError("a task can only be assigned to a Technician")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	dbTask, errFindTask := getAccessibleTask(taskID, userID, c, "Not possible to assign a task located outside of your sites")
+	if errFindTask != nil {
+		c.JSON(errFindTask.Status(), errFindTask)
+		return
+	}
+
+	previousAssignee := dbTask.UserID
+	if dbTask.AssigneeID != nil {
+		previousAssignee = *dbTask.AssigneeID
+	}
+
+	assignedTask, errAssign := repositories.TaskRepo.Assign(dbTask, assignee.ID, userID)
+	if errAssign != nil {
+		c.JSON(errAssign.Status(), errAssign)
+		return
+	}
+
+	//publish the message here
+	msg := fmt.Sprintf("The manager %d reassigned the task %d from the tech %d to the tech %d",
+		userID,
+		assignedTask.ID,
+		previousAssignee,
+		assignee.ID,
+	)
+	message.Publish(c.Writer, config.GOOGLE_PROJECT_ID, config.GOOGLE_TOPIC_ID, msg)
+
+	c.Header("ETag", taskETag(assignedTask))
+	c.JSON(http.StatusOK, assignedTask)
+}
+
 func GetTask(c *gin.Context) {
 	userID, err := authentication.ExtractUserId(c)
 	if err != nil {
@@ -164,12 +378,39 @@ func GetTask(c *gin.Context) {
 		return
 	}
 
-	if dbTask.UserID != userID {
+	if !dbTask.IsAssignedTo(userID) {
 		errForbidden := error_utils.NewForbiddenError("Not possible to see a task that does not belong to you")
 		c.JSON(errForbidden.Status(), errForbidden)
 		return
 	}
 
+	checklist, errChecklist := repositories.ChecklistRepo.GetAllByTaskID(taskID)
+	if errChecklist != nil {
+		c.JSON(errChecklist.Status(), errChecklist)
+		return
+	}
+
+	dbTask.Checklist = checklist
+	dbTask.Progress = models.ChecklistProgress(checklist)
+
+	if errBlocked := markBlockedTasks(dbTask); errBlocked != nil {
+		c.JSON(errBlocked.Status(), errBlocked)
+		return
+	}
+
+	if errTags := loadTaskTags(dbTask); errTags != nil {
+		c.JSON(errTags.Status(), errTags)
+		return
+	}
+
+	timeSpent, errTimeSpent := repositories.WorkLogRepo.TotalByTaskID(taskID)
+	if errTimeSpent != nil {
+		c.JSON(errTimeSpent.Status(), errTimeSpent)
+		return
+	}
+	dbTask.TimeSpentSeconds = &timeSpent
+
+	c.Header("ETag", taskETag(dbTask))
 	c.JSON(http.StatusOK, dbTask)
 }
 
@@ -191,12 +432,41 @@ func GetTasksByUser(c *gin.Context) {
 		return
 	}
 
-	dbTasks := repositories.TaskRepo.GetAllByUserID(userID)
+	query, errQuery := parseTaskQuery(c)
+	if errQuery != nil {
+		c.JSON(errQuery.Status(), errQuery)
+		return
+	}
+
+	query.UserID = userID
+
+	dbTasks, errList := repositories.TaskRepo.List(query)
+	if errList != nil {
+		c.JSON(errList.Status(), errList)
+		return
+	}
+
+	if errBlocked := markBlockedPage(dbTasks); errBlocked != nil {
+		c.JSON(errBlocked.Status(), errBlocked)
+		return
+	}
+
+	if errTags := loadTaskTags(pageTasks(dbTasks)...); errTags != nil {
+		c.JSON(errTags.Status(), errTags)
+		return
+	}
 
 	c.JSON(http.StatusOK, dbTasks)
 }
 
 func GetAllTasks(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
 	if canGetList, err := checkIsMethodAllowed("list", c); err != nil || !canGetList {
 		if err != nil {
 			c.JSON(err.Status(), err)
@@ -207,12 +477,300 @@ func GetAllTasks(c *gin.Context) {
 		return
 	}
 
-	dbTasks := repositories.TaskRepo.GetAll()
+	query, errQuery := parseListTaskQuery(c, userID)
+	if errQuery != nil {
+		c.JSON(errQuery.Status(), errQuery)
+		return
+	}
+
+	dbTasks, errList := repositories.TaskRepo.List(query)
+	if errList != nil {
+		c.JSON(errList.Status(), errList)
+		return
+	}
+
+	if errBlocked := markBlockedPage(dbTasks); errBlocked != nil {
+		c.JSON(errBlocked.Status(), errBlocked)
+		return
+	}
+
+	if errTags := loadTaskTags(pageTasks(dbTasks)...); errTags != nil {
+		c.JSON(errTags.Status(), errTags)
+		return
+	}
+
+	c.JSON(http.StatusOK, dbTasks)
+}
+
+func GetSLABreaches(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canGetList, err := checkIsMethodAllowed("list", c); err != nil || !canGetList {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the SLA breaches")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	siteIDs, errSites := repositories.LocationRepo.GetUserSites(userID)
+	if errSites != nil {
+		c.JSON(errSites.Status(), errSites)
+		return
+	}
+
+	breaches, errBreaches := repositories.TaskRepo.SLABreaches(time.Now(), siteIDs)
+	if errBreaches != nil {
+		c.JSON(errBreaches.Status(), errBreaches)
+		return
+	}
+
+	c.JSON(http.StatusOK, breaches)
+}
+
+func GetTaskHistory(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canSeeHistory, err := checkIsMethodAllowed("history", c); err != nil || !canSeeHistory {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the history of a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	// managers can see the history of deleted tasks located in their sites, everybody else needs the task to still exist and be theirs
+	canGetList, errList := checkIsMethodAllowed("list", c)
+	if errList != nil {
+		c.JSON(errList.Status(), errList)
+		return
+	}
+
+	var siteIDs []uint64
+	if canGetList {
+		var errSites error_utils.MessageErr
+		if siteIDs, errSites = repositories.LocationRepo.GetUserSites(userID); errSites != nil {
+			c.JSON(errSites.Status(), errSites)
+			return
+		}
+	} else {
+		dbTask, errFindTask := repositories.TaskRepo.Get(taskID)
+		if errFindTask != nil {
+			c.JSON(errFindTask.Status(), errFindTask)
+			return
+		}
+
+		if !dbTask.IsAssignedTo(userID) {
+			errForbidden := error_utils.NewForbiddenError("Not possible to see the history of a task that does not belong to you")
+			c.JSON(errForbidden.Status(), errForbidden)
+			return
+		}
+	}
+
+	events, errHistory := repositories.TaskRepo.History(taskID, siteIDs)
+	if errHistory != nil {
+		c.JSON(errHistory.Status(), errHistory)
+		return
+	}
+
+	if len(events) == 0 {
+		errNotFound := error_utils.NewNotFoundError("no record matching given the identification")
+		c.JSON(errNotFound.Status(), errNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+func SearchTasks(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	query := models.TaskSearchQuery{Text: c.Query("q")}
+
+	// managers search every task of their sites, technicians only the tasks assigned to them
+	if canGetList, err := checkIsMethodAllowed("list", c); err != nil || !canGetList {
+		if err != nil {
+			c.JSON(err.Status(), err)
+			return
+		}
+
+		if canGetYourOwnTasks, err := checkIsMethodAllowed("list_own_tasks", c); err != nil || !canGetYourOwnTasks {
+			if err != nil {
+				c.JSON(err.Status(), err)
+			} else {
+				errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to search tasks")
+				c.JSON(errForbidden.Status(), errForbidden)
+			}
+			return
+		}
+
+		query.UserID = userID
+	} else {
+		siteIDs, errSites := repositories.LocationRepo.GetUserSites(userID)
+		if errSites != nil {
+			c.JSON(errSites.Status(), errSites)
+			return
+		}
+		query.SiteIDs = siteIDs
+	}
+
+	if limit := c.Query("limit"); len(limit) > 0 {
+		pageSize, err := strconv.Atoi(limit)
+		if err != nil || pageSize <= 0 {
+			errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("the limit should be between 1 and %d", models.MaxTaskPageSize))
+			c.JSON(errBadRequest.Status(), errBadRequest)
+			return
+		}
+		query.Limit = pageSize
+	}
+
+	if err := query.Prepare(); err != nil {
+		errBadRequest := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	results, errSearch := repositories.TaskRepo.Search(query)
+	if errSearch != nil {
+		c.JSON(errSearch.Status(), errSearch)
+		return
+	}
+
+	tasks := make([]*models.Task, len(results))
+	for i := range results {
+		results[i].Snippet = search_utils.Snippet(results[i].Summary, query.Text, searchSnippetRadius)
+		tasks[i] = &results[i].Task
+	}
+
+	if errBlocked := markBlockedTasks(tasks...); errBlocked != nil {
+		c.JSON(errBlocked.Status(), errBlocked)
+		return
+	}
+
+	if errTags := loadTaskTags(tasks...); errTags != nil {
+		c.JSON(errTags.Status(), errTags)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+func GetTrashTasks(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canRestore, err := checkIsMethodAllowed("restore", c); err != nil || !canRestore {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the trash")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	query, errQuery := parseTaskQuery(c)
+	if errQuery != nil {
+		c.JSON(errQuery.Status(), errQuery)
+		return
+	}
+
+	siteIDs, errSites := repositories.LocationRepo.GetUserSites(userID)
+	if errSites != nil {
+		c.JSON(errSites.Status(), errSites)
+		return
+	}
+
+	query.Trashed = true
+	query.SiteIDs = siteIDs
+
+	dbTasks, errList := repositories.TaskRepo.List(query)
+	if errList != nil {
+		c.JSON(errList.Status(), errList)
+		return
+	}
 
 	c.JSON(http.StatusOK, dbTasks)
 }
 
+func RestoreTask(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canRestore, err := checkIsMethodAllowed("restore", c); err != nil || !canRestore {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to restore a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	siteIDs, errSites := repositories.LocationRepo.GetUserSites(userID)
+	if errSites != nil {
+		c.JSON(errSites.Status(), errSites)
+		return
+	}
+
+	restoredTask, errRestore := repositories.TaskRepo.Restore(taskID, userID, siteIDs)
+	if errRestore != nil {
+		c.JSON(errRestore.Status(), errRestore)
+		return
+	}
+
+	c.JSON(http.StatusOK, restoredTask)
+}
+
 func DeleteTasks(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
 	if canDelete, err := checkIsMethodAllowed("delete", c); err != nil || !canDelete {
 		if err != nil {
 			c.JSON(err.Status(), err)
@@ -230,7 +788,18 @@ func DeleteTasks(c *gin.Context) {
 		return
 	}
 
-	errDeleteTask := repositories.TaskRepo.Delete(taskID)
+	version, errIfMatch := parseIfMatch(c)
+	if errIfMatch != nil {
+		c.JSON(errIfMatch.Status(), errIfMatch)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, "Not possible to delete a task located outside of your sites"); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	errDeleteTask := repositories.TaskRepo.Delete(&models.Task{ID: taskID, Version: version}, userID)
 
 	if errDeleteTask != nil {
 		c.JSON(errDeleteTask.Status(), errDeleteTask)
@@ -258,3 +827,177 @@ func checkIsMethodAllowed(permission string, ctx *gin.Context) (bool, error_util
 
 	return isAllowed, nil
 }
+
+// canAccessTask reports whether the user may act on the task, managers with the list permission can act on any task located in their sites.
+func canAccessTask(task *models.Task, userID uint64, ctx *gin.Context) (bool, error_utils.MessageErr) {
+	if task.IsAssignedTo(userID) {
+		return true, nil
+	}
+
+	if canList, err := checkIsMethodAllowed("list", ctx); err != nil || !canList {
+		return false, err
+	}
+
+	siteIDs, errSites := repositories.LocationRepo.GetUserSites(userID)
+	if errSites != nil {
+		return false, errSites
+	}
+
+	return repositories.LocationRepo.IsInSites(task.LocationID, siteIDs)
+}
+
+// getAccessibleTask returns the task when the user can act on it, otherwise a forbidden error with the given message.
+func getAccessibleTask(taskID uint64, userID uint64, ctx *gin.Context, forbiddenMessage string) (*models.Task, error_utils.MessageErr) {
+	dbTask, errFindTask := repositories.TaskRepo.Get(taskID)
+	if errFindTask != nil {
+		return nil, errFindTask
+	}
+
+	canAccess, errAccess := canAccessTask(dbTask, userID, ctx)
+	if errAccess != nil {
+		return nil, errAccess
+	}
+
+	if !canAccess {
+		return nil, error_utils.NewForbiddenError(forbiddenMessage)
+	}
+
+	return dbTask, nil
+}
+
+// taskETag identifies the current version of a task, clients send it back in If-Match to change it.
+func taskETag(task *models.Task) string {
+	return fmt.Sprintf("\"%d\"", task.Version)
+}
+
+// parseIfMatch reads the task version expected by the client from the If-Match header.
+func parseIfMatch(ctx *gin.Context) (uint64, error_utils.MessageErr) {
+	ifMatch := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if len(ifMatch) == 0 {
+		return 0, error_utils.NewPreconditionRequiredError("the If-Match header with the ETag of the task is required")
+	}
+
+	version, err := strconv.ParseUint(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), "\""), 10, 64)
+	if err != nil || version == 0 {
+		return 0, error_utils.NewPreconditionFailedError(fmt.Sprintf("the If-Match header %s doesn't match the ETag of the task", ifMatch))
+	}
+
+	return version, nil
+}
+
+// parseTaskQuery reads the listing filters, sorting and pagination from the query string.
+func parseTaskQuery(c *gin.Context) (models.TaskQuery, error_utils.MessageErr) {
+	query := models.TaskQuery{
+		Status: c.Query("status"),
+		Text:   c.Query("q"),
+		Sort:   c.Query("sort"),
+	}
+
+	if from := c.Query("from"); len(from) > 0 {
+		fromDate, err := parseQueryDate(from, false)
+		if err != nil {
+			return query, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a date", from))
+		}
+		query.From = &fromDate
+	}
+
+	if to := c.Query("to"); len(to) > 0 {
+		toDate, err := parseQueryDate(to, true)
+		if err != nil {
+			return query, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a date", to))
+		}
+		query.To = &toDate
+	}
+
+	if overdue := c.Query("overdue"); len(overdue) > 0 {
+		isOverdue, err := strconv.ParseBool(overdue)
+		if err != nil {
+			return query, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a boolean", overdue))
+		}
+		query.Overdue = isOverdue
+	}
+
+	if limit := c.Query("limit"); len(limit) > 0 {
+		pageSize, err := strconv.Atoi(limit)
+		if err != nil || pageSize <= 0 {
+			return query, error_utils.NewBadRequestError(fmt.Sprintf("the limit should be between 1 and %d", models.MaxTaskPageSize))
+		}
+		query.Limit = pageSize
+	}
+
+	if tags := c.Query("tags"); len(tags) > 0 {
+		for _, tag := range strings.Split(tags, ",") {
+			tagID, err := strconv.ParseUint(strings.TrimSpace(tag), 10, 64)
+			if err != nil {
+				return query, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", tag))
+			}
+			query.TagIDs = append(query.TagIDs, tagID)
+		}
+		query.TagMode = c.Query("tagMode")
+	}
+
+	if location := c.Query("locationId"); len(location) > 0 {
+		locationID, err := strconv.ParseUint(location, 10, 64)
+		if err != nil {
+			return query, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", location))
+		}
+		query.LocationID = locationID
+	}
+
+	if cursor := c.Query("cursor"); len(cursor) > 0 {
+		taskCursor, err := models.DecodeTaskCursor(cursor)
+		if err != nil {
+			return query, error_utils.NewBadRequestError(err.Error())
+		}
+		query.Cursor = taskCursor
+	}
+
+	if err := query.Prepare(); err != nil {
+		return query, error_utils.NewBadRequestError(err.Error())
+	}
+
+	return query, nil
+}
+
+// parseListTaskQuery reads the filters of a manager listing every task, including the assignee filter and the sites the manager is scoped to.
+func parseListTaskQuery(c *gin.Context, userID uint64) (models.TaskQuery, error_utils.MessageErr) {
+	query, errQuery := parseTaskQuery(c)
+	if errQuery != nil {
+		return query, errQuery
+	}
+
+	if filter := c.Query("userId"); len(filter) > 0 {
+		filterUserID, err := strconv.ParseUint(filter, 10, 64)
+		if err != nil {
+			return query, error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", filter))
+		}
+		query.UserID = filterUserID
+	}
+
+	// a manager scoped to sites only sees the tasks located in those sites
+	siteIDs, errSites := repositories.LocationRepo.GetUserSites(userID)
+	if errSites != nil {
+		return query, errSites
+	}
+	query.SiteIDs = siteIDs
+
+	return query, nil
+}
+
+// parseQueryDate accepts RFC3339 timestamps or plain dates, a plain date used as an upper bound covers the whole day.
+func parseQueryDate(value string, endOfDay bool) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return date, err
+	}
+
+	if endOfDay {
+		date = date.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return date, nil
+}
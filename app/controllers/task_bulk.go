@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/message"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkTasks runs a batch of operations on tasks in a single transaction, every operation needs the permission of its
+// single task endpoint. The answer is a 200 when every operation succeeded, a 207 with the result of each one otherwise.
+func BulkTasks(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	var request models.TaskBulkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := request.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	for _, operation := range request.Operations {
+		if isAllowed, err := checkIsMethodAllowed(models.TaskBulkPermissions[operation.Action], c); err != nil || !isAllowed {
+			if err != nil {
+				c.JSON(err.Status(), err)
+			} else {
+				errForbidden := error_utils.NewForbiddenError(fmt.Sprintf("The user doesn't have the right permission to run the %s operation", operation.Action))
+				c.JSON(errForbidden.Status(), errForbidden)
+			}
+			return
+		}
+	}
+
+	// like the single task endpoints, only the managers that can list every task act on the tasks of others, within their sites
+	ownerID := userID
+	var siteIDs []uint64
+	if canList, err := checkIsMethodAllowed("list", c); err == nil && canList {
+		var errSites error_utils.MessageErr
+		if siteIDs, errSites = repositories.LocationRepo.GetUserSites(userID); errSites != nil {
+			c.JSON(errSites.Status(), errSites)
+			return
+		}
+		ownerID = 0
+	}
+
+	response, errBulk := repositories.TaskRepo.Bulk(request, userID, ownerID, siteIDs)
+	if errBulk != nil {
+		c.JSON(errBulk.Status(), errBulk)
+		return
+	}
+
+	if response.Committed {
+		publishBulkResults(response, userID, c)
+	}
+
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	c.JSON(status, response)
+}
+
+// publishBulkResults sends the messages the single task endpoints send for the operations that went through.
+func publishBulkResults(response *models.TaskBulkResponse, userID uint64, ctx *gin.Context) {
+	for _, result := range response.Results {
+		if result.Status != http.StatusOK || result.Task == nil {
+			continue
+		}
+
+		switch result.Action {
+		case models.TaskBulkActionAssign:
+			msg := fmt.Sprintf("The manager %d reassigned the task %d from the tech %d to the tech %d",
+				userID,
+				result.Task.ID,
+				*result.PreviousAssigneeID,
+				*result.Task.AssigneeID,
+			)
+			message.Publish(ctx.Writer, config.GOOGLE_PROJECT_ID, config.GOOGLE_TOPIC_ID, msg)
+		case models.TaskBulkActionUpdateStatus:
+			if result.Task.IsResolved() {
+				publishUnblocked(result.Task, ctx)
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// taskExportFlushEvery is the number of rows written before they are sent to the client.
+const taskExportFlushEvery = 500
+
+var taskExportContentTypes = map[string]string{
+	models.TaskExportFormatCSV:    "text/csv; charset=utf-8",
+	models.TaskExportFormatJSON:   "application/json; charset=utf-8",
+	models.TaskExportFormatNDJSON: "application/x-ndjson",
+}
+
+// taskExporter writes the rows of an export in one format.
+type taskExporter interface {
+	Begin() error
+	Write(row *models.TaskExportRow) error
+	Flush() error
+	End() error
+}
+
+// ExportTasks streams every task matching the listing filters as csv, json or ndjson, the rows go from the database
+// cursor straight to the response so the size of an export doesn't matter.
+func ExportTasks(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canExport, err := checkIsMethodAllowed("list", c); err != nil || !canExport {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errUnauthorized := error_utils.NewForbiddenError("The user doesn't have the right permission to export the tasks")
+			c.JSON(errUnauthorized.Status(), errUnauthorized)
+		}
+		return
+	}
+
+	format := c.DefaultQuery("format", models.TaskExportFormatCSV)
+	if !models.IsValidTaskExportFormat(format) {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("the format %s is not valid, use one of csv, json, ndjson", format))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	query, errQuery := parseListTaskQuery(c, userID)
+	if errQuery != nil {
+		c.JSON(errQuery.Status(), errQuery)
+		return
+	}
+
+	exporter := newTaskExporter(format, c.Writer)
+	started, rows := false, 0
+
+	// the headers are only sent with the first row, a query that fails before it still gets a JSON error
+	begin := func() error {
+		started = true
+		c.Header("Content-Type", taskExportContentTypes[format])
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks-%s.%s"`, time.Now().Format("20060102-150405"), format))
+		c.Status(http.StatusOK)
+		return exporter.Begin()
+	}
+
+	errExport := repositories.TaskRepo.Export(query, func(row *models.TaskExportRow) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+
+		if err := exporter.Write(row); err != nil {
+			return err
+		}
+
+		if rows++; rows%taskExportFlushEvery == 0 {
+			if err := exporter.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+
+	if errExport != nil {
+		if !started {
+			c.JSON(errExport.Status(), errExport)
+			return
+		}
+
+		// the status is already sent, the client gets a truncated file
+		log.Println("it's not possible to finish the export of the tasks after", rows, "rows", errExport.Message())
+		return
+	}
+
+	if !started {
+		if err := begin(); err != nil {
+			log.Println("it's not possible to write the export of the tasks", err)
+			return
+		}
+	}
+
+	if err := exporter.End(); err != nil {
+		log.Println("it's not possible to write the export of the tasks", err)
+	}
+}
+
+func newTaskExporter(format string, writer io.Writer) taskExporter {
+	switch format {
+	case models.TaskExportFormatJSON:
+		return &jsonTaskExporter{writer: writer, encoder: json.NewEncoder(writer)}
+	case models.TaskExportFormatNDJSON:
+		return &ndjsonTaskExporter{encoder: json.NewEncoder(writer)}
+	}
+
+	return &csvTaskExporter{writer: csv.NewWriter(writer)}
+}
+
+type csvTaskExporter struct {
+	writer *csv.Writer
+}
+
+func (exporter *csvTaskExporter) Begin() error {
+	return exporter.writer.Write(models.TaskExportHeader)
+}
+
+func (exporter *csvTaskExporter) Write(row *models.TaskExportRow) error {
+	return exporter.writer.Write(row.CSVRecord())
+}
+
+func (exporter *csvTaskExporter) Flush() error {
+	exporter.writer.Flush()
+	return exporter.writer.Error()
+}
+
+func (exporter *csvTaskExporter) End() error {
+	return exporter.Flush()
+}
+
+// jsonTaskExporter writes a single array, one element at a time.
+type jsonTaskExporter struct {
+	writer  io.Writer
+	encoder *json.Encoder
+	written bool
+}
+
+func (exporter *jsonTaskExporter) Begin() error {
+	_, err := io.WriteString(exporter.writer, "[")
+	return err
+}
+
+func (exporter *jsonTaskExporter) Write(row *models.TaskExportRow) error {
+	if exporter.written {
+		if _, err := io.WriteString(exporter.writer, ","); err != nil {
+			return err
+		}
+	}
+	exporter.written = true
+
+	return exporter.encoder.Encode(row)
+}
+
+func (exporter *jsonTaskExporter) Flush() error {
+	return nil
+}
+
+func (exporter *jsonTaskExporter) End() error {
+	_, err := io.WriteString(exporter.writer, "]\n")
+	return err
+}
+
+// ndjsonTaskExporter writes one JSON object per line.
+type ndjsonTaskExporter struct {
+	encoder *json.Encoder
+}
+
+func (exporter *ndjsonTaskExporter) Begin() error {
+	return nil
+}
+
+func (exporter *ndjsonTaskExporter) Write(row *models.TaskExportRow) error {
+	return exporter.encoder.Encode(row)
+}
+
+func (exporter *ndjsonTaskExporter) Flush() error {
+	return nil
+}
+
+func (exporter *ndjsonTaskExporter) End() error {
+	return nil
+}
@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/message"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateTemplate(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canManageTemplates, err := checkIsMethodAllowed("templates", c); err != nil || !canManageTemplates {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to create a template")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	var template models.TaskTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := template.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	template.ID = 0
+	template.CreatedBy = userID
+
+	dbTemplate, errCreateTemplate := repositories.TemplateRepo.Create(&template)
+	if errCreateTemplate != nil {
+		c.JSON(errCreateTemplate.Status(), errCreateTemplate)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbTemplate)
+}
+
+func GetTemplates(c *gin.Context) {
+	if canViewTemplates, err := checkIsMethodAllowed("view_templates", c); err != nil || !canViewTemplates {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the templates")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	templates, errTemplates := repositories.TemplateRepo.GetAll()
+	if errTemplates != nil {
+		c.JSON(errTemplates.Status(), errTemplates)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func GetTemplate(c *gin.Context) {
+	if canViewTemplates, err := checkIsMethodAllowed("view_templates", c); err != nil || !canViewTemplates {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see a template")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	dbTemplate, errFindTemplate := repositories.TemplateRepo.Get(templateID)
+	if errFindTemplate != nil {
+		c.JSON(errFindTemplate.Status(), errFindTemplate)
+		return
+	}
+
+	c.JSON(http.StatusOK, dbTemplate)
+}
+
+// UpdateTemplate saves the new content as the next version of the template.
+func UpdateTemplate(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canManageTemplates, err := checkIsMethodAllowed("templates", c); err != nil || !canManageTemplates {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to update a template")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var template models.TaskTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := template.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	template.ID = templateID
+
+	updatedTemplate, errUpdateTemplate := repositories.TemplateRepo.Update(&template, userID)
+	if errUpdateTemplate != nil {
+		c.JSON(errUpdateTemplate.Status(), errUpdateTemplate)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedTemplate)
+}
+
+func DeleteTemplate(c *gin.Context) {
+	if canManageTemplates, err := checkIsMethodAllowed("templates", c); err != nil || !canManageTemplates {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to delete a template")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if errDeleteTemplate := repositories.TemplateRepo.Delete(templateID); errDeleteTemplate != nil {
+		c.JSON(errDeleteTemplate.Status(), errDeleteTemplate)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetTemplateVersions returns every version of the template, the latest first.
+func GetTemplateVersions(c *gin.Context) {
+	if canViewTemplates, err := checkIsMethodAllowed("view_templates", c); err != nil || !canViewTemplates {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the versions of a template")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errFindTemplate := repositories.TemplateRepo.Get(templateID); errFindTemplate != nil {
+		c.JSON(errFindTemplate.Status(), errFindTemplate)
+		return
+	}
+
+	versions, errVersions := repositories.TemplateRepo.Versions(templateID)
+	if errVersions != nil {
+		c.JSON(errVersions.Status(), errVersions)
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// CreateTaskFromTemplate creates a task out of the current version of a template, with its checklist and its tags.
+func CreateTaskFromTemplate(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canCreate, err := checkIsMethodAllowed("create", c); err != nil || !canCreate {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to create a task")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("templateId"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("templateId")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var request models.TaskFromTemplateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	dbTemplate, errFindTemplate := repositories.TemplateRepo.Get(templateID)
+	if errFindTemplate != nil {
+		c.JSON(errFindTemplate.Status(), errFindTemplate)
+		return
+	}
+
+	task, err := dbTemplate.NewTask(request, userID)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if err := task.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	if errAsset := checkAssetExists(task.AssetID); errAsset != nil {
+		c.JSON(errAsset.Status(), errAsset)
+		return
+	}
+
+	if errLocation := checkLocationExists(task.LocationID); errLocation != nil {
+		c.JSON(errLocation.Status(), errLocation)
+		return
+	}
+
+	// without an explicit due date the task gets the SLA of its priority
+	if task.DueAt == nil {
+		dueAt := time.Now().Add(config.GetSLAByPriority()[task.Priority])
+		task.DueAt = &dueAt
+	}
+
+	dbTask, errCreateTask := repositories.TemplateRepo.Instantiate(task, dbTemplate.TagIDs)
+	if errCreateTask != nil {
+		c.JSON(errCreateTask.Status(), errCreateTask)
+		return
+	}
+
+	//publish the message here
+	msg := fmt.Sprintf("The tech %d performed the task %d on date %d-%02d-%02d",
+		userID,
+		dbTask.ID,
+		dbTask.CreatedAt.Year(),
+		dbTask.CreatedAt.Month(),
+		dbTask.CreatedAt.Day(),
+	)
+	message.Publish(c.Writer, config.GOOGLE_PROJECT_ID, config.GOOGLE_TOPIC_ID, msg)
+
+	c.Header("ETag", taskETag(dbTask))
+	c.JSON(http.StatusCreated, dbTask)
+}
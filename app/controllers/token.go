@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshToken trades a refresh token for a new access token and a new refresh token, each refresh token works once.
+func RefreshToken(c *gin.Context) {
+	var request models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := request.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	now := time.Now()
+	var authenticationData models.AuthenticationData
+
+	errRotate := repositories.TokenRepo.Rotate(models.HashRefreshToken(request.RefreshToken), now, config.ACCESS_TOKEN_TTL, func(user *models.User, current *models.RefreshToken) (*models.RefreshToken, error) {
+		accessToken, err := authentication.CreateAccessToken(user.ID, user.Type, current.FamilyID)
+		if err != nil {
+			return nil, err
+		}
+
+		next, refreshToken, err := models.NewRefreshToken(user.ID, current.FamilyID, accessToken.ID, now.Add(config.REFRESH_TOKEN_TTL))
+		if err != nil {
+			return nil, err
+		}
+
+		authenticationData = models.AuthenticationData{
+			ID:           strconv.FormatUint(user.ID, 10),
+			Token:        accessToken.Token,
+			ExpiresAt:    &accessToken.ExpiresAt,
+			RefreshToken: refreshToken,
+		}
+		return next, nil
+	})
+	if errRotate != nil {
+		c.JSON(errRotate.Status(), errRotate)
+		return
+	}
+
+	c.JSON(http.StatusOK, authenticationData)
+}
+
+// Logout revokes the access token and the refresh tokens of its session.
+func Logout(c *gin.Context) {
+	tokenInfo, err := authentication.ExtractTokenInfo(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if len(tokenInfo.ID) > 0 {
+		if errRevoke := repositories.TokenRepo.Revoke(tokenInfo.ID, tokenInfo.ExpiresAt); errRevoke != nil {
+			c.JSON(errRevoke.Status(), errRevoke)
+			return
+		}
+	}
+
+	if len(tokenInfo.SessionID) > 0 {
+		if errRevoke := repositories.TokenRepo.RevokeSession(tokenInfo.SessionID, time.Now(), config.ACCESS_TOKEN_TTL); errRevoke != nil {
+			c.JSON(errRevoke.Status(), errRevoke)
+			return
+		}
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// createSession signs the user in, the refresh token starts a new family.
+func createSession(user *models.User) (*models.AuthenticationData, error_utils.MessageErr) {
+	sessionID, err := models.NewTokenID()
+	if err != nil {
+		return nil, error_utils.NewInternalServerError(err.Error())
+	}
+
+	accessToken, err := authentication.CreateAccessToken(user.ID, user.Type, sessionID)
+	if err != nil {
+		return nil, error_utils.NewInternalServerError(err.Error())
+	}
+
+	refreshToken, token, err := models.NewRefreshToken(user.ID, sessionID, accessToken.ID, time.Now().Add(config.REFRESH_TOKEN_TTL))
+	if err != nil {
+		return nil, error_utils.NewInternalServerError(err.Error())
+	}
+
+	if _, errCreate := repositories.TokenRepo.Create(refreshToken); errCreate != nil {
+		return nil, errCreate
+	}
+
+	return &models.AuthenticationData{
+		ID:           strconv.FormatUint(user.ID, 10),
+		Token:        accessToken.Token,
+		ExpiresAt:    &accessToken.ExpiresAt,
+		RefreshToken: token,
+	}, nil
+}
@@ -1,10 +1,15 @@
 package controllers
 
 import (
+	"api/app/authentication"
+	"api/app/config"
 	"api/app/models"
 	"api/app/repositories"
 	"api/app/utils/error_utils"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,6 +28,17 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	// the Managers and the Admins join through an invitation
+	if config.SELF_REGISTRATION == "disabled" {
+		errForbidden := error_utils.NewForbiddenError("the registration is closed, ask for an invitation")
+		c.JSON(errForbidden.Status(), errForbidden)
+		return
+	} else if user.Type != "Technician" {
+		errForbidden := error_utils.NewForbiddenError(fmt.Sprintf("a %s can't register, ask for an invitation", user.Type))
+		c.JSON(errForbidden.Status(), errForbidden)
+		return
+	}
+
 	dbUser, errCreateUser := repositories.UserRepo.Create(&user)
 
 	if errCreateUser != nil {
@@ -30,5 +46,315 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	dbUser.Password = ""
 	c.JSON(http.StatusCreated, dbUser)
 }
+
+func GetUsers(c *gin.Context) {
+	if canListUsers, err := checkIsMethodAllowed("users", c); err != nil || !canListUsers {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to list the users")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	query := models.UserQuery{Type: c.Query("type"), Text: c.Query("q")}
+
+	if active := c.Query("active"); len(active) > 0 {
+		isActive, err := strconv.ParseBool(active)
+		if err != nil {
+			errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a boolean", active))
+			c.JSON(errBadRequest.Status(), errBadRequest)
+			return
+		}
+		query.Active = &isActive
+	}
+
+	if limit := c.Query("limit"); len(limit) > 0 {
+		pageSize, err := strconv.Atoi(limit)
+		if err != nil || pageSize <= 0 {
+			errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("the limit should be between 1 and %d", models.MaxUserPageSize))
+			c.JSON(errBadRequest.Status(), errBadRequest)
+			return
+		}
+		query.Limit = pageSize
+	}
+
+	if cursor := c.Query("cursor"); len(cursor) > 0 {
+		userID, err := models.DecodeUserCursor(cursor)
+		if err != nil {
+			errBadRequest := error_utils.NewBadRequestError(err.Error())
+			c.JSON(errBadRequest.Status(), errBadRequest)
+			return
+		}
+		query.Cursor = userID
+	}
+
+	if err := query.Prepare(); err != nil {
+		errBadRequest := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	page, errList := repositories.UserRepo.List(query)
+	if errList != nil {
+		c.JSON(errList.Status(), errList)
+		return
+	}
+
+	for i := range page.Data {
+		page.Data[i].Password = ""
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetUser returns a user, everyone can get its own profile.
+func GetUser(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if targetID != userID {
+		if canGetUser, err := checkIsMethodAllowed("users", c); err != nil || !canGetUser {
+			if err != nil {
+				c.JSON(err.Status(), err)
+			} else {
+				errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to get another user")
+				c.JSON(errForbidden.Status(), errForbidden)
+			}
+			return
+		}
+	}
+
+	dbUser, errFindUser := repositories.UserRepo.Get(targetID)
+	if errFindUser != nil {
+		c.JSON(errFindUser.Status(), errFindUser)
+		return
+	}
+
+	dbUser.Password = ""
+	c.JSON(http.StatusOK, dbUser)
+}
+
+// UpdateUser changes a profile, everyone can change its own name and email, its password goes through ChangePassword.
+// A manager can change the name and email of a technician, an admin can change everything of anyone but its own type.
+func UpdateUser(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	var update models.UserUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	if err := update.Prepare(); err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	dbUser, errFindUser := repositories.UserRepo.Get(targetID)
+	if errFindUser != nil {
+		c.JSON(errFindUser.Status(), errFindUser)
+		return
+	}
+
+	canManageAll, errPermission := checkIsMethodAllowed("manage_users", c)
+	if errPermission != nil {
+		c.JSON(errPermission.Status(), errPermission)
+		return
+	}
+
+	if update.Type != nil && (!canManageAll || targetID == userID) {
+		errForbidden := error_utils.NewForbiddenError("Only an admin can change the type of another user")
+		c.JSON(errForbidden.Status(), errForbidden)
+		return
+	}
+
+	// without the current password a stolen token would be enough to take the account over
+	if update.Password != nil && targetID == userID {
+		errForbidden := error_utils.NewForbiddenError("the own password is changed with PUT /v1/users/me/password, it needs the current one")
+		c.JSON(errForbidden.Status(), errForbidden)
+		return
+	}
+
+	if targetID != userID {
+		if isAllowed, err := canManageUser(dbUser, c); err != nil || !isAllowed {
+			if err != nil {
+				c.JSON(err.Status(), err)
+			} else {
+				errForbidden := error_utils.NewForbiddenError(fmt.Sprintf("The user doesn't have the right permission to change the user %d", targetID))
+				c.JSON(errForbidden.Status(), errForbidden)
+			}
+			return
+		}
+
+		if update.Password != nil && !canManageAll {
+			errForbidden := error_utils.NewForbiddenError("Only an admin can change the password of another user")
+			c.JSON(errForbidden.Status(), errForbidden)
+			return
+		}
+	}
+
+	update.Apply(dbUser)
+
+	updatedUser, errUpdate := repositories.UserRepo.Update(dbUser)
+	if errUpdate != nil {
+		c.JSON(errUpdate.Status(), errUpdate)
+		return
+	}
+
+	updatedUser.Password = ""
+	c.JSON(http.StatusOK, updatedUser)
+}
+
+// DeactivateUser stops the user from logging in, the tokens it already has are refused as well.
+func DeactivateUser(c *gin.Context) {
+	now := time.Now()
+	setUserDeactivatedAt(c, &now)
+}
+
+func ActivateUser(c *gin.Context) {
+	setUserDeactivatedAt(c, nil)
+}
+
+func setUserDeactivatedAt(c *gin.Context, deactivatedAt *time.Time) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if targetID == userID {
+		errBadRequest := error_utils.NewBadRequestError("a user can't deactivate or activate itself")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	dbUser, errFindUser := repositories.UserRepo.Get(targetID)
+	if errFindUser != nil {
+		c.JSON(errFindUser.Status(), errFindUser)
+		return
+	}
+
+	if isAllowed, err := canManageUser(dbUser, c); err != nil || !isAllowed {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError(fmt.Sprintf("The user doesn't have the right permission to change the user %d", targetID))
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	updatedUser, errUpdate := repositories.UserRepo.SetDeactivatedAt(targetID, deactivatedAt)
+	if errUpdate != nil {
+		c.JSON(errUpdate.Status(), errUpdate)
+		return
+	}
+
+	updatedUser.Password = ""
+	c.JSON(http.StatusOK, updatedUser)
+}
+
+func DeleteUser(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canDelete, err := checkIsMethodAllowed("manage_users", c); err != nil || !canDelete {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to delete a user")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if targetID == userID {
+		errBadRequest := error_utils.NewBadRequestError("a user can't delete itself")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if errDelete := repositories.UserRepo.Delete(targetID); errDelete != nil {
+		c.JSON(errDelete.Status(), errDelete)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// canManageUser reports whether the caller can change another user, admins can change anyone and managers the technicians.
+func canManageUser(target *models.User, ctx *gin.Context) (bool, error_utils.MessageErr) {
+	if canManageAll, err := checkIsMethodAllowed("manage_users", ctx); err != nil || canManageAll {
+		return canManageAll, err
+	}
+
+	canManageTechnicians, err := checkIsMethodAllowed("users", ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return canManageTechnicians && target.Type == "Technician", nil
+}
+
+// checkIsTechnician makes sure the user exists and is a technician, otherwise returns a bad request with the message.
+func checkIsTechnician(userID uint64, message string) error_utils.MessageErr {
+	user, errFindUser := repositories.UserRepo.Get(userID)
+	if errFindUser != nil {
+		return errFindUser
+	}
+
+	if user.Type != "Technician" {
+		return error_utils.NewBadRequestError(message)
+	}
+
+	return nil
+}
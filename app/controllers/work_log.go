@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const workLogForbiddenMessage = "Not possible to log time on a task that does not belong to you"
+
+func StartTimer(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canTrackTime, err := checkIsMethodAllowed("track_time", c); err != nil || !canTrackTime {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to start a timer")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, workLogForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	workLog := models.WorkLog{TaskID: taskID, TechnicianID: userID, StartedAt: time.Now()}
+
+	dbWorkLog, errStartTimer := repositories.WorkLogRepo.StartTimer(&workLog)
+	if errStartTimer != nil {
+		c.JSON(errStartTimer.Status(), errStartTimer)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbWorkLog)
+}
+
+func StopTimer(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canTrackTime, err := checkIsMethodAllowed("track_time", c); err != nil || !canTrackTime {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to stop a timer")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	// the note is optional so an empty body is fine
+	var stop models.TimerStop
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&stop); err != nil {
+			errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+			c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+			return
+		}
+	}
+
+	if len(stop.Note) > 1000 {
+		errBadRequest := error_utils.NewBadRequestError("the note is too long need to be less or equal to 1000 characters")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	dbWorkLog, errStopTimer := repositories.WorkLogRepo.StopTimer(taskID, userID, time.Now(), stop.Note)
+	if errStopTimer != nil {
+		c.JSON(errStopTimer.Status(), errStopTimer)
+		return
+	}
+
+	c.JSON(http.StatusOK, dbWorkLog)
+}
+
+func CreateWorkLog(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canTrackTime, err := checkIsMethodAllowed("track_time", c); err != nil || !canTrackTime {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to log time")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, workLogForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	var request models.WorkLogRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errUnprocessibleEntity := error_utils.NewUnprocessibleEntityError("it's not possible to convert the JSON into an object")
+		c.JSON(errUnprocessibleEntity.Status(), errUnprocessibleEntity)
+		return
+	}
+
+	// technicians log their own time, managers can log it for any technician
+	technicianID := userID
+	if request.TechnicianID != 0 && request.TechnicianID != userID {
+		if canList, err := checkIsMethodAllowed("list", c); err != nil || !canList {
+			errForbidden := error_utils.NewForbiddenError("Not possible to log time for another technician")
+			c.JSON(errForbidden.Status(), errForbidden)
+			return
+		}
+
+		if errTechnician := checkIsTechnician(request.TechnicianID, "the time can only be logged for a Technician"); errTechnician != nil {
+			c.JSON(errTechnician.Status(), errTechnician)
+			return
+		}
+		technicianID = request.TechnicianID
+	}
+
+	workLog, err := request.NewWorkLog(taskID, technicianID, time.Now())
+	if err != nil {
+		errPrepare := error_utils.NewBadRequestError(err.Error())
+		c.JSON(errPrepare.Status(), errPrepare)
+		return
+	}
+
+	dbWorkLog, errCreateWorkLog := repositories.WorkLogRepo.Create(workLog)
+	if errCreateWorkLog != nil {
+		c.JSON(errCreateWorkLog.Status(), errCreateWorkLog)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dbWorkLog)
+}
+
+func GetWorkLogs(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canTrackTime, err := checkIsMethodAllowed("track_time", c); err != nil || !canTrackTime {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see the work logs")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", c.Param("id")))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if _, errTask := getAccessibleTask(taskID, userID, c, workLogForbiddenMessage); errTask != nil {
+		c.JSON(errTask.Status(), errTask)
+		return
+	}
+
+	workLogs, errWorkLogs := repositories.WorkLogRepo.GetAllByTaskID(taskID)
+	if errWorkLogs != nil {
+		c.JSON(errWorkLogs.Status(), errWorkLogs)
+		return
+	}
+
+	c.JSON(http.StatusOK, workLogs)
+}
+
+func GetTimesheet(c *gin.Context) {
+	userID, err := authentication.ExtractUserId(c)
+	if err != nil {
+		errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+		c.JSON(errUnauthorized.Status(), errUnauthorized)
+		return
+	}
+
+	if canTrackTime, err := checkIsMethodAllowed("track_time", c); err != nil || !canTrackTime {
+		if err != nil {
+			c.JSON(err.Status(), err)
+		} else {
+			errForbidden := error_utils.NewForbiddenError("The user doesn't have the right permission to see a timesheet")
+			c.JSON(errForbidden.Status(), errForbidden)
+		}
+		return
+	}
+
+	timesheet := models.Timesheet{TechnicianID: userID}
+
+	// technicians see their own timesheet, managers the one of any technician
+	if technicianID := c.Query("userId"); len(technicianID) > 0 {
+		filterUserID, err := strconv.ParseUint(technicianID, 10, 64)
+		if err != nil {
+			errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a number", technicianID))
+			c.JSON(errBadRequest.Status(), errBadRequest)
+			return
+		}
+
+		if filterUserID != userID {
+			if canList, err := checkIsMethodAllowed("list", c); err != nil || !canList {
+				errForbidden := error_utils.NewForbiddenError("Not possible to see the timesheet of another user")
+				c.JSON(errForbidden.Status(), errForbidden)
+				return
+			}
+		}
+		timesheet.TechnicianID = filterUserID
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if len(from) == 0 || len(to) == 0 {
+		errBadRequest := error_utils.NewBadRequestError("the from and to dates are required")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if timesheet.From, err = parseQueryDate(from, false); err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a date", from))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if timesheet.To, err = parseQueryDate(to, true); err != nil {
+		errBadRequest := error_utils.NewBadRequestError(fmt.Sprintf("not possible to convert %s into a date", to))
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	if timesheet.To.Before(timesheet.From) {
+		errBadRequest := error_utils.NewBadRequestError("the from date should be before the to date")
+		c.JSON(errBadRequest.Status(), errBadRequest)
+		return
+	}
+
+	workLogs, errWorkLogs := repositories.WorkLogRepo.GetAllByTechnician(timesheet.TechnicianID, timesheet.From, timesheet.To)
+	if errWorkLogs != nil {
+		c.JSON(errWorkLogs.Status(), errWorkLogs)
+		return
+	}
+
+	timesheet.Entries = workLogs
+	for _, workLog := range workLogs {
+		timesheet.DurationSeconds += workLog.DurationSeconds
+	}
+
+	c.JSON(http.StatusOK, timesheet)
+}
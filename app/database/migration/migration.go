@@ -3,12 +3,28 @@ package migration
 import (
 	"api/app/database"
 	"api/app/models"
+	"log"
 )
 
+const taskSummaryFullTextIndex = "idx_tasks_summary_fulltext"
+
 func getModels() []interface{} {
-	return []interface{}{&models.User{}, &models.Task{}}
+	return []interface{}{&models.User{}, &models.Task{}, &models.TaskAssignment{}, &models.TaskEvent{}, &models.Comment{}, &models.Attachment{}, &models.ChecklistItem{}, &models.TaskDependency{}, &models.Schedule{}, &models.JobLease{}, &models.WorkLog{}, &models.Tag{}, &models.TaskTag{}, &models.Asset{}, &models.Location{}, &models.UserSite{}, &models.TaskTemplate{}, &models.TaskTemplateVersion{}, &models.Invitation{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.PasswordReset{}}
 }
 
 func AutoMigration() {
 	database.Database.AutoMigrate(getModels()...)
+
+	createFullTextIndexes()
+}
+
+// createFullTextIndexes adds the MySQL FULLTEXT indexes, AutoMigrate can't declare them through the struct tags.
+func createFullTextIndexes() {
+	if database.Database.Migrator().HasIndex(&models.Task{}, taskSummaryFullTextIndex) {
+		return
+	}
+
+	if err := database.Database.Exec("CREATE FULLTEXT INDEX " + taskSummaryFullTextIndex + " ON tasks (summary)").Error; err != nil {
+		log.Println("it's not possible to create the full text index on the tasks summary", err)
+	}
 }
@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// every runs the job right away and then at each interval until the context is cancelled.
+func every(ctx context.Context, interval time.Duration, job func()) {
+	job()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job()
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"api/app/config"
+	"api/app/message"
+	"api/app/repositories"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// StartOverdueCheck periodically publishes an overdue event for the tasks that missed their due date.
+func StartOverdueCheck(ctx context.Context) {
+	go every(ctx, config.OVERDUE_CHECK_INTERVAL, CheckOverdueTasks)
+}
+
+func CheckOverdueTasks() {
+	tasks, err := repositories.TaskRepo.MarkOverdue(time.Now())
+	if err != nil {
+		log.Println("it's not possible to check the overdue tasks", err.Message())
+		return
+	}
+
+	for _, task := range tasks {
+		technicianID := task.UserID
+		if task.AssigneeID != nil {
+			technicianID = *task.AssigneeID
+		}
+
+		msg := fmt.Sprintf("overdue: the task %d of the tech %d with priority %s was due on %s",
+			task.ID,
+			technicianID,
+			task.Priority,
+			task.DueAt.Format(time.RFC3339),
+		)
+		if err := message.Publish(log.Writer(), config.GOOGLE_PROJECT_ID, config.GOOGLE_TOPIC_ID, msg); err != nil {
+			log.Println("it's not possible to publish the overdue task", task.ID, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"api/app/config"
+	"api/app/message"
+	"api/app/repositories"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const scheduleLease = "schedules"
+
+// leaseHolder identifies this replica when it takes a job lease.
+var leaseHolder = func() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}()
+
+// StartScheduler periodically creates the tasks of the schedules whose next occurrence is within the lead time.
+func StartScheduler(ctx context.Context) {
+	go every(ctx, config.SCHEDULE_CHECK_INTERVAL, MaterializeSchedules)
+}
+
+func MaterializeSchedules() {
+	now := time.Now()
+
+	// a single replica runs the scheduler, another one takes over when its lease expires
+	acquired, err := repositories.LeaseRepo.Acquire(scheduleLease, leaseHolder, now, 3*config.SCHEDULE_CHECK_INTERVAL)
+	if err != nil {
+		log.Println("it's not possible to take the scheduler lease", err.Message())
+		return
+	}
+
+	if !acquired {
+		return
+	}
+
+	tasks, err := repositories.ScheduleRepo.Materialize(now, config.SCHEDULE_LEAD_TIME)
+	if err != nil {
+		log.Println("it's not possible to create the scheduled tasks", err.Message())
+		return
+	}
+
+	for _, task := range tasks {
+		msg := fmt.Sprintf("scheduled: the task %d of the tech %d from the schedule %d is due on %s",
+			task.ID,
+			task.UserID,
+			*task.ScheduleID,
+			task.DueAt.Format(time.RFC3339),
+		)
+		if err := message.Publish(log.Writer(), config.GOOGLE_PROJECT_ID, config.GOOGLE_TOPIC_ID, msg); err != nil {
+			log.Println("it's not possible to publish the scheduled task", task.ID, err)
+		}
+	}
+}
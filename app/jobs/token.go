@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"api/app/config"
+	"api/app/repositories"
+	"context"
+	"log"
+	"time"
+)
+
+// StartTokenPurge periodically removes the refresh tokens and the revoked access tokens that expired.
+func StartTokenPurge(ctx context.Context) {
+	go every(ctx, config.TOKEN_PURGE_INTERVAL, PurgeTokens)
+}
+
+func PurgeTokens() {
+	purged, err := repositories.TokenRepo.Purge(time.Now())
+	if err != nil {
+		log.Println("it's not possible to purge the expired tokens", err.Message())
+		return
+	}
+
+	if purged > 0 {
+		log.Printf("%d expired tokens purged", purged)
+	}
+}
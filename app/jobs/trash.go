@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"api/app/config"
+	"api/app/repositories"
+	"context"
+	"log"
+	"time"
+)
+
+// StartTrashPurge periodically removes for good the tasks that stayed in the trash longer than the retention.
+func StartTrashPurge(ctx context.Context) {
+	go every(ctx, config.TRASH_PURGE_INTERVAL, PurgeTrash)
+}
+
+func PurgeTrash() {
+	purged, err := repositories.TaskRepo.PurgeTrash(time.Now().Add(-config.TRASH_RETENTION))
+	if err != nil {
+		log.Println("it's not possible to purge the trash", err.Message())
+		return
+	}
+
+	if purged > 0 {
+		log.Printf("%d tasks purged from the trash", purged)
+	}
+}
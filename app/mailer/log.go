@@ -0,0 +1,26 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer logs the emails instead of sending them, it is meant for development.
+// The body holds the invitation and reset tokens, it is only logged when showBody is set so the links can be followed locally.
+type LogMailer struct {
+	showBody bool
+}
+
+func NewLogMailer(showBody bool) *LogMailer {
+	return &LogMailer{showBody: showBody}
+}
+
+func (logMailer *LogMailer) Send(ctx context.Context, message Message) error {
+	if logMailer.showBody {
+		log.Printf("email to %s not sent, the log mailer is used: %s\n%s", message.To, message.Subject, message.Body)
+		return nil
+	}
+
+	log.Printf("email to %s not sent, the log mailer is used: %s", message.To, message.Subject)
+	return nil
+}
@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"api/app/config"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Mail is the mailer used to send the emails, it is set by Init from the configuration.
+var Mail Mailer
+
+var ErrInvalidHeader = errors.New("the email address or subject can't contain a line break")
+
+// Message is a plain text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers the emails, Send returns once the message was accepted for delivery.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+func Init() {
+	switch config.MAILER_DRIVER {
+	case "smtp":
+		Mail = NewSMTPMailer(SMTPOptions{
+			Host:     config.SMTP_HOST,
+			Port:     config.SMTP_PORT,
+			Username: config.SMTP_USERNAME,
+			Password: config.SMTP_PASSWORD,
+			From:     config.MAIL_FROM,
+		})
+	default:
+		// the links are only logged in development, the tokens must not end up in the logs of a deployed environment
+		Mail = NewLogMailer(config.ENV == "DEV")
+	}
+}
+
+// format writes the message with its headers, the header values are checked so a recipient or a subject can't add headers.
+func (message Message) format(from string, date time.Time) ([]byte, error) {
+	for _, value := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "From: %s\r\n", from)
+	fmt.Fprintf(&content, "To: %s\r\n", message.To)
+	fmt.Fprintf(&content, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&content, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	content.WriteString("MIME-Version: 1.0\r\n")
+	content.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	content.WriteString("\r\n")
+	content.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return content.Bytes(), nil
+}
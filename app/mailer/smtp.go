@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPOptions points to the SMTP server, without a username the emails are sent without authentication.
+type SMTPOptions struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer sends the emails through an SMTP server, the connection is upgraded with STARTTLS when the server offers it.
+type SMTPMailer struct {
+	options SMTPOptions
+}
+
+func NewSMTPMailer(options SMTPOptions) *SMTPMailer {
+	return &SMTPMailer{options: options}
+}
+
+func (smtpMailer *SMTPMailer) Send(ctx context.Context, message Message) error {
+	content, err := message.format(smtpMailer.options.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(smtpMailer.options.Host, smtpMailer.options.Port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// the whole conversation shares the deadline of the context
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, smtpMailer.options.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: smtpMailer.options.Host}); err != nil {
+			return err
+		}
+	}
+
+	if len(smtpMailer.options.Username) > 0 {
+		auth := smtp.PlainAuth("", smtpMailer.options.Username, smtpMailer.options.Password, smtpMailer.options.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(smtpMailer.options.From); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(message.To); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -2,8 +2,10 @@ package middleware
 
 import (
 	"api/app/authentication"
+	"api/app/repositories"
 	"api/app/utils/error_utils"
 	"log"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,6 +25,52 @@ func AuthUser() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+
+		// the token outlives the user, a deactivated or deleted user can't use the tokens it still has
+		userID, err := authentication.ExtractUserId(c)
+		if err != nil {
+			errUnauthorized := error_utils.NewUnauthorizedError(err.Error())
+			c.JSON(errUnauthorized.Status(), errUnauthorized)
+			c.Abort()
+			return
+		}
+
+		// the deleted users are still read to tell them apart from the ones that don't exist at all
+		user, errFindUser := repositories.UserRepo.GetWithDeleted(userID)
+		if errFindUser != nil {
+			if errFindUser.Status() == http.StatusNotFound {
+				errFindUser = error_utils.NewUnauthorizedError("the user of the token doesn't exist anymore")
+			}
+			c.JSON(errFindUser.Status(), errFindUser)
+			c.Abort()
+			return
+		}
+
+		if user.DeletedAt.Valid {
+			errUnauthorized := error_utils.NewUnauthorizedError("the user was deleted")
+			c.JSON(errUnauthorized.Status(), errUnauthorized)
+			c.Abort()
+			return
+		}
+
+		if !user.IsActive() {
+			errUnauthorized := error_utils.NewUnauthorizedError("the user is deactivated")
+			c.JSON(errUnauthorized.Status(), errUnauthorized)
+			c.Abort()
+			return
+		}
+
+		// changing the password signs the user out everywhere
+		if user.PasswordChangedAt != nil {
+			tokenInfo, err := authentication.ExtractTokenInfo(c)
+			if err != nil || user.IssuedBeforePasswordChange(tokenInfo.IssuedAt) {
+				errUnauthorized := error_utils.NewUnauthorizedError("the password changed, sign in again")
+				c.JSON(errUnauthorized.Status(), errUnauthorized)
+				c.Abort()
+				return
+			}
+		}
+
 		c.Next()
 	}
 }
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Asset is a piece of equipment the tasks are about.
+type Asset struct {
+	ID             uint64     `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name           string     `gorm:"size:255;not null" json:"name"`
+	SerialNumber   string     `gorm:"size:100;not null;uniqueIndex" json:"serialNumber"`
+	Location       string     `gorm:"size:255" json:"location,omitempty"`
+	LocationID     *uint64    `gorm:"index" json:"locationId,omitempty"`
+	Category       string     `gorm:"size:100;index" json:"category,omitempty"`
+	CreatedBy      uint64     `json:"createdBy,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt      time.Time  `json:"modifiedAt,omitempty"`
+	LastServicedAt *time.Time `gorm:"-" json:"lastServicedAt,omitempty"`
+	OpenTasks      int64      `gorm:"-" json:"openTasks"`
+}
+
+// AssetStats sums up the maintenance of an asset.
+type AssetStats struct {
+	AssetID        uint64
+	LastServicedAt *time.Time
+	OpenTasks      int64
+}
+
+func (asset *Asset) Prepare() error {
+	asset.Name = strings.TrimSpace(asset.Name)
+	asset.SerialNumber = strings.TrimSpace(asset.SerialNumber)
+	asset.Location = strings.TrimSpace(asset.Location)
+	asset.Category = strings.TrimSpace(asset.Category)
+
+	if len(asset.Name) == 0 {
+		return errors.New("the field name is required can't be empty")
+	} else if len(asset.Name) > 255 {
+		return errors.New("the name is too long need to be less or equal to 255 characters")
+	}
+
+	if len(asset.SerialNumber) == 0 {
+		return errors.New("the field serialNumber is required can't be empty")
+	} else if len(asset.SerialNumber) > 100 {
+		return errors.New("the serial number is too long need to be less or equal to 100 characters")
+	}
+
+	if len(asset.Location) > 255 {
+		return errors.New("the location is too long need to be less or equal to 255 characters")
+	}
+
+	if len(asset.Category) > 100 {
+		return errors.New("the category is too long need to be less or equal to 100 characters")
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Attachment describes a file uploaded to a task, the content itself lives in the storage under StorageKey.
+type Attachment struct {
+	ID          uint64    `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	TaskID      uint64    `gorm:"not null;index" json:"taskId"`
+	UploaderID  uint64    `gorm:"not null" json:"uploaderId"`
+	FileName    string    `gorm:"size:255;not null" json:"fileName"`
+	ContentType string    `gorm:"size:100;not null" json:"contentType"`
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"size:255;not null;unique" json:"-"`
+	CreatedAt   time.Time `json:"createdAt,omitempty"`
+}
+
+// SanitizeFileName keeps only the base name of the uploaded file, without control characters or quotes.
+func SanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+
+	if name == "." || name == "/" || len(strings.TrimSpace(name)) == 0 {
+		return "attachment"
+	}
+
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+
+	return name
+}
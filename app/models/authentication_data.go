@@ -1,6 +1,10 @@
 package models
 
+import "time"
+
 type AuthenticationData struct {
-	ID    string `json:"id"`
-	Token string `json:"token"`
+	ID           string     `json:"id"`
+	Token        string     `json:"token"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	RefreshToken string     `json:"refreshToken,omitempty"`
 }
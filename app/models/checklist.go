@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ChecklistItem is one step of a task, mandatory steps must be checked before the task can be done.
+type ChecklistItem struct {
+	ID          uint64     `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	TaskID      uint64     `gorm:"not null;index" json:"taskId"`
+	Title       string     `gorm:"size:255;not null" json:"title"`
+	Position    int        `gorm:"not null;default:0" json:"position"`
+	Mandatory   bool       `gorm:"not null;default:false" json:"mandatory"`
+	Done        bool       `gorm:"not null;default:false" json:"done"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CompletedBy *uint64    `json:"completedBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt   time.Time  `json:"modifiedAt,omitempty"`
+}
+
+// ChecklistItemUpdate holds the fields to change on an item, the missing ones are kept.
+type ChecklistItemUpdate struct {
+	Title     *string `json:"title"`
+	Mandatory *bool   `json:"mandatory"`
+	Done      *bool   `json:"done"`
+	Position  *int    `json:"position"`
+}
+
+func (item *ChecklistItem) Prepare() error {
+	item.Title = strings.TrimSpace(item.Title)
+
+	if len(item.Title) == 0 {
+		return errors.New("the field title is required can't be empty")
+	} else if len(item.Title) > 255 {
+		return errors.New("the title is too long need to be less or equal to 255 characters")
+	}
+
+	if item.Position < 0 {
+		return errors.New("the position can't be negative")
+	}
+
+	return nil
+}
+
+// Apply copies the given fields into the item, checking or unchecking it records who did it and when.
+func (item *ChecklistItem) Apply(update ChecklistItemUpdate, userID uint64) {
+	if update.Title != nil {
+		item.Title = *update.Title
+	}
+
+	if update.Mandatory != nil {
+		item.Mandatory = *update.Mandatory
+	}
+
+	if update.Position != nil {
+		item.Position = *update.Position
+	}
+
+	if update.Done != nil && *update.Done != item.Done {
+		item.Done = *update.Done
+		item.CompletedAt, item.CompletedBy = nil, nil
+
+		if item.Done {
+			now := time.Now()
+			item.CompletedAt, item.CompletedBy = &now, &userID
+		}
+	}
+}
+
+// ChecklistProgress returns the percentage of checked items, nil when the task has no checklist.
+func ChecklistProgress(items []ChecklistItem) *int {
+	if len(items) == 0 {
+		return nil
+	}
+
+	done := 0
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+
+	progress := done * 100 / len(items)
+	return &progress
+}
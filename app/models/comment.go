@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const maxCommentLength = 2000
+
+// Comment is a message of the discussion thread of a task.
+type Comment struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	TaskID    uint64    `gorm:"not null;index" json:"taskId"`
+	AuthorID  uint64    `gorm:"not null" json:"authorId"`
+	Body      string    `gorm:"size:2000;not null" json:"body,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"modifiedAt,omitempty"`
+}
+
+func (comment *Comment) Prepare() error {
+	comment.format()
+
+	if err := comment.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (comment *Comment) validate() error {
+	if len(comment.Body) == 0 {
+		return errors.New("the field body is required can't be empty")
+	} else if len(comment.Body) > maxCommentLength {
+		return errors.New("the body is too long need to be less or equal to 2000 characters")
+	}
+
+	return nil
+}
+
+func (comment *Comment) format() {
+	comment.Body = strings.TrimSpace(comment.Body)
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"api/app/security"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/badoux/checkmail"
+)
+
+// Invitation lets someone join with the type chosen by the one who invited, its token is only kept as a hash
+// and can be redeemed once before it expires.
+type Invitation struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Email      string     `gorm:"size:255;not null;index" json:"email"`
+	Type       string     `gorm:"size:20;not null" json:"type"`
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	InvitedBy  uint64     `gorm:"not null" json:"invitedBy"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expiresAt"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+	UserID     *uint64    `json:"userId,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt  time.Time  `json:"modifiedAt,omitempty"`
+}
+
+type InvitationRequest struct {
+	Email     string     `json:"email"`
+	Type      string     `json:"type"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// InvitationAcceptRequest redeems the token of an invitation, the email and the type come from the invitation.
+type InvitationAcceptRequest struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// Prepare validates the request, without an expiry the invitation lasts the given TTL and an expiry can't be further than the max TTL.
+func (request *InvitationRequest) Prepare(now time.Time, ttl time.Duration, maxTTL time.Duration) error {
+	request.Email = strings.TrimSpace(request.Email)
+
+	if len(request.Email) == 0 {
+		return errors.New("the field email is required can't be empty")
+	} else if err := checkmail.ValidateFormat(request.Email); err != nil {
+		return errors.New("the email is invalid")
+	}
+
+	if !IsValidUserType(request.Type) {
+		return errors.New("the field type should be Technician, Manager or Admin")
+	}
+
+	if request.ExpiresAt == nil {
+		expiresAt := now.Add(ttl)
+		request.ExpiresAt = &expiresAt
+	} else if !request.ExpiresAt.After(now) {
+		return errors.New("the expiresAt date should be in the future")
+	} else if request.ExpiresAt.After(now.Add(maxTTL)) {
+		return fmt.Errorf("the expiresAt date should be at most %.0f hours from now", maxTTL.Hours())
+	}
+
+	return nil
+}
+
+// NewInvitation returns the invitation with the token to send to the invitee, only its hash is stored.
+func (request *InvitationRequest) NewInvitation(invitedBy uint64) (*Invitation, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	invitation := &Invitation{
+		Email:     request.Email,
+		Type:      request.Type,
+		TokenHash: HashInvitationToken(token),
+		InvitedBy: invitedBy,
+		ExpiresAt: *request.ExpiresAt,
+	}
+
+	return invitation, token, nil
+}
+
+func HashInvitationToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (request *InvitationAcceptRequest) Prepare() error {
+	request.Token = strings.TrimSpace(request.Token)
+	request.Name = strings.TrimSpace(request.Name)
+
+	errs := []string{}
+	if len(request.Token) == 0 {
+		errs = append(errs, "the field token is required can't be empty")
+	}
+
+	if len(request.Name) == 0 {
+		errs = append(errs, "the field name is required can't be empty")
+	}
+
+	if len(request.Password) == 0 {
+		errs = append(errs, "the field password is required can't be empty")
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	hashedPassword, err := security.Hash(request.Password)
+	if err != nil {
+		return err
+	}
+	request.Password = string(hashedPassword)
+
+	return nil
+}
+
+// NewUser returns the user the invitation creates.
+func (invitation *Invitation) NewUser(request InvitationAcceptRequest) *User {
+	return &User{
+		Name:     request.Name,
+		Email:    invitation.Email,
+		Password: request.Password,
+		Type:     invitation.Type,
+	}
+}
+
+// InvitationEmail writes the email sent to the invitee, the link is only added when an accept URL is configured.
+func (invitation *Invitation) InvitationEmail(token string, acceptURL string) (string, string) {
+	subject := fmt.Sprintf("You are invited to join Task Maintain as a %s", invitation.Type)
+
+	var body strings.Builder
+	body.WriteString("Hello,\n\n")
+	fmt.Fprintf(&body, "You were invited to join Task Maintain as a %s.\n", invitation.Type)
+	if link, err := url.Parse(acceptURL); len(acceptURL) > 0 && err == nil {
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+		fmt.Fprintf(&body, "Choose your password at %s\n", link.String())
+	} else {
+		fmt.Fprintf(&body, "Choose your password with the invitation token %s\n", token)
+	}
+	fmt.Fprintf(&body, "\nThe invitation expires on %s.\n", invitation.ExpiresAt.UTC().Format(time.RFC1123))
+
+	return subject, body.String()
+}
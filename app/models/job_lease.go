@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// JobLease lets a single replica run a background job, the holder keeps it by renewing it before it expires.
+type JobLease struct {
+	Name      string    `gorm:"primaryKey;size:64"`
+	Holder    string    `gorm:"size:128;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	LocationKindSite     = "site"
+	LocationKindBuilding = "building"
+	LocationKindFloor    = "floor"
+	LocationKindRoom     = "room"
+)
+
+// locationParentKinds maps each kind of location to the kind its parent must have, a site is a root.
+var locationParentKinds = map[string]string{
+	LocationKindSite:     "",
+	LocationKindBuilding: LocationKindSite,
+	LocationKindFloor:    LocationKindBuilding,
+	LocationKindRoom:     LocationKindFloor,
+}
+
+// Location is a node of the site, building, floor, room hierarchy, the path lists the ids from the site down to
+// the location so a whole subtree can be matched by its prefix.
+type Location struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	ParentID  *uint64   `gorm:"index" json:"parentId,omitempty"`
+	Kind      string    `gorm:"size:20;not null" json:"kind"`
+	Name      string    `gorm:"size:255;not null" json:"name"`
+	Path      string    `gorm:"size:255;not null;index" json:"path,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"modifiedAt,omitempty"`
+}
+
+// UserSite scopes a manager to a site, a manager without sites sees every site.
+type UserSite struct {
+	UserID    uint64    `gorm:"primaryKey;autoIncrement:false" json:"userId"`
+	SiteID    uint64    `gorm:"primaryKey;autoIncrement:false;index" json:"siteId"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+// UserSitesRequest replaces the sites a manager is scoped to.
+type UserSitesRequest struct {
+	SiteIDs []uint64 `json:"siteIds"`
+}
+
+func (location *Location) Prepare() error {
+	location.Name = strings.TrimSpace(location.Name)
+
+	if len(location.Name) == 0 {
+		return errors.New("the field name is required can't be empty")
+	} else if len(location.Name) > 255 {
+		return errors.New("the name is too long need to be less or equal to 255 characters")
+	}
+
+	parentKind, ok := locationParentKinds[location.Kind]
+	if !ok {
+		return fmt.Errorf("the kind %s is not a valid location kind, use one of site, building, floor, room", location.Kind)
+	}
+
+	if len(parentKind) == 0 && location.ParentID != nil {
+		return errors.New("a site can't have a parent location")
+	}
+
+	if len(parentKind) > 0 && location.ParentID == nil {
+		return fmt.Errorf("a %s needs a parent %s", location.Kind, parentKind)
+	}
+
+	return nil
+}
+
+// CheckParent makes sure the location sits right under a location of the expected kind.
+func (location *Location) CheckParent(parent *Location) error {
+	if parentKind := locationParentKinds[location.Kind]; parent.Kind != parentKind {
+		return fmt.Errorf("a %s needs a parent %s, the location %d is a %s", location.Kind, parentKind, parent.ID, parent.Kind)
+	}
+
+	return nil
+}
+
+// SetPath places the location under its parent, it needs the id of the location so it is set once created.
+func (location *Location) SetPath(parent *Location) {
+	prefix := "/"
+	if parent != nil {
+		prefix = parent.Path
+	}
+
+	location.Path = fmt.Sprintf("%s%d/", prefix, location.ID)
+}
+
+func (request *UserSitesRequest) Prepare() {
+	seen := make(map[uint64]bool, len(request.SiteIDs))
+	siteIDs := make([]uint64, 0, len(request.SiteIDs))
+
+	for _, siteID := range request.SiteIDs {
+		if !seen[siteID] {
+			seen[siteID] = true
+			siteIDs = append(siteIDs, siteID)
+		}
+	}
+
+	request.SiteIDs = siteIDs
+}
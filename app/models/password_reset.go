@@ -0,0 +1,139 @@
+package models
+
+import (
+	"api/app/security"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PasswordReset lets a user choose a new password without the current one, its token is only kept as a hash
+// and can be used once before it expires.
+type PasswordReset struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"`
+	UserID    uint64    `gorm:"not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+func (request *ForgotPasswordRequest) Prepare() error {
+	request.Email = strings.TrimSpace(request.Email)
+
+	if len(request.Email) == 0 {
+		return errors.New("the field email is required can't be empty")
+	}
+
+	return nil
+}
+
+// Prepare validates the request and hashes the new password.
+func (request *ResetPasswordRequest) Prepare() error {
+	request.Token = strings.TrimSpace(request.Token)
+
+	errs := []string{}
+	if len(request.Token) == 0 {
+		errs = append(errs, "the field token is required can't be empty")
+	}
+
+	if len(request.Password) == 0 {
+		errs = append(errs, "the field password is required can't be empty")
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	hashedPassword, err := security.Hash(request.Password)
+	if err != nil {
+		return err
+	}
+	request.Password = string(hashedPassword)
+
+	return nil
+}
+
+// Prepare validates the request, the new password is hashed once the current one is verified.
+func (request *ChangePasswordRequest) Prepare() error {
+	errs := []string{}
+	if len(request.CurrentPassword) == 0 {
+		errs = append(errs, "the field currentPassword is required can't be empty")
+	}
+
+	if len(request.NewPassword) == 0 {
+		errs = append(errs, "the field newPassword is required can't be empty")
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	if request.CurrentPassword == request.NewPassword {
+		return errors.New("the new password should be different from the current one")
+	}
+
+	return nil
+}
+
+// NewPasswordReset returns the reset with the token to send to the user, only its hash is stored.
+func NewPasswordReset(userID uint64, expiresAt time.Time) (*PasswordReset, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	reset := &PasswordReset{
+		UserID:    userID,
+		TokenHash: HashPasswordResetToken(token),
+		ExpiresAt: expiresAt,
+	}
+
+	return reset, token, nil
+}
+
+func HashPasswordResetToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// PasswordResetEmail writes the email sent to the user, the link is only added when a reset URL is configured.
+func (reset *PasswordReset) PasswordResetEmail(token string, resetURL string) (string, string) {
+	subject := "Reset your Task Maintain password"
+
+	var body strings.Builder
+	body.WriteString("Hello,\n\n")
+	body.WriteString("Someone asked to reset the password of your Task Maintain account.\n")
+	if link, err := url.Parse(resetURL); len(resetURL) > 0 && err == nil {
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+		fmt.Fprintf(&body, "Choose a new password at %s\n", link.String())
+	} else {
+		fmt.Fprintf(&body, "Choose a new password with the reset token %s\n", token)
+	}
+	fmt.Fprintf(&body, "\nThe link expires on %s. If you didn't ask for it, you can ignore this email.\n", reset.ExpiresAt.UTC().Format(time.RFC1123))
+
+	return subject, body.String()
+}
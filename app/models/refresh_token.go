@@ -0,0 +1,80 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// RefreshToken renews the access tokens of a session, it is replaced by a new one each time it is used.
+// The tokens of a session share the family, when a used token comes back the whole family is revoked.
+type RefreshToken struct {
+	ID            uint64    `gorm:"primaryKey;autoIncrement"`
+	UserID        uint64    `gorm:"not null;index"`
+	FamilyID      string    `gorm:"size:64;not null;index"`
+	TokenHash     string    `gorm:"size:64;not null;uniqueIndex"`
+	AccessTokenID string    `gorm:"size:64"`
+	ExpiresAt     time.Time `gorm:"not null;index"`
+	UsedAt        *time.Time
+	RevokedAt     *time.Time
+	CreatedAt     time.Time
+}
+
+// RevokedToken keeps the id of an access token that can't be used anymore until it expires.
+type RevokedToken struct {
+	ID        string    `gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (request *RefreshTokenRequest) Prepare() error {
+	request.RefreshToken = strings.TrimSpace(request.RefreshToken)
+
+	if len(request.RefreshToken) == 0 {
+		return errors.New("the field refreshToken is required can't be empty")
+	}
+
+	return nil
+}
+
+// NewTokenID returns a random id for an access token or a session.
+func NewTokenID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(raw), nil
+}
+
+// NewRefreshToken returns the refresh token with the value to give to the client, only its hash is stored.
+func NewRefreshToken(userID uint64, familyID string, accessTokenID string, expiresAt time.Time) (*RefreshToken, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	refreshToken := &RefreshToken{
+		UserID:        userID,
+		FamilyID:      familyID,
+		TokenHash:     HashRefreshToken(token),
+		AccessTokenID: accessTokenID,
+		ExpiresAt:     expiresAt,
+	}
+
+	return refreshToken, token, nil
+}
+
+func HashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"api/app/utils/cron_utils"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// Schedule creates a task for its technician ahead of every occurrence of its recurrence.
+type Schedule struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Summary    string    `gorm:"size:2500;not null" json:"summary"`
+	Recurrence string    `gorm:"size:100;not null" json:"recurrence"`
+	Timezone   string    `gorm:"size:64;not null;default:UTC" json:"timezone"`
+	AssigneeID uint64    `gorm:"not null;index" json:"assigneeId"`
+	Priority   string    `gorm:"size:10;not null;default:medium" json:"priority"`
+	Active     bool      `gorm:"not null" json:"active"`
+	NextRunAt  time.Time `gorm:"not null;index" json:"nextRunAt"`
+	CreatedBy  uint64    `gorm:"not null" json:"createdBy"`
+	CreatedAt  time.Time `json:"createdAt,omitempty"`
+	UpdatedAt  time.Time `json:"modifiedAt,omitempty"`
+}
+
+// ScheduleRequest holds the fields a manager sends to create or change a schedule.
+type ScheduleRequest struct {
+	Summary    string `json:"summary"`
+	Recurrence string `json:"recurrence"`
+	Timezone   string `json:"timezone"`
+	AssigneeID uint64 `json:"assigneeId"`
+	Priority   string `json:"priority"`
+	Active     *bool  `json:"active"`
+}
+
+// Apply copies the request into the schedule and plans its next occurrence after now.
+func (schedule *Schedule) Apply(request ScheduleRequest, now time.Time) error {
+	schedule.Summary = strings.TrimSpace(request.Summary)
+	schedule.Recurrence = strings.TrimSpace(request.Recurrence)
+	schedule.Timezone = request.Timezone
+	schedule.AssigneeID = request.AssigneeID
+	schedule.Priority = request.Priority
+
+	if len(schedule.Timezone) == 0 {
+		schedule.Timezone = "UTC"
+	}
+
+	if len(schedule.Priority) == 0 {
+		schedule.Priority = TaskPriorityMedium
+	}
+
+	if request.Active != nil {
+		schedule.Active = *request.Active
+	} else if schedule.ID == 0 {
+		schedule.Active = true
+	}
+
+	if err := schedule.validate(); err != nil {
+		return err
+	}
+
+	next, err := schedule.NextOccurrence(now)
+	if err != nil {
+		return err
+	}
+	schedule.NextRunAt = next
+
+	return nil
+}
+
+// NextOccurrence returns the first occurrence of the recurrence after the given time, in UTC.
+func (schedule *Schedule) NextOccurrence(after time.Time) (time.Time, error) {
+	expression, err := cron_utils.Parse(schedule.Recurrence)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("the timezone %s is not valid", schedule.Timezone)
+	}
+
+	next := expression.Next(after.In(location))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("the recurrence %s never happens", schedule.Recurrence)
+	}
+
+	return next.UTC(), nil
+}
+
+// NewTask builds the task of the given occurrence, it is due at the time of the occurrence.
+func (schedule *Schedule) NewTask(occurrence time.Time) *Task {
+	scheduleID := schedule.ID
+
+	return &Task{
+		Summary:      schedule.Summary,
+		Status:       TaskStatusOpen,
+		UserID:       schedule.AssigneeID,
+		Priority:     schedule.Priority,
+		DueAt:        &occurrence,
+		ScheduleID:   &scheduleID,
+		OccurrenceAt: &occurrence,
+		Version:      1,
+	}
+}
+
+func (schedule *Schedule) validate() error {
+	if len(schedule.Summary) == 0 {
+		return errors.New("the field summary is required can't be empty")
+	} else if len(schedule.Summary) > 2500 {
+		return errors.New("the summary is too long need to be less or equal to 2500 characters")
+	}
+
+	if len(schedule.Recurrence) == 0 {
+		return errors.New("the field recurrence is required can't be empty")
+	}
+
+	if schedule.AssigneeID == 0 {
+		return errors.New("the field assigneeId is required can't be empty")
+	}
+
+	if !IsValidTaskPriority(schedule.Priority) {
+		return fmt.Errorf("the priority %s is not a valid task priority, use one of low, medium, high, critical", schedule.Priority)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+// Tag is a label managers define and apply to tasks to group them.
+type Tag struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name      string    `gorm:"size:50;not null;uniqueIndex" json:"name"`
+	CreatedBy uint64    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"modifiedAt,omitempty"`
+}
+
+// TaskTag links a tag to a task.
+type TaskTag struct {
+	TaskID    uint64    `gorm:"primaryKey;autoIncrement:false" json:"taskId"`
+	TagID     uint64    `gorm:"primaryKey;autoIncrement:false;index" json:"tagId"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+// TagCount is a tag with the number of tasks it is applied to.
+type TagCount struct {
+	Tag
+	Tasks int64 `gorm:"column:tasks" json:"tasks"`
+}
+
+// TaskTagsRequest replaces the tags of a task.
+type TaskTagsRequest struct {
+	TagIDs []uint64 `json:"tagIds"`
+}
+
+func (tag *Tag) Prepare() error {
+	tag.Name = strings.TrimSpace(tag.Name)
+
+	if len(tag.Name) == 0 {
+		return errors.New("the field name is required can't be empty")
+	} else if len(tag.Name) > 50 {
+		return errors.New("the name is too long need to be less or equal to 50 characters")
+	}
+
+	return nil
+}
+
+// Prepare drops the duplicated tags, an empty list removes every tag of the task.
+func (request *TaskTagsRequest) Prepare() error {
+	seen := make(map[uint64]bool, len(request.TagIDs))
+	tagIDs := make([]uint64, 0, len(request.TagIDs))
+
+	for _, tagID := range request.TagIDs {
+		if tagID == 0 {
+			return errors.New("the tag ids should be greater than 0")
+		}
+
+		if !seen[tagID] {
+			seen[tagID] = true
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+
+	request.TagIDs = tagIDs
+
+	return nil
+}
+
+func IsValidTagMode(mode string) bool {
+	return mode == TagModeAny || mode == TagModeAll
+}
@@ -2,15 +2,106 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TaskStatusOpen       = "open"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusBlocked    = "blocked"
+	TaskStatusDone       = "done"
+	TaskStatusCancelled  = "cancelled"
+)
+
+// taskStatusTransitions maps each status to the statuses it is allowed to move to.
+var taskStatusTransitions = map[string][]string{
+	TaskStatusOpen:       {TaskStatusInProgress, TaskStatusBlocked, TaskStatusCancelled},
+	TaskStatusInProgress: {TaskStatusOpen, TaskStatusBlocked, TaskStatusDone, TaskStatusCancelled},
+	TaskStatusBlocked:    {TaskStatusOpen, TaskStatusInProgress, TaskStatusCancelled},
+	TaskStatusDone:       {TaskStatusOpen},
+	TaskStatusCancelled:  {TaskStatusOpen},
+}
+
+const (
+	TaskPriorityLow      = "low"
+	TaskPriorityMedium   = "medium"
+	TaskPriorityHigh     = "high"
+	TaskPriorityCritical = "critical"
 )
 
+var taskPriorities = []string{TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh, TaskPriorityCritical}
+
+var ErrInvalidStatusTransition = errors.New("invalid status transition")
+
 type Task struct {
-	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
-	Summary   string    `gorm:"size:2500;not null" json:"summary,omitempty"`
-	UserID    uint64    `json:"userId,omitempty"`
-	CreatedAt time.Time `json:"createdAt,omitempty"`
-	UpdatedAt time.Time `json:"modifiedAt,omitempty"`
+	ID                uint64          `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Summary           string          `gorm:"size:2500;not null" json:"summary,omitempty"`
+	Status            string          `gorm:"size:20;not null;default:open;index" json:"status,omitempty"`
+	UserID            uint64          `json:"userId,omitempty"`
+	AssigneeID        *uint64         `gorm:"index" json:"assigneeId,omitempty"`
+	AssetID           *uint64         `gorm:"index" json:"assetId,omitempty"`
+	LocationID        *uint64         `gorm:"index" json:"locationId,omitempty"`
+	Priority          string          `gorm:"size:10;not null;default:medium" json:"priority,omitempty"`
+	DueAt             *time.Time      `gorm:"index" json:"dueAt,omitempty"`
+	CompletedAt       *time.Time      `json:"completedAt,omitempty"`
+	OverdueNotifiedAt *time.Time      `json:"-"`
+	ScheduleID        *uint64         `gorm:"uniqueIndex:idx_task_schedule_occurrence" json:"scheduleId,omitempty"`
+	OccurrenceAt      *time.Time      `gorm:"uniqueIndex:idx_task_schedule_occurrence" json:"occurrenceAt,omitempty"`
+	TemplateID        *uint64         `gorm:"index" json:"templateId,omitempty"`
+	TemplateVersion   *uint64         `json:"templateVersion,omitempty"`
+	Version           uint64          `gorm:"not null;default:1" json:"version,omitempty"`
+	CreatedAt         time.Time       `gorm:"index" json:"createdAt,omitempty"`
+	UpdatedAt         time.Time       `json:"modifiedAt,omitempty"`
+	DeletedAt         gorm.DeletedAt  `gorm:"index" json:"deletedAt,omitempty"`
+	Checklist         []ChecklistItem `json:"checklist,omitempty"`
+	Progress          *int            `gorm:"-" json:"progress,omitempty"`
+	Blocked           bool            `gorm:"-" json:"blocked"`
+	TimeSpentSeconds  *int64          `gorm:"-" json:"timeSpentSeconds,omitempty"`
+	Tags              []Tag           `gorm:"-" json:"tags,omitempty"`
+}
+
+// SLABreachCount sums up, for one technician, the tasks that missed their due date.
+type SLABreachCount struct {
+	TechnicianID  uint64 `json:"technicianId"`
+	Overdue       int64  `json:"overdue"`
+	CompletedLate int64  `json:"completedLate"`
+	Breached      int64  `json:"breached"`
+}
+
+type TaskStatusUpdate struct {
+	Status string `json:"status"`
+}
+
+type TaskAssignmentRequest struct {
+	AssigneeID uint64 `json:"assigneeId"`
+}
+
+// TaskAssignment records every time a task is (re)assigned to a technician.
+type TaskAssignment struct {
+	ID                 uint64    `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	TaskID             uint64    `gorm:"not null;index" json:"taskId"`
+	AssigneeID         uint64    `gorm:"not null" json:"assigneeId"`
+	PreviousAssigneeID *uint64   `json:"previousAssigneeId,omitempty"`
+	AssignedBy         uint64    `gorm:"not null" json:"assignedBy"`
+	CreatedAt          time.Time `json:"createdAt,omitempty"`
+}
+
+func IsValidTaskStatus(status string) bool {
+	_, ok := taskStatusTransitions[status]
+	return ok
+}
+
+func IsValidTaskPriority(priority string) bool {
+	for _, taskPriority := range taskPriorities {
+		if taskPriority == priority {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (task *Task) Prepare() error {
@@ -19,9 +110,60 @@ func (task *Task) Prepare() error {
 		return err
 	}
 
+	task.format()
+
 	return nil
 }
 
+// IsAssignedTo reports whether the task is assigned to the user, tasks without an assignee belong to their creator.
+func (task *Task) IsAssignedTo(userID uint64) bool {
+	if task.AssigneeID != nil {
+		return *task.AssigneeID == userID
+	}
+
+	return task.UserID == userID
+}
+
+// TransitionTo moves the task to the given status when the transition graph allows it.
+func (task *Task) TransitionTo(status string) error {
+	if err := CheckStatusTransition(task.Status, status); err != nil {
+		return err
+	}
+
+	task.Status = status
+	task.CompletedAt = nil
+	if status == TaskStatusDone {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+
+	return nil
+}
+
+// CheckStatusTransition reports whether the transition graph allows a task to move between the two statuses.
+func CheckStatusTransition(from string, to string) error {
+	if !IsValidTaskStatus(to) {
+		return fmt.Errorf("the status %s is not a valid task status", to)
+	}
+
+	for _, allowed := range taskStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: not possible to move a task from %s to %s", ErrInvalidStatusTransition, from, to)
+}
+
+// IsOverdue reports whether the task is still pending after its due date.
+func (task *Task) IsOverdue(now time.Time) bool {
+	if task.DueAt == nil || task.Status == TaskStatusDone || task.Status == TaskStatusCancelled {
+		return false
+	}
+
+	return task.DueAt.Before(now)
+}
+
 func (task *Task) validate() error {
 	if len(task.Summary) == 0 {
 		return errors.New("the field summary is required can't be empty")
@@ -29,5 +171,19 @@ func (task *Task) validate() error {
 		return errors.New("the summary is too long need to be less or equal to 2500 characters")
 	}
 
+	if len(task.Status) > 0 && !IsValidTaskStatus(task.Status) {
+		return fmt.Errorf("the status %s is not a valid task status", task.Status)
+	}
+
+	if len(task.Priority) > 0 && !IsValidTaskPriority(task.Priority) {
+		return fmt.Errorf("the priority %s is not a valid task priority, use one of low, medium, high, critical", task.Priority)
+	}
+
 	return nil
 }
+
+func (task *Task) format() {
+	if len(task.Status) == 0 {
+		task.Status = TaskStatusOpen
+	}
+}
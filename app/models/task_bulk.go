@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	TaskBulkActionDelete       = "delete"
+	TaskBulkActionUpdateStatus = "update_status"
+	TaskBulkActionAssign       = "assign"
+	TaskBulkActionTag          = "tag"
+
+	// TaskBulkModeAtomic rolls every operation back as soon as one fails.
+	TaskBulkModeAtomic = "all_or_nothing"
+	// TaskBulkModeBestEffort keeps the operations that succeeded.
+	TaskBulkModeBestEffort = "best_effort"
+
+	MaxTaskBulkOperations = 100
+)
+
+// TaskBulkPermissions maps each bulk action to the permission its single task endpoint requires.
+var TaskBulkPermissions = map[string]string{
+	TaskBulkActionDelete:       "delete",
+	TaskBulkActionUpdateStatus: "update_status",
+	TaskBulkActionAssign:       "assign",
+	TaskBulkActionTag:          "tag",
+}
+
+// TaskBulkOperation is one action on one task, only the fields of its action are read.
+type TaskBulkOperation struct {
+	Action     string   `json:"action"`
+	TaskID     uint64   `json:"taskId"`
+	Status     string   `json:"status,omitempty"`
+	AssigneeID uint64   `json:"assigneeId,omitempty"`
+	TagIDs     []uint64 `json:"tagIds,omitempty"`
+}
+
+// TaskBulkRequest runs its operations in order within a single transaction.
+type TaskBulkRequest struct {
+	Mode       string              `json:"mode"`
+	Operations []TaskBulkOperation `json:"operations"`
+}
+
+// TaskBulkResult is the outcome of one operation, the status is the one its single task endpoint would answer.
+type TaskBulkResult struct {
+	Index              int     `json:"index"`
+	TaskID             uint64  `json:"taskId"`
+	Action             string  `json:"action"`
+	Status             int     `json:"status"`
+	Error              string  `json:"error,omitempty"`
+	Task               *Task   `json:"task,omitempty"`
+	PreviousAssigneeID *uint64 `json:"-"`
+}
+
+type TaskBulkResponse struct {
+	Mode      string           `json:"mode"`
+	Committed bool             `json:"committed"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []TaskBulkResult `json:"results"`
+}
+
+func (request *TaskBulkRequest) Prepare() error {
+	if len(request.Mode) == 0 {
+		request.Mode = TaskBulkModeAtomic
+	}
+
+	if request.Mode != TaskBulkModeAtomic && request.Mode != TaskBulkModeBestEffort {
+		return fmt.Errorf("the mode %s is not valid, use one of all_or_nothing, best_effort", request.Mode)
+	}
+
+	if len(request.Operations) == 0 {
+		return errors.New("the field operations is required can't be empty")
+	} else if len(request.Operations) > MaxTaskBulkOperations {
+		return fmt.Errorf("a bulk request can't have more than %d operations", MaxTaskBulkOperations)
+	}
+
+	for i := range request.Operations {
+		if err := request.Operations[i].prepare(); err != nil {
+			return fmt.Errorf("the operation %d is not valid: %s", i, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (operation *TaskBulkOperation) prepare() error {
+	if _, ok := TaskBulkPermissions[operation.Action]; !ok {
+		return fmt.Errorf("the action %s is not valid, use one of delete, update_status, assign, tag", operation.Action)
+	}
+
+	if operation.TaskID == 0 {
+		return errors.New("the field taskId is required can't be empty")
+	}
+
+	switch operation.Action {
+	case TaskBulkActionUpdateStatus:
+		if !IsValidTaskStatus(operation.Status) {
+			return fmt.Errorf("the status %s is not a valid task status", operation.Status)
+		}
+	case TaskBulkActionAssign:
+		if operation.AssigneeID == 0 {
+			return errors.New("the field assigneeId is required can't be empty")
+		}
+	case TaskBulkActionTag:
+		if len(operation.TagIDs) == 0 {
+			return errors.New("the field tagIds is required can't be empty")
+		}
+
+		request := TaskTagsRequest{TagIDs: operation.TagIDs}
+		if err := request.Prepare(); err != nil {
+			return err
+		}
+		operation.TagIDs = request.TagIDs
+	}
+
+	return nil
+}
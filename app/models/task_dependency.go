@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// TaskDependency records that a task can't move on until its blocker is resolved.
+type TaskDependency struct {
+	TaskID      uint64    `gorm:"primaryKey;autoIncrement:false" json:"taskId"`
+	BlockedByID uint64    `gorm:"primaryKey;autoIncrement:false;index" json:"blockedById"`
+	CreatedBy   uint64    `json:"createdBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt,omitempty"`
+}
+
+func (dependency *TaskDependency) Prepare() error {
+	if dependency.BlockedByID == 0 {
+		return errors.New("the field blockedById is required can't be empty")
+	}
+
+	if dependency.BlockedByID == dependency.TaskID {
+		return errors.New("a task can't be blocked by itself")
+	}
+
+	return nil
+}
+
+// IsResolved tells if the task stopped blocking the tasks that depend on it.
+func (task *Task) IsResolved() bool {
+	return task.Status == TaskStatusDone || task.Status == TaskStatusCancelled
+}
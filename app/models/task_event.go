@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+const (
+	TaskEventCreated       = "created"
+	TaskEventUpdated       = "updated"
+	TaskEventDeleted       = "deleted"
+	TaskEventStatusChanged = "status_changed"
+	TaskEventAssigned      = "assigned"
+	TaskEventRestored      = "restored"
+	TaskEventPurged        = "purged"
+)
+
+// TaskEvent is one entry of the audit history of a task, it outlives the task itself.
+type TaskEvent struct {
+	ID        uint64      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	TaskID    uint64      `gorm:"not null;index" json:"taskId"`
+	ActorID   uint64      `gorm:"not null" json:"actorId"`
+	Action    string      `gorm:"size:30;not null" json:"action"`
+	Changes   TaskChanges `gorm:"type:text" json:"changes"`
+	CreatedAt time.Time   `json:"createdAt,omitempty"`
+}
+
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// TaskChanges maps the json name of a field to its previous and new value.
+type TaskChanges map[string]FieldChange
+
+// auditedTaskFields lists the task fields tracked by the history, keyed by their json name.
+var auditedTaskFields = map[string]func(task *Task) interface{}{
+	"summary":    func(task *Task) interface{} { return task.Summary },
+	"status":     func(task *Task) interface{} { return task.Status },
+	"userId":     func(task *Task) interface{} { return task.UserID },
+	"assigneeId": func(task *Task) interface{} { return task.AssigneeID },
+	"assetId":    func(task *Task) interface{} { return task.AssetID },
+	"locationId": func(task *Task) interface{} { return task.LocationID },
+	"priority":   func(task *Task) interface{} { return task.Priority },
+	"dueAt":      func(task *Task) interface{} { return task.DueAt },
+}
+
+// DiffTasks returns the audited fields that differ between both versions of a task, a nil version stands for a task that doesn't exist.
+func DiffTasks(before *Task, after *Task) TaskChanges {
+	changes := TaskChanges{}
+
+	for field, value := range auditedTaskFields {
+		var from, to interface{}
+		if before != nil {
+			from = normalizeAuditValue(value(before))
+		}
+		if after != nil {
+			to = normalizeAuditValue(value(after))
+		}
+
+		if !reflect.DeepEqual(from, to) {
+			changes[field] = FieldChange{From: from, To: to}
+		}
+	}
+
+	return changes
+}
+
+// normalizeAuditValue dereferences pointers so a nil pointer and a missing value compare as equal,
+// dates are compared in UTC as the database and the requests don't share the same location.
+func normalizeAuditValue(value interface{}) interface{} {
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() == reflect.Ptr {
+		if reflected.IsNil() {
+			return nil
+		}
+		value = reflected.Elem().Interface()
+	}
+
+	if date, ok := value.(time.Time); ok {
+		return date.UTC()
+	}
+
+	return value
+}
+
+func (changes TaskChanges) Value() (driver.Value, error) {
+	if changes == nil {
+		return "{}", nil
+	}
+
+	bytes, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(bytes), nil
+}
+
+func (changes *TaskChanges) Scan(value interface{}) error {
+	var bytes []byte
+	switch typed := value.(type) {
+	case nil:
+		*changes = TaskChanges{}
+		return nil
+	case []byte:
+		bytes = typed
+	case string:
+		bytes = []byte(typed)
+	default:
+		return errors.New("not possible to convert the task changes")
+	}
+
+	return json.Unmarshal(bytes, changes)
+}
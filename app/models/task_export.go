@@ -0,0 +1,93 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TaskExportFormatCSV    = "csv"
+	TaskExportFormatJSON   = "json"
+	TaskExportFormatNDJSON = "ndjson"
+)
+
+// TaskExportRow is a task as it is exported, with the names of its creator and of its assignee.
+type TaskExportRow struct {
+	ID           uint64     `json:"id"`
+	Summary      string     `json:"summary"`
+	Status       string     `json:"status"`
+	Priority     string     `json:"priority"`
+	UserID       uint64     `json:"userId"`
+	UserName     string     `json:"userName"`
+	AssigneeID   *uint64    `json:"assigneeId"`
+	AssigneeName *string    `json:"assigneeName"`
+	AssetID      *uint64    `json:"assetId"`
+	LocationID   *uint64    `json:"locationId"`
+	DueAt        *time.Time `json:"dueAt"`
+	CompletedAt  *time.Time `json:"completedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"modifiedAt"`
+}
+
+// TaskExportHeader names the columns of a CSV export, in the order of TaskExportRow.CSVRecord.
+var TaskExportHeader = []string{
+	"id", "summary", "status", "priority", "userId", "userName", "assigneeId", "assigneeName",
+	"assetId", "locationId", "dueAt", "completedAt", "createdAt", "modifiedAt",
+}
+
+func IsValidTaskExportFormat(format string) bool {
+	return format == TaskExportFormatCSV || format == TaskExportFormatJSON || format == TaskExportFormatNDJSON
+}
+
+// CSVRecord returns the row as CSV fields, the free text is escaped so a spreadsheet never runs it as a formula.
+func (row *TaskExportRow) CSVRecord() []string {
+	return []string{
+		strconv.FormatUint(row.ID, 10),
+		escapeSpreadsheetFormula(row.Summary),
+		row.Status,
+		row.Priority,
+		strconv.FormatUint(row.UserID, 10),
+		escapeSpreadsheetFormula(row.UserName),
+		formatExportID(row.AssigneeID),
+		escapeSpreadsheetFormula(formatExportText(row.AssigneeName)),
+		formatExportID(row.AssetID),
+		formatExportID(row.LocationID),
+		formatExportTime(row.DueAt),
+		formatExportTime(row.CompletedAt),
+		formatExportTime(&row.CreatedAt),
+		formatExportTime(&row.UpdatedAt),
+	}
+}
+
+func formatExportID(id *uint64) string {
+	if id == nil {
+		return ""
+	}
+
+	return strconv.FormatUint(*id, 10)
+}
+
+func formatExportText(text *string) string {
+	if text == nil {
+		return ""
+	}
+
+	return *text
+}
+
+func formatExportTime(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+
+	return date.UTC().Format(time.RFC3339)
+}
+
+func escapeSpreadsheetFormula(text string) string {
+	if len(text) > 0 && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+
+	return text
+}
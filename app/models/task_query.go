@@ -0,0 +1,183 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TaskSortCreatedAtAsc  = "createdAt"
+	TaskSortCreatedAtDesc = "-createdAt"
+	TaskSortIDAsc         = "id"
+	TaskSortIDDesc        = "-id"
+
+	DefaultTaskPageSize = 20
+	MaxTaskPageSize     = 100
+)
+
+// TaskQuery holds the filters, sorting and keyset pagination options used to list tasks.
+type TaskQuery struct {
+	UserID     uint64
+	Status     string
+	From       *time.Time
+	To         *time.Time
+	Text       string
+	Overdue    bool
+	Trashed    bool
+	TagIDs     []uint64
+	TagMode    string
+	LocationID uint64
+	SiteIDs    []uint64
+	Sort       string
+	Cursor     *TaskCursor
+	Limit      int
+}
+
+// TaskCursor points to the last task of a page, the next page starts right after it.
+type TaskCursor struct {
+	CreatedAt time.Time
+	ID        uint64
+}
+
+type TaskPage struct {
+	Data       []Task `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int64  `json:"total"`
+}
+
+// TaskSearchQuery holds the options of a full text search over the task summaries.
+type TaskSearchQuery struct {
+	Text    string
+	UserID  uint64
+	SiteIDs []uint64
+	Limit   int
+}
+
+type TaskSearchResult struct {
+	Task
+	Relevance float64 `gorm:"column:relevance" json:"relevance"`
+	Snippet   string  `gorm:"-" json:"snippet"`
+}
+
+func (query *TaskSearchQuery) Prepare() error {
+	query.Text = strings.TrimSpace(query.Text)
+
+	if len(query.Text) == 0 {
+		return errors.New("the query parameter q is required can't be empty")
+	}
+
+	if query.Limit < 0 || query.Limit > MaxTaskPageSize {
+		return fmt.Errorf("the limit should be between 1 and %d", MaxTaskPageSize)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultTaskPageSize
+	}
+
+	return nil
+}
+
+func (query *TaskQuery) Prepare() error {
+	if err := query.validate(); err != nil {
+		return err
+	}
+
+	query.format()
+
+	return nil
+}
+
+func (query *TaskQuery) validate() error {
+	if len(query.Status) > 0 && !IsValidTaskStatus(query.Status) {
+		return fmt.Errorf("the status %s is not a valid task status", query.Status)
+	}
+
+	if len(query.TagMode) > 0 && !IsValidTagMode(query.TagMode) {
+		return fmt.Errorf("the tag mode %s is not supported, use one of any, all", query.TagMode)
+	}
+
+	switch query.Sort {
+	case "", TaskSortCreatedAtAsc, TaskSortCreatedAtDesc, TaskSortIDAsc, TaskSortIDDesc:
+	default:
+		return fmt.Errorf("the sort %s is not supported, use one of createdAt, -createdAt, id, -id", query.Sort)
+	}
+
+	if query.Limit < 0 || query.Limit > MaxTaskPageSize {
+		return fmt.Errorf("the limit should be between 1 and %d", MaxTaskPageSize)
+	}
+
+	if query.From != nil && query.To != nil && query.From.After(*query.To) {
+		return errors.New("the from date should be before the to date")
+	}
+
+	return nil
+}
+
+func (query *TaskQuery) format() {
+	query.Text = strings.TrimSpace(query.Text)
+
+	if len(query.Sort) == 0 {
+		query.Sort = TaskSortCreatedAtDesc
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultTaskPageSize
+	}
+
+	if len(query.TagMode) == 0 {
+		query.TagMode = TagModeAny
+	}
+
+	// a repeated tag would never match in the all mode, every tag is counted once
+	seen := make(map[uint64]bool, len(query.TagIDs))
+	tagIDs := make([]uint64, 0, len(query.TagIDs))
+	for _, tagID := range query.TagIDs {
+		if !seen[tagID] {
+			seen[tagID] = true
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+	query.TagIDs = tagIDs
+}
+
+// SortsByID reports whether the keyset is on the ID only, otherwise it is on CreatedAt then ID.
+func (query *TaskQuery) SortsByID() bool {
+	return query.Sort == TaskSortIDAsc || query.Sort == TaskSortIDDesc
+}
+
+func (query *TaskQuery) IsDescending() bool {
+	return strings.HasPrefix(query.Sort, "-")
+}
+
+func NewTaskCursor(task Task) *TaskCursor {
+	return &TaskCursor{CreatedAt: task.CreatedAt, ID: task.ID}
+}
+
+func (cursor *TaskCursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", cursor.CreatedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeTaskCursor(encoded string) (*TaskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("the cursor is invalid")
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, errors.New("the cursor is invalid")
+	}
+
+	nanos, errNanos := strconv.ParseInt(parts[0], 10, 64)
+	id, errID := strconv.ParseUint(parts[1], 10, 64)
+	if errNanos != nil || errID != nil {
+		return nil, errors.New("the cursor is invalid")
+	}
+
+	return &TaskCursor{CreatedAt: time.Unix(0, nanos), ID: id}, nil
+}
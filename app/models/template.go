@@ -0,0 +1,245 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// templatePlaceholder matches a {{variable}} of a template, spaces around the name are allowed.
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// TaskTemplate is a standard job managers define once and create tasks from, every change bumps its version.
+type TaskTemplate struct {
+	ID        uint64         `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name      string         `gorm:"size:255;not null;index" json:"name"`
+	Version   uint64         `gorm:"not null;default:1" json:"version,omitempty"`
+	CreatedBy uint64         `json:"createdBy,omitempty"`
+	CreatedAt time.Time      `json:"createdAt,omitempty"`
+	UpdatedAt time.Time      `json:"modifiedAt,omitempty"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	TaskTemplateContent
+}
+
+// TaskTemplateVersion is the frozen content of a template at one of its versions, tasks point to it.
+type TaskTemplateVersion struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	TemplateID uint64    `gorm:"not null;uniqueIndex:idx_template_version" json:"templateId"`
+	Version    uint64    `gorm:"not null;uniqueIndex:idx_template_version" json:"version"`
+	CreatedBy  uint64    `json:"createdBy,omitempty"`
+	CreatedAt  time.Time `json:"createdAt,omitempty"`
+	TaskTemplateContent
+}
+
+// TaskTemplateContent is what a template gives to the tasks created from it.
+type TaskTemplateContent struct {
+	Summary   string            `gorm:"size:2500;not null" json:"summary"`
+	Priority  string            `gorm:"size:10;not null;default:medium" json:"priority"`
+	Checklist TemplateChecklist `gorm:"type:text" json:"checklist"`
+	TagIDs    TemplateTagIDs    `gorm:"type:text" json:"tagIds"`
+}
+
+// TemplateChecklistItem is a checklist item every task created from the template starts with.
+type TemplateChecklistItem struct {
+	Title     string `json:"title"`
+	Mandatory bool   `json:"mandatory"`
+}
+
+type TemplateChecklist []TemplateChecklistItem
+
+type TemplateTagIDs []uint64
+
+// TaskFromTemplateRequest fills the variables of a template, the other fields override its defaults.
+type TaskFromTemplateRequest struct {
+	Variables  map[string]string `json:"variables"`
+	Priority   string            `json:"priority"`
+	DueAt      *time.Time        `json:"dueAt"`
+	AssetID    *uint64           `json:"assetId"`
+	LocationID *uint64           `json:"locationId"`
+}
+
+func (template *TaskTemplate) Prepare() error {
+	template.Name = strings.TrimSpace(template.Name)
+
+	if len(template.Name) == 0 {
+		return errors.New("the field name is required can't be empty")
+	} else if len(template.Name) > 255 {
+		return errors.New("the name is too long need to be less or equal to 255 characters")
+	}
+
+	return template.TaskTemplateContent.prepare()
+}
+
+// NewVersion freezes the current content of the template.
+func (template *TaskTemplate) NewVersion(createdBy uint64) *TaskTemplateVersion {
+	return &TaskTemplateVersion{
+		TemplateID:          template.ID,
+		Version:             template.Version,
+		CreatedBy:           createdBy,
+		TaskTemplateContent: template.TaskTemplateContent,
+	}
+}
+
+// NewTask builds the task described by the template, the placeholders are replaced by the variables of the request.
+func (template *TaskTemplate) NewTask(request TaskFromTemplateRequest, userID uint64) (*Task, error) {
+	summary, err := fillPlaceholders(template.Summary, request.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist := make([]ChecklistItem, len(template.Checklist))
+	for i, item := range template.Checklist {
+		title, err := fillPlaceholders(item.Title, request.Variables)
+		if err != nil {
+			return nil, err
+		}
+		checklist[i] = ChecklistItem{Title: title, Position: i + 1, Mandatory: item.Mandatory}
+	}
+
+	priority := template.Priority
+	if len(request.Priority) > 0 {
+		priority = request.Priority
+	}
+
+	templateID := template.ID
+	templateVersion := template.Version
+
+	return &Task{
+		Summary:         summary,
+		Status:          TaskStatusOpen,
+		UserID:          userID,
+		AssigneeID:      &userID,
+		AssetID:         request.AssetID,
+		LocationID:      request.LocationID,
+		Priority:        priority,
+		DueAt:           request.DueAt,
+		TemplateID:      &templateID,
+		TemplateVersion: &templateVersion,
+		Checklist:       checklist,
+	}, nil
+}
+
+func (content *TaskTemplateContent) prepare() error {
+	content.Summary = strings.TrimSpace(content.Summary)
+
+	if len(content.Summary) == 0 {
+		return errors.New("the field summary is required can't be empty")
+	} else if len(content.Summary) > 2500 {
+		return errors.New("the summary is too long need to be less or equal to 2500 characters")
+	}
+
+	if len(content.Priority) == 0 {
+		content.Priority = TaskPriorityMedium
+	}
+
+	if !IsValidTaskPriority(content.Priority) {
+		return fmt.Errorf("the priority %s is not a valid task priority, use one of low, medium, high, critical", content.Priority)
+	}
+
+	for i := range content.Checklist {
+		content.Checklist[i].Title = strings.TrimSpace(content.Checklist[i].Title)
+
+		if len(content.Checklist[i].Title) == 0 {
+			return errors.New("the title of a checklist item is required can't be empty")
+		} else if len(content.Checklist[i].Title) > 255 {
+			return errors.New("the title of a checklist item is too long need to be less or equal to 255 characters")
+		}
+	}
+
+	seen := make(map[uint64]bool, len(content.TagIDs))
+	tagIDs := make(TemplateTagIDs, 0, len(content.TagIDs))
+	for _, tagID := range content.TagIDs {
+		if !seen[tagID] {
+			seen[tagID] = true
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+	content.TagIDs = tagIDs
+
+	return nil
+}
+
+// fillPlaceholders replaces every {{variable}} of the text, all the variables used need a value.
+func fillPlaceholders(text string, variables map[string]string) (string, error) {
+	missing := map[string]bool{}
+
+	filled := templatePlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := templatePlaceholder.FindStringSubmatch(placeholder)[1]
+		value, ok := variables[name]
+		if !ok {
+			missing[name] = true
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if len(names) == 1 {
+			return "", fmt.Errorf("the variable %s needs a value", names[0])
+		}
+		return "", fmt.Errorf("the variables %s need a value", strings.Join(names, ", "))
+	}
+
+	return filled, nil
+}
+
+func (checklist TemplateChecklist) Value() (driver.Value, error) {
+	if checklist == nil {
+		return "[]", nil
+	}
+
+	return marshalTemplateField(checklist)
+}
+
+func (checklist *TemplateChecklist) Scan(value interface{}) error {
+	return unmarshalTemplateField(value, checklist)
+}
+
+func (tagIDs TemplateTagIDs) Value() (driver.Value, error) {
+	if tagIDs == nil {
+		return "[]", nil
+	}
+
+	return marshalTemplateField(tagIDs)
+}
+
+func (tagIDs *TemplateTagIDs) Scan(value interface{}) error {
+	return unmarshalTemplateField(value, tagIDs)
+}
+
+func marshalTemplateField(value interface{}) (driver.Value, error) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(bytes), nil
+}
+
+func unmarshalTemplateField(value interface{}, field interface{}) error {
+	var bytes []byte
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		bytes = typed
+	case string:
+		bytes = []byte(typed)
+	default:
+		return errors.New("not possible to convert the template content")
+	}
+
+	return json.Unmarshal(bytes, field)
+}
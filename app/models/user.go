@@ -2,23 +2,37 @@ package models
 
 import (
 	"api/app/security"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/badoux/checkmail"
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
-	Name      string    `gorm:"not null" json:"name,omitempty"`
-	Email     string    `gorm:"not null; unique" json:"email"`
-	Password  string    `gorm:"not null" json:"password"`
-	Type      string    `gorm:"not null" json:"type,omitempty"`
-	Tasks     []Task    `json:"tasks,omitempty"`
-	CreatedAt time.Time `json:"createdAt,omitempty"`
-	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+	ID                uint64         `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name              string         `gorm:"not null" json:"name,omitempty"`
+	Email             string         `gorm:"not null; unique" json:"email"`
+	Password          string         `gorm:"not null" json:"password,omitempty"`
+	Type              string         `gorm:"not null" json:"type,omitempty"`
+	Tasks             []Task         `json:"tasks,omitempty"`
+	CreatedAt         time.Time      `json:"createdAt,omitempty"`
+	UpdatedAt         time.Time      `json:"updatedAt,omitempty"`
+	DeactivatedAt     *time.Time     `json:"deactivatedAt,omitempty"`
+	PasswordChangedAt *time.Time     `json:"-"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// UserUpdate holds the profile fields to change, the ones left out keep their value.
+type UserUpdate struct {
+	Name     *string `json:"name"`
+	Email    *string `json:"email"`
+	Password *string `json:"password"`
+	Type     *string `json:"type"`
 }
 
 func (user *User) Prepare() error {
@@ -68,11 +82,11 @@ func (user *User) validate() error {
 		}
 
 		errs = fmt.Sprintf("%sthe field type is required can't be empty", errs)
-	} else if user.Type != "Technician" && user.Type != "Manager" {
+	} else if !IsValidUserType(user.Type) {
 		if len(errs) > 0 {
 			errs = fmt.Sprintf("%s\n", errs)
 		}
-		errs = fmt.Sprintf("%sthe field type should be Technician or Manager", errs)
+		errs = fmt.Sprintf("%sthe field type should be Technician, Manager or Admin", errs)
 	}
 
 	if len(errs) > 0 {
@@ -96,3 +110,112 @@ func (user *User) format() error {
 
 	return nil
 }
+
+// NewBootstrapAdmin returns the first Admin of the given email, its password is random so it has to be set through a password reset.
+func NewBootstrapAdmin(email string) (*User, error) {
+	email = strings.TrimSpace(email)
+	if err := checkmail.ValidateFormat(email); err != nil {
+		return nil, fmt.Errorf("the admin email %s is invalid", email)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := security.Hash(base64.RawURLEncoding.EncodeToString(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	return &User{
+		Name:     "Admin",
+		Email:    email,
+		Password: string(hashedPassword),
+		Type:     "Admin",
+	}, nil
+}
+
+// IsActive reports whether the user can still log in and use its tokens.
+func (user *User) IsActive() bool {
+	return user.DeactivatedAt == nil
+}
+
+// IsValidUserType tells if the type is one a user can be given. Only a Technician can register on its own,
+// the Managers and the Admins are invited and the first Admin comes from the configuration.
+func IsValidUserType(userType string) bool {
+	return userType == "Technician" || userType == "Manager" || userType == "Admin"
+}
+
+func (update *UserUpdate) Prepare() error {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if len(name) == 0 {
+			return errors.New("the field name can't be empty")
+		}
+		update.Name = &name
+	}
+
+	if update.Email != nil {
+		email := strings.TrimSpace(*update.Email)
+		if err := checkmail.ValidateFormat(email); err != nil {
+			return errors.New("the email is invalid")
+		}
+		update.Email = &email
+	}
+
+	if update.Type != nil && !IsValidUserType(*update.Type) {
+		return errors.New("the field type should be Technician, Manager or Admin")
+	}
+
+	if update.Password != nil {
+		if len(*update.Password) == 0 {
+			return errors.New("the field password can't be empty")
+		}
+
+		hashedPassword, err := security.Hash(*update.Password)
+		if err != nil {
+			return err
+		}
+
+		password := string(hashedPassword)
+		update.Password = &password
+	}
+
+	if update.Name == nil && update.Email == nil && update.Password == nil && update.Type == nil {
+		return errors.New("at least one of the fields name, email, password, type is required")
+	}
+
+	return nil
+}
+
+// Apply copies the changed fields into the user.
+func (update *UserUpdate) Apply(user *User) {
+	if update.Name != nil {
+		user.Name = *update.Name
+	}
+
+	if update.Email != nil {
+		user.Email = *update.Email
+	}
+
+	if update.Password != nil {
+		user.SetPassword(*update.Password, time.Now())
+	}
+
+	if update.Type != nil {
+		user.Type = *update.Type
+	}
+}
+
+// SetPassword changes the hashed password, the sessions opened before stop working.
+func (user *User) SetPassword(hashedPassword string, now time.Time) {
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+}
+
+// IssuedBeforePasswordChange tells whether a token issued at the given date predates the last password change,
+// the dates are compared by millisecond since it is the precision of the tokens and a token of the same millisecond is refused.
+func (user *User) IssuedBeforePasswordChange(issuedAt time.Time) bool {
+	return user.PasswordChangedAt != nil && !issuedAt.Truncate(time.Millisecond).After(user.PasswordChangedAt.Truncate(time.Millisecond))
+}
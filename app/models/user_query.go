@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultUserPageSize = 20
+	MaxUserPageSize     = 100
+)
+
+// UserQuery holds the filters and the keyset pagination options used to list users, the users are sorted by ID.
+type UserQuery struct {
+	Type   string
+	Active *bool
+	Text   string
+	Cursor uint64
+	Limit  int
+}
+
+type UserPage struct {
+	Data       []User `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int64  `json:"total"`
+}
+
+func (query *UserQuery) Prepare() error {
+	if len(query.Type) > 0 && !IsValidUserType(query.Type) {
+		return fmt.Errorf("the type %s is not a valid user type", query.Type)
+	}
+
+	if query.Limit < 0 || query.Limit > MaxUserPageSize {
+		return fmt.Errorf("the limit should be between 1 and %d", MaxUserPageSize)
+	}
+
+	query.Text = strings.TrimSpace(query.Text)
+
+	if query.Limit == 0 {
+		query.Limit = DefaultUserPageSize
+	}
+
+	return nil
+}
+
+func EncodeUserCursor(userID uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(userID, 10)))
+}
+
+func DecodeUserCursor(encoded string) (uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, errors.New("the cursor is invalid")
+	}
+
+	userID, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, errors.New("the cursor is invalid")
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxWorkLogDuration is the longest work log that can be entered by hand.
+	MaxWorkLogDuration = 24 * time.Hour
+	// MaxWorkLogAge is how far in the past a work log entered by hand can start.
+	MaxWorkLogAge = 90 * 24 * time.Hour
+)
+
+// WorkLog is the time a technician spent on a task, a running timer has no end yet.
+type WorkLog struct {
+	ID              uint64     `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	TaskID          uint64     `gorm:"not null;index" json:"taskId"`
+	TechnicianID    uint64     `gorm:"not null;index:idx_work_log_technician" json:"technicianId"`
+	StartedAt       time.Time  `gorm:"not null;index:idx_work_log_technician" json:"startedAt"`
+	EndedAt         *time.Time `json:"endedAt,omitempty"`
+	DurationSeconds int64      `gorm:"not null;default:0" json:"durationSeconds"`
+	Note            string     `gorm:"size:1000" json:"note,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt       time.Time  `json:"modifiedAt,omitempty"`
+}
+
+// WorkLogRequest is a work log entered by hand, managers can log the time of any technician.
+type WorkLogRequest struct {
+	StartedAt       *time.Time `json:"startedAt"`
+	DurationSeconds int64      `json:"durationSeconds"`
+	Note            string     `json:"note"`
+	TechnicianID    uint64     `json:"technicianId"`
+}
+
+// TimerStop holds the optional note written when a timer is stopped.
+type TimerStop struct {
+	Note string `json:"note"`
+}
+
+// Timesheet sums up the time a technician logged over a period.
+type Timesheet struct {
+	TechnicianID    uint64    `json:"technicianId"`
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	DurationSeconds int64     `json:"durationSeconds"`
+	Entries         []WorkLog `json:"entries"`
+}
+
+// IsRunning tells if the work log is a timer that wasn't stopped yet.
+func (workLog *WorkLog) IsRunning() bool {
+	return workLog.EndedAt == nil
+}
+
+// Stop ends the running timer at the given time.
+func (workLog *WorkLog) Stop(now time.Time, note string) {
+	workLog.EndedAt = &now
+	workLog.DurationSeconds = int64(now.Sub(workLog.StartedAt) / time.Second)
+
+	if note = strings.TrimSpace(note); len(note) > 0 {
+		workLog.Note = note
+	}
+}
+
+// NewWorkLog builds a finished work log from a manual entry, it ends now when no start is given.
+func (request *WorkLogRequest) NewWorkLog(taskID uint64, technicianID uint64, now time.Time) (*WorkLog, error) {
+	if request.DurationSeconds <= 0 {
+		return nil, errors.New("the field durationSeconds should be greater than 0")
+	}
+
+	// checked before converting, a huge number of seconds would overflow the duration
+	if request.DurationSeconds > int64(MaxWorkLogDuration/time.Second) {
+		return nil, fmt.Errorf("the field durationSeconds should be less or equal to %d", int64(MaxWorkLogDuration/time.Second))
+	}
+
+	duration := time.Duration(request.DurationSeconds) * time.Second
+	startedAt := now.Add(-duration)
+	if request.StartedAt != nil {
+		startedAt = *request.StartedAt
+	}
+	endedAt := startedAt.Add(duration)
+
+	if endedAt.After(now) {
+		return nil, errors.New("not possible to log time in the future")
+	}
+
+	if startedAt.Before(now.Add(-MaxWorkLogAge)) {
+		return nil, fmt.Errorf("not possible to log time started more than %d days ago", int(MaxWorkLogAge.Hours()/24))
+	}
+
+	workLog := &WorkLog{
+		TaskID:          taskID,
+		TechnicianID:    technicianID,
+		StartedAt:       startedAt,
+		EndedAt:         &endedAt,
+		DurationSeconds: request.DurationSeconds,
+		Note:            strings.TrimSpace(request.Note),
+	}
+
+	if err := workLog.validate(); err != nil {
+		return nil, err
+	}
+
+	return workLog, nil
+}
+
+func (workLog *WorkLog) validate() error {
+	if len(workLog.Note) > 1000 {
+		return errors.New("the note is too long need to be less or equal to 1000 characters")
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var AssetRepo AssetRepoInterface = &assetRepo{}
+
+// assetInUseError keeps an asset with a maintenance history from being deleted.
+type assetInUseError struct {
+	tasks int64
+}
+
+func (err *assetInUseError) Error() string {
+	return fmt.Sprintf("the asset is linked to %d tasks, it can't be deleted", err.tasks)
+}
+
+type AssetRepoInterface interface {
+	Get(assetID uint64) (*models.Asset, error_utils.MessageErr)
+	GetAll(category string) ([]models.Asset, error_utils.MessageErr)
+	Create(*models.Asset) (*models.Asset, error_utils.MessageErr)
+	Update(*models.Asset) (*models.Asset, error_utils.MessageErr)
+	Delete(assetID uint64) error_utils.MessageErr
+	Tasks(assetID uint64, query models.TaskQuery) ([]models.Task, error_utils.MessageErr)
+	Stats(assetIDs []uint64) (map[uint64]models.AssetStats, error_utils.MessageErr)
+	Init()
+}
+
+type assetRepo struct {
+	db *gorm.DB
+}
+
+func (assetRepo *assetRepo) Init() {
+	assetRepo.db = database.Database
+}
+
+func NewAssetRepository(db *gorm.DB) AssetRepoInterface {
+	return &assetRepo{db: db}
+}
+
+func (assetRepo *assetRepo) Get(assetID uint64) (*models.Asset, error_utils.MessageErr) {
+	asset := &models.Asset{}
+	result := assetRepo.db.First(asset, assetID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return asset, nil
+}
+
+func (assetRepo *assetRepo) GetAll(category string) ([]models.Asset, error_utils.MessageErr) {
+	assets := []models.Asset{}
+	db := assetRepo.db
+
+	if len(category) > 0 {
+		db = db.Where("category = ?", category)
+	}
+
+	result := db.Order("name, id").Find(&assets)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return assets, nil
+}
+
+func (assetRepo *assetRepo) Create(asset *models.Asset) (*models.Asset, error_utils.MessageErr) {
+	result := assetRepo.db.Create(asset)
+
+	if result.Error != nil {
+		return nil, parseAssetError(result.Error, asset)
+	}
+
+	return asset, nil
+}
+
+func (assetRepo *assetRepo) Update(asset *models.Asset) (*models.Asset, error_utils.MessageErr) {
+	result := assetRepo.db.Model(asset).Select("name", "serial_number", "location", "location_id", "category").Updates(asset)
+
+	if result.Error != nil {
+		return nil, parseAssetError(result.Error, asset)
+	}
+
+	return asset, nil
+}
+
+// Delete removes an asset no task was ever linked to, the trashed tasks count as well.
+func (assetRepo *assetRepo) Delete(assetID uint64) error_utils.MessageErr {
+	err := assetRepo.db.Transaction(func(tx *gorm.DB) error {
+		var tasks int64
+		if err := tx.Unscoped().Model(&models.Task{}).Where("asset_id = ?", assetID).Count(&tasks).Error; err != nil {
+			return err
+		}
+
+		if tasks > 0 {
+			return &assetInUseError{tasks: tasks}
+		}
+
+		result := tx.Delete(&models.Asset{}, assetID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		var errInUse *assetInUseError
+		if errors.As(err, &errInUse) {
+			return error_utils.NewConflictError(err.Error())
+		}
+		return error_formats.ParseError(err)
+	}
+
+	return nil
+}
+
+// Tasks returns the maintenance history of the asset, the most recent tasks first.
+// The user and the sites of the query restrict it like the task listing does.
+func (assetRepo *assetRepo) Tasks(assetID uint64, query models.TaskQuery) ([]models.Task, error_utils.MessageErr) {
+	tasks := []models.Task{}
+	result := filterTasks(assetRepo.db.Where("asset_id = ?", assetID), query).Order("created_at DESC, id DESC").Find(&tasks)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return tasks, nil
+}
+
+// Stats returns when each asset was last serviced and how many of its tasks are still pending.
+func (assetRepo *assetRepo) Stats(assetIDs []uint64) (map[uint64]models.AssetStats, error_utils.MessageErr) {
+	stats := make(map[uint64]models.AssetStats, len(assetIDs))
+	if len(assetIDs) == 0 {
+		return stats, nil
+	}
+
+	var rows []struct {
+		AssetID        uint64
+		LastServicedAt *time.Time
+		OpenTasks      int64
+	}
+
+	result := assetRepo.db.Model(&models.Task{}).
+		Select(
+			"asset_id, MAX(CASE WHEN status = ? THEN completed_at END) AS last_serviced_at, SUM(CASE WHEN status IN ? THEN 0 ELSE 1 END) AS open_tasks",
+			models.TaskStatusDone, resolvedStatuses,
+		).
+		Where("asset_id IN ?", assetIDs).
+		Group("asset_id").
+		Scan(&rows)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	for _, row := range rows {
+		stats[row.AssetID] = models.AssetStats{AssetID: row.AssetID, LastServicedAt: row.LastServicedAt, OpenTasks: row.OpenTasks}
+	}
+
+	return stats, nil
+}
+
+// parseAssetError turns a duplicated serial number into a 409, every other error is parsed as usual.
+func parseAssetError(err error, asset *models.Asset) error_utils.MessageErr {
+	if error_formats.IsDuplicateEntry(err) {
+		return error_utils.NewConflictError(fmt.Sprintf("the serial number %s is already registered", asset.SerialNumber))
+	}
+
+	return error_formats.ParseError(err)
+}
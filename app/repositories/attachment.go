@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+
+	"gorm.io/gorm"
+)
+
+var AttachmentRepo AttachmentRepoInterface = &attachmentRepo{}
+
+type AttachmentRepoInterface interface {
+	Get(attachmentID uint64) (*models.Attachment, error_utils.MessageErr)
+	GetAllByTaskID(taskID uint64) ([]models.Attachment, error_utils.MessageErr)
+	Create(*models.Attachment) (*models.Attachment, error_utils.MessageErr)
+	Delete(attachmentID uint64) error_utils.MessageErr
+	Init()
+}
+
+type attachmentRepo struct {
+	db *gorm.DB
+}
+
+func (attachmentRepo *attachmentRepo) Init() {
+	attachmentRepo.db = database.Database
+}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepoInterface {
+	return &attachmentRepo{db: db}
+}
+
+func (attachmentRepo *attachmentRepo) Get(attachmentID uint64) (*models.Attachment, error_utils.MessageErr) {
+	attachment := &models.Attachment{}
+	result := attachmentRepo.db.First(attachment, attachmentID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return attachment, nil
+}
+
+func (attachmentRepo *attachmentRepo) GetAllByTaskID(taskID uint64) ([]models.Attachment, error_utils.MessageErr) {
+	attachments := []models.Attachment{}
+	result := attachmentRepo.db.Where(&models.Attachment{TaskID: taskID}).Order("created_at, id").Find(&attachments)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return attachments, nil
+}
+
+func (attachmentRepo *attachmentRepo) Create(attachment *models.Attachment) (*models.Attachment, error_utils.MessageErr) {
+	result := attachmentRepo.db.Create(attachment)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return attachment, nil
+}
+
+func (attachmentRepo *attachmentRepo) Delete(attachmentID uint64) error_utils.MessageErr {
+	result := attachmentRepo.db.Delete(&models.Attachment{}, attachmentID)
+
+	if result.Error != nil {
+		return error_formats.ParseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+
+	"gorm.io/gorm"
+)
+
+var ChecklistRepo ChecklistRepoInterface = &checklistRepo{}
+
+type ChecklistRepoInterface interface {
+	Get(itemID uint64) (*models.ChecklistItem, error_utils.MessageErr)
+	GetAllByTaskID(taskID uint64) ([]models.ChecklistItem, error_utils.MessageErr)
+	Create(*models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr)
+	Update(*models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr)
+	Delete(itemID uint64) error_utils.MessageErr
+	CountPendingMandatory(taskID uint64) (int64, error_utils.MessageErr)
+	Init()
+}
+
+type checklistRepo struct {
+	db *gorm.DB
+}
+
+func (checklistRepo *checklistRepo) Init() {
+	checklistRepo.db = database.Database
+}
+
+func NewChecklistRepository(db *gorm.DB) ChecklistRepoInterface {
+	return &checklistRepo{db: db}
+}
+
+func (checklistRepo *checklistRepo) Get(itemID uint64) (*models.ChecklistItem, error_utils.MessageErr) {
+	item := &models.ChecklistItem{}
+	result := checklistRepo.db.First(item, itemID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return item, nil
+}
+
+func (checklistRepo *checklistRepo) GetAllByTaskID(taskID uint64) ([]models.ChecklistItem, error_utils.MessageErr) {
+	items := []models.ChecklistItem{}
+	result := checklistRepo.db.Where(&models.ChecklistItem{TaskID: taskID}).Order("position, id").Find(&items)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return items, nil
+}
+
+// Create appends the item at the end of the checklist unless it comes with a position.
+func (checklistRepo *checklistRepo) Create(item *models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr) {
+	err := checklistRepo.db.Transaction(func(tx *gorm.DB) error {
+		if item.Position == 0 {
+			var last int
+			if err := tx.Model(&models.ChecklistItem{}).Where("task_id = ?", item.TaskID).
+				Select("COALESCE(MAX(position), 0)").Scan(&last).Error; err != nil {
+				return err
+			}
+			item.Position = last + 1
+		}
+
+		return tx.Create(item).Error
+	})
+
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+
+	return item, nil
+}
+
+func (checklistRepo *checklistRepo) Update(item *models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr) {
+	result := checklistRepo.db.Model(item).
+		Select("title", "position", "mandatory", "done", "completed_at", "completed_by").
+		Updates(item)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return item, nil
+}
+
+func (checklistRepo *checklistRepo) Delete(itemID uint64) error_utils.MessageErr {
+	result := checklistRepo.db.Delete(&models.ChecklistItem{}, itemID)
+
+	if result.Error != nil {
+		return error_formats.ParseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	return nil
+}
+
+func (checklistRepo *checklistRepo) CountPendingMandatory(taskID uint64) (int64, error_utils.MessageErr) {
+	var pending int64
+	result := checklistRepo.db.Model(&models.ChecklistItem{}).
+		Where("task_id = ? AND mandatory = ? AND done = ?", taskID, true, false).
+		Count(&pending)
+
+	if result.Error != nil {
+		return 0, error_formats.ParseError(result.Error)
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+
+	"gorm.io/gorm"
+)
+
+var CommentRepo CommentRepoInterface = &commentRepo{}
+
+type CommentRepoInterface interface {
+	Get(commentID uint64) (*models.Comment, error_utils.MessageErr)
+	GetAllByTaskID(taskID uint64) ([]models.Comment, error_utils.MessageErr)
+	Create(*models.Comment) (*models.Comment, error_utils.MessageErr)
+	Update(*models.Comment) (*models.Comment, error_utils.MessageErr)
+	Delete(commentID uint64) error_utils.MessageErr
+	Init()
+}
+
+type commentRepo struct {
+	db *gorm.DB
+}
+
+func (commentRepo *commentRepo) Init() {
+	commentRepo.db = database.Database
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepoInterface {
+	return &commentRepo{db: db}
+}
+
+func (commentRepo *commentRepo) Get(commentID uint64) (*models.Comment, error_utils.MessageErr) {
+	comment := &models.Comment{}
+	result := commentRepo.db.First(comment, commentID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return comment, nil
+}
+
+func (commentRepo *commentRepo) GetAllByTaskID(taskID uint64) ([]models.Comment, error_utils.MessageErr) {
+	comments := []models.Comment{}
+	result := commentRepo.db.Where(&models.Comment{TaskID: taskID}).Order("created_at, id").Find(&comments)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return comments, nil
+}
+
+func (commentRepo *commentRepo) Create(comment *models.Comment) (*models.Comment, error_utils.MessageErr) {
+	result := commentRepo.db.Create(comment)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return comment, nil
+}
+
+func (commentRepo *commentRepo) Update(comment *models.Comment) (*models.Comment, error_utils.MessageErr) {
+	result := commentRepo.db.Model(comment).Update("body", comment.Body)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return comment, nil
+}
+
+func (commentRepo *commentRepo) Delete(commentID uint64) error_utils.MessageErr {
+	result := commentRepo.db.Delete(&models.Comment{}, commentID)
+
+	if result.Error != nil {
+		return error_formats.ParseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var DependencyRepo DependencyRepoInterface = &dependencyRepo{}
+
+var errDependencyCycle = errors.New("the task can't be blocked by a task that already depends on it")
+
+// resolvedStatuses are the statuses where a task stops blocking its dependents.
+var resolvedStatuses = []string{models.TaskStatusDone, models.TaskStatusCancelled}
+
+type DependencyRepoInterface interface {
+	GetAllByTaskID(taskID uint64) ([]models.TaskDependency, error_utils.MessageErr)
+	Create(*models.TaskDependency) (*models.TaskDependency, error_utils.MessageErr)
+	Delete(taskID uint64, blockedByID uint64) error_utils.MessageErr
+	BlockedTaskIDs(taskIDs []uint64) (map[uint64]bool, error_utils.MessageErr)
+	Unblocked(blockerID uint64) ([]uint64, error_utils.MessageErr)
+	Init()
+}
+
+type dependencyRepo struct {
+	db *gorm.DB
+}
+
+func (dependencyRepo *dependencyRepo) Init() {
+	dependencyRepo.db = database.Database
+}
+
+func NewDependencyRepository(db *gorm.DB) DependencyRepoInterface {
+	return &dependencyRepo{db: db}
+}
+
+func (dependencyRepo *dependencyRepo) GetAllByTaskID(taskID uint64) ([]models.TaskDependency, error_utils.MessageErr) {
+	dependencies := []models.TaskDependency{}
+	result := dependencyRepo.db.Where(&models.TaskDependency{TaskID: taskID}).Order("created_at, blocked_by_id").Find(&dependencies)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return dependencies, nil
+}
+
+// Create links the task to its blocker unless the blocker already depends on the task, linking twice is a no-op.
+func (dependencyRepo *dependencyRepo) Create(dependency *models.TaskDependency) (*models.TaskDependency, error_utils.MessageErr) {
+	err := dependencyRepo.db.Transaction(func(tx *gorm.DB) error {
+		cycle, err := dependsOn(tx, dependency.BlockedByID, dependency.TaskID)
+		if err != nil {
+			return err
+		}
+
+		if cycle {
+			return errDependencyCycle
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(dependency).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, errDependencyCycle) {
+			return nil, error_utils.NewConflictError(err.Error())
+		}
+		return nil, error_formats.ParseError(err)
+	}
+
+	return dependency, nil
+}
+
+func (dependencyRepo *dependencyRepo) Delete(taskID uint64, blockedByID uint64) error_utils.MessageErr {
+	result := dependencyRepo.db.Where("task_id = ? AND blocked_by_id = ?", taskID, blockedByID).Delete(&models.TaskDependency{})
+
+	if result.Error != nil {
+		return error_formats.ParseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	return nil
+}
+
+// BlockedTaskIDs returns which of the given tasks still wait on a blocker that isn't resolved nor deleted.
+func (dependencyRepo *dependencyRepo) BlockedTaskIDs(taskIDs []uint64) (map[uint64]bool, error_utils.MessageErr) {
+	blocked := map[uint64]bool{}
+	if len(taskIDs) == 0 {
+		return blocked, nil
+	}
+
+	var blockedIDs []uint64
+	result := dependencyRepo.db.Model(&models.TaskDependency{}).
+		Joins("JOIN tasks ON tasks.id = task_dependencies.blocked_by_id AND tasks.deleted_at IS NULL").
+		Where("task_dependencies.task_id IN ? AND tasks.status NOT IN ?", taskIDs, resolvedStatuses).
+		Distinct().Pluck("task_dependencies.task_id", &blockedIDs)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	for _, taskID := range blockedIDs {
+		blocked[taskID] = true
+	}
+
+	return blocked, nil
+}
+
+// Unblocked returns the tasks waiting on the blocker that have no other pending blocker left.
+func (dependencyRepo *dependencyRepo) Unblocked(blockerID uint64) ([]uint64, error_utils.MessageErr) {
+	pending := dependencyRepo.db.Table("task_dependencies AS pending").
+		Select("1").
+		Joins("JOIN tasks AS blockers ON blockers.id = pending.blocked_by_id AND blockers.deleted_at IS NULL").
+		Where("pending.task_id = task_dependencies.task_id AND blockers.status NOT IN ?", resolvedStatuses)
+
+	var taskIDs []uint64
+	result := dependencyRepo.db.Model(&models.TaskDependency{}).
+		Joins("JOIN tasks ON tasks.id = task_dependencies.task_id AND tasks.deleted_at IS NULL").
+		Where("task_dependencies.blocked_by_id = ? AND NOT EXISTS (?)", blockerID, pending).
+		Pluck("task_dependencies.task_id", &taskIDs)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return taskIDs, nil
+}
+
+// dependsOn walks the blockers of the task looking for the target, the locking reads keep a concurrent
+// link from closing a cycle between the walk and the insert.
+func dependsOn(tx *gorm.DB, taskID uint64, target uint64) (bool, error) {
+	visited := map[uint64]bool{taskID: true}
+	frontier := []uint64{taskID}
+
+	for len(frontier) > 0 {
+		var blockerIDs []uint64
+		if err := tx.Model(&models.TaskDependency{}).Clauses(clause.Locking{Strength: "SHARE"}).
+			Where("task_id IN ?", frontier).Pluck("blocked_by_id", &blockerIDs).Error; err != nil {
+			return false, err
+		}
+
+		frontier = frontier[:0]
+		for _, blockerID := range blockerIDs {
+			if blockerID == target {
+				return true, nil
+			}
+
+			if !visited[blockerID] {
+				visited[blockerID] = true
+				frontier = append(frontier, blockerID)
+			}
+		}
+	}
+
+	return false, nil
+}
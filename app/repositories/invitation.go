@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var InvitationRepo InvitationRepoInterface = &invitationRepo{}
+
+var (
+	errInvitationUsed    = errors.New("the invitation was already accepted")
+	errInvitationExpired = errors.New("the invitation expired")
+	errEmailRegistered   = errors.New("the email is already registered")
+)
+
+type InvitationRepoInterface interface {
+	Get(invitationID uint64) (*models.Invitation, error_utils.MessageErr)
+	GetAll() ([]models.Invitation, error_utils.MessageErr)
+	Create(invitation *models.Invitation, deliver func(invitation *models.Invitation) error) (*models.Invitation, error_utils.MessageErr)
+	Delete(invitationID uint64) error_utils.MessageErr
+	Accept(request models.InvitationAcceptRequest, now time.Time) (*models.User, error_utils.MessageErr)
+	Init()
+}
+
+type invitationRepo struct {
+	db *gorm.DB
+}
+
+func (invitationRepo *invitationRepo) Init() {
+	invitationRepo.db = database.Database
+}
+
+func NewInvitationRepository(db *gorm.DB) InvitationRepoInterface {
+	return &invitationRepo{db: db}
+}
+
+func (invitationRepo *invitationRepo) Get(invitationID uint64) (*models.Invitation, error_utils.MessageErr) {
+	invitation := &models.Invitation{}
+	result := invitationRepo.db.First(invitation, invitationID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return invitation, nil
+}
+
+func (invitationRepo *invitationRepo) GetAll() ([]models.Invitation, error_utils.MessageErr) {
+	invitations := []models.Invitation{}
+	result := invitationRepo.db.Order("created_at DESC, id DESC").Find(&invitations)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return invitations, nil
+}
+
+// Create saves the invitation and delivers it, an invitation that couldn't be sent is removed.
+// The email is sent once the row is committed so the transaction doesn't wait on the mail server.
+func (invitationRepo *invitationRepo) Create(invitation *models.Invitation, deliver func(invitation *models.Invitation) error) (*models.Invitation, error_utils.MessageErr) {
+	err := invitationRepo.db.Transaction(func(tx *gorm.DB) error {
+		// the deleted users keep their email
+		var users int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("email = ?", invitation.Email).Count(&users).Error; err != nil {
+			return err
+		}
+
+		if users > 0 {
+			return errEmailRegistered
+		}
+
+		return tx.Create(invitation).Error
+	})
+
+	if err != nil {
+		return nil, parseInvitationError(err, invitation.Email)
+	}
+
+	if errDeliver := deliver(invitation); errDeliver != nil {
+		if err := invitationRepo.db.Delete(invitation).Error; err != nil {
+			log.Println("it's not possible to remove the invitation", invitation.ID, "that couldn't be sent", err)
+		}
+
+		return nil, error_utils.NewInternalServerError(fmt.Sprintf("it's not possible to send the invitation: %s", errDeliver.Error()))
+	}
+
+	return invitation, nil
+}
+
+// Delete revokes an invitation that wasn't accepted yet.
+func (invitationRepo *invitationRepo) Delete(invitationID uint64) error_utils.MessageErr {
+	err := invitationRepo.db.Transaction(func(tx *gorm.DB) error {
+		var invitation models.Invitation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invitation, invitationID).Error; err != nil {
+			return err
+		}
+
+		if invitation.AcceptedAt != nil {
+			return errInvitationUsed
+		}
+
+		return tx.Delete(&invitation).Error
+	})
+
+	if err != nil {
+		return parseInvitationError(err, "")
+	}
+
+	return nil
+}
+
+// Accept redeems the invitation of the token and creates its user, a token only works once and before it expires.
+func (invitationRepo *invitationRepo) Accept(request models.InvitationAcceptRequest, now time.Time) (*models.User, error_utils.MessageErr) {
+	var user *models.User
+	invitation := &models.Invitation{}
+
+	err := invitationRepo.db.Transaction(func(tx *gorm.DB) error {
+		// the row is locked so the same token can't be redeemed twice at the same time
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", models.HashInvitationToken(request.Token)).
+			First(invitation).Error; err != nil {
+			return err
+		}
+
+		if invitation.AcceptedAt != nil {
+			return errInvitationUsed
+		}
+
+		if !invitation.ExpiresAt.After(now) {
+			return errInvitationExpired
+		}
+
+		user = invitation.NewUser(request)
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		invitation.AcceptedAt = &now
+		invitation.UserID = &user.ID
+		return tx.Model(invitation).Select("accepted_at", "user_id").Updates(invitation).Error
+	})
+
+	if err != nil {
+		return nil, parseInvitationError(err, invitation.Email)
+	}
+
+	return user, nil
+}
+
+// parseInvitationError turns the state of the invitation into a 409, every other error is parsed as usual.
+func parseInvitationError(err error, email string) error_utils.MessageErr {
+	if errors.Is(err, errInvitationUsed) || errors.Is(err, errInvitationExpired) {
+		return error_utils.NewConflictError(err.Error())
+	}
+
+	if errors.Is(err, errEmailRegistered) || error_formats.IsDuplicateEntry(err) {
+		return error_utils.NewConflictError(fmt.Sprintf("the email %s is already registered", email))
+	}
+
+	return error_formats.ParseError(err)
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var LeaseRepo LeaseRepoInterface = &leaseRepo{}
+
+type LeaseRepoInterface interface {
+	Acquire(name string, holder string, now time.Time, ttl time.Duration) (bool, error_utils.MessageErr)
+	Init()
+}
+
+type leaseRepo struct {
+	db *gorm.DB
+}
+
+func (leaseRepo *leaseRepo) Init() {
+	leaseRepo.db = database.Database
+}
+
+func NewLeaseRepository(db *gorm.DB) LeaseRepoInterface {
+	return &leaseRepo{db: db}
+}
+
+// Acquire takes or renews the lease for the holder, it fails while another holder has a lease that isn't expired.
+func (leaseRepo *leaseRepo) Acquire(name string, holder string, now time.Time, ttl time.Duration) (bool, error_utils.MessageErr) {
+	acquired := false
+
+	err := leaseRepo.db.Transaction(func(tx *gorm.DB) error {
+		lease := models.JobLease{}
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).Limit(1).Find(&lease)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			// two replicas may try to create it at once, only one insert goes through
+			lease = models.JobLease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)}
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lease)
+			acquired = result.Error == nil && result.RowsAffected > 0
+			return result.Error
+		}
+
+		if lease.Holder != holder && lease.ExpiresAt.After(now) {
+			return nil
+		}
+
+		acquired = true
+		return tx.Model(&lease).Updates(map[string]interface{}{"holder": holder, "expires_at": now.Add(ttl)}).Error
+	})
+
+	if err != nil {
+		return false, error_formats.ParseError(err)
+	}
+
+	return acquired, nil
+}
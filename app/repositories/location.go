@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var LocationRepo LocationRepoInterface = &locationRepo{}
+
+var errNotOnlySites = errors.New("a manager can only be scoped to sites")
+
+// invalidLocationError is a location that doesn't fit in the hierarchy.
+type invalidLocationError struct {
+	message string
+}
+
+func (err *invalidLocationError) Error() string {
+	return err.message
+}
+
+type LocationRepoInterface interface {
+	Get(locationID uint64) (*models.Location, error_utils.MessageErr)
+	GetAll() ([]models.Location, error_utils.MessageErr)
+	Create(*models.Location) (*models.Location, error_utils.MessageErr)
+	Update(*models.Location) (*models.Location, error_utils.MessageErr)
+	Delete(locationID uint64) error_utils.MessageErr
+	GetUserSites(userID uint64) ([]uint64, error_utils.MessageErr)
+	IsInSites(locationID *uint64, siteIDs []uint64) (bool, error_utils.MessageErr)
+	SetUserSites(userID uint64, siteIDs []uint64) error_utils.MessageErr
+	Init()
+}
+
+type locationRepo struct {
+	db *gorm.DB
+}
+
+func (locationRepo *locationRepo) Init() {
+	locationRepo.db = database.Database
+}
+
+func NewLocationRepository(db *gorm.DB) LocationRepoInterface {
+	return &locationRepo{db: db}
+}
+
+func (locationRepo *locationRepo) Get(locationID uint64) (*models.Location, error_utils.MessageErr) {
+	location := &models.Location{}
+	result := locationRepo.db.First(location, locationID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return location, nil
+}
+
+// GetAll returns the whole hierarchy, every location comes right after its parent.
+func (locationRepo *locationRepo) GetAll() ([]models.Location, error_utils.MessageErr) {
+	locations := []models.Location{}
+	result := locationRepo.db.Order("path").Find(&locations)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return locations, nil
+}
+
+// Create adds the location under its parent, the parent is locked so it can't be deleted meanwhile.
+func (locationRepo *locationRepo) Create(location *models.Location) (*models.Location, error_utils.MessageErr) {
+	err := locationRepo.db.Transaction(func(tx *gorm.DB) error {
+		var parent *models.Location
+		if location.ParentID != nil {
+			parent = &models.Location{}
+			result := tx.Clauses(clause.Locking{Strength: "SHARE"}).Limit(1).Find(parent, *location.ParentID)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				return &invalidLocationError{message: fmt.Sprintf("the parent location %d doesn't exist", *location.ParentID)}
+			}
+
+			if err := location.CheckParent(parent); err != nil {
+				return &invalidLocationError{message: err.Error()}
+			}
+		}
+
+		// the path needs the id so it is only known once the location is created
+		location.Path = "/"
+		if err := tx.Create(location).Error; err != nil {
+			return err
+		}
+
+		location.SetPath(parent)
+
+		return tx.Model(location).Update("path", location.Path).Error
+	})
+
+	if err != nil {
+		var errInvalid *invalidLocationError
+		if errors.As(err, &errInvalid) {
+			return nil, error_utils.NewBadRequestError(err.Error())
+		}
+		return nil, error_formats.ParseError(err)
+	}
+
+	return location, nil
+}
+
+// Update renames the location, it can't be moved to another parent.
+func (locationRepo *locationRepo) Update(location *models.Location) (*models.Location, error_utils.MessageErr) {
+	result := locationRepo.db.Model(location).Select("name").Updates(location)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return location, nil
+}
+
+// Delete removes a location nothing points to anymore, the managers scoped to a deleted site lose that scope.
+func (locationRepo *locationRepo) Delete(locationID uint64) error_utils.MessageErr {
+	err := locationRepo.db.Transaction(func(tx *gorm.DB) error {
+		var children, tasks, assets int64
+		if err := tx.Model(&models.Location{}).Where("parent_id = ?", locationID).Count(&children).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&models.Task{}).Where("location_id = ?", locationID).Count(&tasks).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Asset{}).Where("location_id = ?", locationID).Count(&assets).Error; err != nil {
+			return err
+		}
+
+		if children+tasks+assets > 0 {
+			return &invalidLocationError{message: fmt.Sprintf(
+				"the location still has %d locations, %d tasks and %d assets, it can't be deleted", children, tasks, assets,
+			)}
+		}
+
+		if err := tx.Where("site_id = ?", locationID).Delete(&models.UserSite{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.Location{}, locationID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		var errInvalid *invalidLocationError
+		if errors.As(err, &errInvalid) {
+			return error_utils.NewConflictError(err.Error())
+		}
+		return error_formats.ParseError(err)
+	}
+
+	return nil
+}
+
+func (locationRepo *locationRepo) GetUserSites(userID uint64) ([]uint64, error_utils.MessageErr) {
+	siteIDs := []uint64{}
+	result := locationRepo.db.Model(&models.UserSite{}).Where("user_id = ?", userID).Order("site_id").Pluck("site_id", &siteIDs)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return siteIDs, nil
+}
+
+// IsInSites tells whether the location is one of the sites or under one of them, without sites every location is.
+func (locationRepo *locationRepo) IsInSites(locationID *uint64, siteIDs []uint64) (bool, error_utils.MessageErr) {
+	located, err := isInSites(locationRepo.db, locationID, siteIDs)
+
+	if err != nil {
+		return false, error_formats.ParseError(err)
+	}
+
+	return located, nil
+}
+
+// SetUserSites replaces the sites the user is scoped to, no site at all removes the scope.
+func (locationRepo *locationRepo) SetUserSites(userID uint64, siteIDs []uint64) error_utils.MessageErr {
+	err := locationRepo.db.Transaction(func(tx *gorm.DB) error {
+		if len(siteIDs) > 0 {
+			var sites int64
+			if err := tx.Model(&models.Location{}).Where("id IN ? AND kind = ?", siteIDs, models.LocationKindSite).Count(&sites).Error; err != nil {
+				return err
+			}
+
+			if sites != int64(len(siteIDs)) {
+				return errNotOnlySites
+			}
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserSite{}).Error; err != nil {
+			return err
+		}
+
+		if len(siteIDs) == 0 {
+			return nil
+		}
+
+		userSites := make([]models.UserSite, len(siteIDs))
+		for i, siteID := range siteIDs {
+			userSites[i] = models.UserSite{UserID: userID, SiteID: siteID}
+		}
+
+		return tx.Create(&userSites).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, errNotOnlySites) {
+			return error_utils.NewBadRequestError(err.Error())
+		}
+		return error_formats.ParseError(err)
+	}
+
+	return nil
+}
+
+// locationSubtree selects the ids of the given locations and of every location under them.
+func locationSubtree(db *gorm.DB, rootIDs []uint64) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Table("locations AS subtree").
+		Select("subtree.id").
+		Joins("JOIN locations AS root ON subtree.path LIKE CONCAT(root.path, '%')").
+		Where("root.id IN ?", rootIDs)
+}
+
+// inSites keeps the tasks located in the sites or under them, without sites every task is kept.
+func inSites(db *gorm.DB, siteIDs []uint64) *gorm.DB {
+	if len(siteIDs) == 0 {
+		return db
+	}
+
+	return db.Where("location_id IN (?)", locationSubtree(db, siteIDs))
+}
+
+// isInSites tells whether the location is one of the sites or under one of them, a task without location is in no site.
+func isInSites(db *gorm.DB, locationID *uint64, siteIDs []uint64) (bool, error) {
+	if len(siteIDs) == 0 {
+		return true, nil
+	}
+
+	if locationID == nil {
+		return false, nil
+	}
+
+	var located int64
+	err := db.Session(&gorm.Session{NewDB: true}).Model(&models.Location{}).
+		Where("id = ? AND id IN (?)", *locationID, locationSubtree(db, siteIDs)).
+		Count(&located).Error
+
+	return located > 0, err
+}
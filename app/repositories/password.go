@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var PasswordRepo PasswordRepoInterface = &passwordRepo{}
+
+var (
+	errPasswordResetUsed    = errors.New("the reset token was already used")
+	errPasswordResetExpired = errors.New("the reset token expired")
+	errPasswordResetTooSoon = errors.New("a reset was already sent recently")
+)
+
+type PasswordRepoInterface interface {
+	CreateReset(reset *models.PasswordReset, recentSince time.Time, deliver func(reset *models.PasswordReset) error) error_utils.MessageErr
+	Reset(tokenHash string, hashedPassword string, now time.Time) (*models.User, error_utils.MessageErr)
+	Init()
+}
+
+type passwordRepo struct {
+	db *gorm.DB
+}
+
+func (passwordRepo *passwordRepo) Init() {
+	passwordRepo.db = database.Database
+}
+
+func NewPasswordRepository(db *gorm.DB) PasswordRepoInterface {
+	return &passwordRepo{db: db}
+}
+
+// CreateReset replaces the pending resets of the user and delivers the new one, a reset that couldn't be sent is removed.
+// Nothing is sent while a pending reset was created after recentSince, so the link the user is about to follow keeps working.
+// The email is sent once the reset is committed so the transaction doesn't wait on the mail server.
+func (passwordRepo *passwordRepo) CreateReset(reset *models.PasswordReset, recentSince time.Time, deliver func(reset *models.PasswordReset) error) error_utils.MessageErr {
+	err := passwordRepo.db.Transaction(func(tx *gorm.DB) error {
+		// the user is locked so the requests made at the same time are checked one after the other
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, reset.UserID).Error; err != nil {
+			return err
+		}
+
+		var recent int64
+		if err := tx.Model(&models.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL AND created_at > ?", reset.UserID, recentSince).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+
+		if recent > 0 {
+			return errPasswordResetTooSoon
+		}
+
+		// only the last token sent works
+		if err := tx.Model(&models.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL", reset.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(reset).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, errPasswordResetTooSoon) {
+			return error_utils.NewConflictError(err.Error())
+		}
+
+		return error_formats.ParseError(err)
+	}
+
+	if errDeliver := deliver(reset); errDeliver != nil {
+		if err := passwordRepo.db.Delete(reset).Error; err != nil {
+			log.Println("it's not possible to remove the password reset", reset.ID, "that couldn't be sent", err)
+		}
+
+		return error_utils.NewInternalServerError(fmt.Sprintf("it's not possible to send the reset email: %s", errDeliver.Error()))
+	}
+
+	return nil
+}
+
+// Reset sets the password of the user of the token, the token only works once and before it expires.
+func (passwordRepo *passwordRepo) Reset(tokenHash string, hashedPassword string, now time.Time) (*models.User, error_utils.MessageErr) {
+	user := &models.User{}
+
+	err := passwordRepo.db.Transaction(func(tx *gorm.DB) error {
+		reset := &models.PasswordReset{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(reset).Error; err != nil {
+			return err
+		}
+
+		if reset.UsedAt != nil {
+			return errPasswordResetUsed
+		}
+
+		if !reset.ExpiresAt.After(now) {
+			return errPasswordResetExpired
+		}
+
+		if err := tx.First(user, reset.UserID).Error; err != nil {
+			return err
+		}
+
+		// the tokens and the refresh tokens issued before the change are refused from now on
+		user.SetPassword(hashedPassword, now)
+		if err := tx.Model(user).Select("password", "password_changed_at").Updates(user).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(reset).Update("used_at", now).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, errPasswordResetUsed) || errors.Is(err, errPasswordResetExpired) {
+			return nil, error_utils.NewConflictError(err.Error())
+		}
+
+		return nil, error_formats.ParseError(err)
+	}
+
+	return user, nil
+}
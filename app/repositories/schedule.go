@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ScheduleRepo ScheduleRepoInterface = &scheduleRepo{}
+
+type ScheduleRepoInterface interface {
+	Get(scheduleID uint64) (*models.Schedule, error_utils.MessageErr)
+	GetAll() ([]models.Schedule, error_utils.MessageErr)
+	Create(*models.Schedule) (*models.Schedule, error_utils.MessageErr)
+	Update(*models.Schedule) (*models.Schedule, error_utils.MessageErr)
+	Delete(scheduleID uint64) error_utils.MessageErr
+	Materialize(now time.Time, leadTime time.Duration) ([]models.Task, error_utils.MessageErr)
+	Init()
+}
+
+type scheduleRepo struct {
+	db *gorm.DB
+}
+
+func (scheduleRepo *scheduleRepo) Init() {
+	scheduleRepo.db = database.Database
+}
+
+func NewScheduleRepository(db *gorm.DB) ScheduleRepoInterface {
+	return &scheduleRepo{db: db}
+}
+
+func (scheduleRepo *scheduleRepo) Get(scheduleID uint64) (*models.Schedule, error_utils.MessageErr) {
+	schedule := &models.Schedule{}
+	result := scheduleRepo.db.First(schedule, scheduleID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return schedule, nil
+}
+
+func (scheduleRepo *scheduleRepo) GetAll() ([]models.Schedule, error_utils.MessageErr) {
+	schedules := []models.Schedule{}
+	result := scheduleRepo.db.Order("id").Find(&schedules)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return schedules, nil
+}
+
+func (scheduleRepo *scheduleRepo) Create(schedule *models.Schedule) (*models.Schedule, error_utils.MessageErr) {
+	result := scheduleRepo.db.Create(schedule)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return schedule, nil
+}
+
+func (scheduleRepo *scheduleRepo) Update(schedule *models.Schedule) (*models.Schedule, error_utils.MessageErr) {
+	result := scheduleRepo.db.Model(schedule).
+		Select("summary", "recurrence", "timezone", "assignee_id", "priority", "active", "next_run_at").
+		Updates(schedule)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return schedule, nil
+}
+
+func (scheduleRepo *scheduleRepo) Delete(scheduleID uint64) error_utils.MessageErr {
+	result := scheduleRepo.db.Delete(&models.Schedule{}, scheduleID)
+
+	if result.Error != nil {
+		return error_formats.ParseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	return nil
+}
+
+// Materialize creates the tasks of the occurrences due within the lead time and returns them. Schedules locked by
+// another replica are skipped and a task is never created twice for the same occurrence, so it is safe to run anywhere.
+func (scheduleRepo *scheduleRepo) Materialize(now time.Time, leadTime time.Duration) ([]models.Task, error_utils.MessageErr) {
+	created := []models.Task{}
+	horizon := now.Add(leadTime)
+
+	err := scheduleRepo.db.Transaction(func(tx *gorm.DB) error {
+		var schedules []models.Schedule
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("active = ? AND next_run_at <= ?", true, horizon).
+			Find(&schedules).Error; err != nil {
+			return err
+		}
+
+		for i := range schedules {
+			schedule := &schedules[i]
+			occurrence := schedule.NextRunAt
+
+			// the occurrences missed while the scheduler wasn't running are skipped
+			for occurrence.Before(now) {
+				next, err := schedule.NextOccurrence(occurrence)
+				if err != nil {
+					return err
+				}
+				occurrence = next
+			}
+
+			for !occurrence.After(horizon) {
+				task := schedule.NewTask(occurrence)
+
+				result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(task)
+				if result.Error != nil {
+					return result.Error
+				}
+
+				if result.RowsAffected > 0 {
+					// the task is created by the scheduler so the event has no actor
+					if err := recordTaskEvent(tx, task.ID, 0, models.TaskEventCreated, models.DiffTasks(nil, task)); err != nil {
+						return err
+					}
+					created = append(created, *task)
+				}
+
+				next, err := schedule.NextOccurrence(occurrence)
+				if err != nil {
+					return err
+				}
+				occurrence = next
+			}
+
+			if err := tx.Model(schedule).UpdateColumn("next_run_at", occurrence).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+
+	return created, nil
+}
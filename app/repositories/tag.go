@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var TagRepo TagRepoInterface = &tagRepo{}
+
+var errUnknownTag = errors.New("some of the tags don't exist")
+
+type TagRepoInterface interface {
+	Get(tagID uint64) (*models.Tag, error_utils.MessageErr)
+	GetAll() ([]models.Tag, error_utils.MessageErr)
+	Create(*models.Tag) (*models.Tag, error_utils.MessageErr)
+	Update(*models.Tag) (*models.Tag, error_utils.MessageErr)
+	Delete(tagID uint64) error_utils.MessageErr
+	Counts(userID uint64, siteIDs []uint64) ([]models.TagCount, error_utils.MessageErr)
+	GetByTaskIDs(taskIDs []uint64) (map[uint64][]models.Tag, error_utils.MessageErr)
+	SetTaskTags(taskID uint64, tagIDs []uint64) ([]models.Tag, error_utils.MessageErr)
+	Init()
+}
+
+type tagRepo struct {
+	db *gorm.DB
+}
+
+func (tagRepo *tagRepo) Init() {
+	tagRepo.db = database.Database
+}
+
+func NewTagRepository(db *gorm.DB) TagRepoInterface {
+	return &tagRepo{db: db}
+}
+
+func (tagRepo *tagRepo) Get(tagID uint64) (*models.Tag, error_utils.MessageErr) {
+	tag := &models.Tag{}
+	result := tagRepo.db.First(tag, tagID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return tag, nil
+}
+
+func (tagRepo *tagRepo) GetAll() ([]models.Tag, error_utils.MessageErr) {
+	tags := []models.Tag{}
+	result := tagRepo.db.Order("name").Find(&tags)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return tags, nil
+}
+
+func (tagRepo *tagRepo) Create(tag *models.Tag) (*models.Tag, error_utils.MessageErr) {
+	result := tagRepo.db.Create(tag)
+
+	if result.Error != nil {
+		return nil, parseTagError(result.Error, tag)
+	}
+
+	return tag, nil
+}
+
+func (tagRepo *tagRepo) Update(tag *models.Tag) (*models.Tag, error_utils.MessageErr) {
+	result := tagRepo.db.Model(tag).Select("name").Updates(tag)
+
+	if result.Error != nil {
+		return nil, parseTagError(result.Error, tag)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	return tag, nil
+}
+
+// Delete removes the tag from every task before removing the tag itself.
+func (tagRepo *tagRepo) Delete(tagID uint64) error_utils.MessageErr {
+	err := tagRepo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tagID).Delete(&models.TaskTag{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.Tag{}, tagID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return error_formats.ParseError(err)
+	}
+
+	return nil
+}
+
+// Counts returns every tag with the number of tasks using it, only the tasks of the user are counted when one is given.
+func (tagRepo *tagRepo) Counts(userID uint64, siteIDs []uint64) ([]models.TagCount, error_utils.MessageErr) {
+	tasks := tagRepo.db.Model(&models.Task{}).Select("id")
+	if userID != 0 {
+		tasks = tasks.Where("assignee_id = ? OR (assignee_id IS NULL AND user_id = ?)", userID, userID)
+	}
+	tasks = inSites(tasks, siteIDs)
+
+	counts := []models.TagCount{}
+	result := tagRepo.db.Model(&models.Tag{}).
+		Select("tags.*, COUNT(task_tags.task_id) AS tasks").
+		Joins("LEFT JOIN task_tags ON task_tags.tag_id = tags.id AND task_tags.task_id IN (?)", tasks).
+		Group("tags.id").
+		Order("tags.name").
+		Scan(&counts)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return counts, nil
+}
+
+func (tagRepo *tagRepo) GetByTaskIDs(taskIDs []uint64) (map[uint64][]models.Tag, error_utils.MessageErr) {
+	tagsByTask := make(map[uint64][]models.Tag)
+	if len(taskIDs) == 0 {
+		return tagsByTask, nil
+	}
+
+	var rows []struct {
+		models.Tag
+		TaskID uint64
+	}
+
+	result := tagRepo.db.Model(&models.Tag{}).
+		Select("tags.*, task_tags.task_id").
+		Joins("JOIN task_tags ON task_tags.tag_id = tags.id").
+		Where("task_tags.task_id IN ?", taskIDs).
+		Order("tags.name").
+		Scan(&rows)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	for _, row := range rows {
+		tagsByTask[row.TaskID] = append(tagsByTask[row.TaskID], row.Tag)
+	}
+
+	return tagsByTask, nil
+}
+
+// SetTaskTags replaces the tags of the task and returns the new ones.
+func (tagRepo *tagRepo) SetTaskTags(taskID uint64, tagIDs []uint64) ([]models.Tag, error_utils.MessageErr) {
+	tags := []models.Tag{}
+
+	err := tagRepo.db.Transaction(func(tx *gorm.DB) error {
+		if len(tagIDs) > 0 {
+			if err := tx.Where("id IN ?", tagIDs).Order("name").Find(&tags).Error; err != nil {
+				return err
+			}
+
+			if len(tags) != len(tagIDs) {
+				return errUnknownTag
+			}
+		}
+
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskTag{}).Error; err != nil {
+			return err
+		}
+
+		if len(tags) == 0 {
+			return nil
+		}
+
+		taskTags := make([]models.TaskTag, len(tags))
+		for i, tag := range tags {
+			taskTags[i] = models.TaskTag{TaskID: taskID, TagID: tag.ID}
+		}
+
+		return tx.Create(&taskTags).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, errUnknownTag) {
+			return nil, error_utils.NewBadRequestError(err.Error())
+		}
+		return nil, error_formats.ParseError(err)
+	}
+
+	return tags, nil
+}
+
+// checkTagsExist makes sure every one of the tags exists, it must run inside a transaction.
+func checkTagsExist(tx *gorm.DB, tagIDs []uint64) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	var tags int64
+	if err := tx.Model(&models.Tag{}).Where("id IN ?", tagIDs).Count(&tags).Error; err != nil {
+		return err
+	}
+
+	if tags != int64(len(tagIDs)) {
+		return errUnknownTag
+	}
+
+	return nil
+}
+
+// parseTagError turns a duplicated name into a 409, every other error is parsed as usual.
+func parseTagError(err error, tag *models.Tag) error_utils.MessageErr {
+	if error_formats.IsDuplicateEntry(err) {
+		return error_utils.NewConflictError(fmt.Sprintf("the tag %s already exists", tag.Name))
+	}
+
+	return error_formats.ParseError(err)
+}
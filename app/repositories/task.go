@@ -3,22 +3,43 @@ package repositories
 import (
 	"api/app/database"
 	"api/app/models"
+	"api/app/storage"
 	"api/app/utils/error_formats"
 	"api/app/utils/error_utils"
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var TaskRepo TaskRepoInterface = &taskRepo{}
 
+var (
+	errStaleTask      = errors.New("the task was changed by someone else, get it again before changing it")
+	errTaskOutOfSites = errors.New("the task isn't located in your sites")
+)
+
 type TaskRepoInterface interface {
 	Get(uint64) (*models.Task, error_utils.MessageErr)
 	Create(*models.Task) (*models.Task, error_utils.MessageErr)
-	Update(*models.Task) (*models.Task, error_utils.MessageErr)
-	GetAll() []models.Task
-	GetAllByUserID(userID uint64) []models.Task
-	Delete(uint64) error_utils.MessageErr
+	Update(task *models.Task, actorID uint64) (*models.Task, error_utils.MessageErr)
+	List(query models.TaskQuery) (*models.TaskPage, error_utils.MessageErr)
+	Search(query models.TaskSearchQuery) ([]models.TaskSearchResult, error_utils.MessageErr)
+	UpdateStatus(task *models.Task, actorID uint64) (*models.Task, error_utils.MessageErr)
+	Assign(task *models.Task, assigneeID uint64, assignedBy uint64) (*models.Task, error_utils.MessageErr)
+	Delete(task *models.Task, actorID uint64) error_utils.MessageErr
+	History(taskID uint64, siteIDs []uint64) ([]models.TaskEvent, error_utils.MessageErr)
+	Restore(taskID uint64, actorID uint64, siteIDs []uint64) (*models.Task, error_utils.MessageErr)
+	PurgeTrash(deletedBefore time.Time) (int64, error_utils.MessageErr)
+	MarkOverdue(now time.Time) ([]models.Task, error_utils.MessageErr)
+	SLABreaches(now time.Time, siteIDs []uint64) ([]models.SLABreachCount, error_utils.MessageErr)
+	Bulk(request models.TaskBulkRequest, actorID uint64, ownerID uint64, siteIDs []uint64) (*models.TaskBulkResponse, error_utils.MessageErr)
+	Export(query models.TaskQuery, write func(row *models.TaskExportRow) error) error_utils.MessageErr
 	Init()
 }
 
@@ -35,10 +56,20 @@ func NewTaskRepository(db *gorm.DB) TaskRepoInterface {
 }
 
 func (taskRepo *taskRepo) Create(task *models.Task) (*models.Task, error_utils.MessageErr) {
-	result := taskRepo.db.Create(&task)
+	if task.Version == 0 {
+		task.Version = 1
+	}
 
-	if result.Error != nil {
-		return nil, error_formats.ParseError(result.Error)
+	err := taskRepo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&task).Error; err != nil {
+			return err
+		}
+
+		return recordTaskEvent(tx, task.ID, task.UserID, models.TaskEventCreated, models.DiffTasks(nil, task))
+	})
+
+	if err != nil {
+		return nil, error_formats.ParseError(err)
 	}
 
 	return task, nil
@@ -55,39 +86,523 @@ func (taskRepo *taskRepo) Get(taskId uint64) (*models.Task, error_utils.MessageE
 	return task, nil
 }
 
-func (taskRepo *taskRepo) Update(task *models.Task) (*models.Task, error_utils.MessageErr) {
-	result := taskRepo.db.Model(&task).Updates(models.Task{Summary: task.Summary})
+// Update replaces the editable fields of the task, a missing due date, asset or location clears it while a missing priority keeps the current one.
+func (taskRepo *taskRepo) Update(task *models.Task, actorID uint64) (*models.Task, error_utils.MessageErr) {
+	var updatedTask *models.Task
+
+	columns := []string{"summary", "due_at", "asset_id", "location_id"}
+	if len(task.Priority) > 0 {
+		columns = append(columns, "priority")
+	}
+
+	err := taskRepo.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		updatedTask, err = updateTask(tx, task.ID, task.Version, actorID, models.TaskEventUpdated, models.Task{
+			Summary:    task.Summary,
+			Priority:   task.Priority,
+			DueAt:      task.DueAt,
+			AssetID:    task.AssetID,
+			LocationID: task.LocationID,
+		}, columns...)
+		return err
+	})
+
+	if err != nil {
+		return nil, parseTaskError(err)
+	}
+
+	return updatedTask, nil
+}
+
+func (taskRepo *taskRepo) UpdateStatus(task *models.Task, actorID uint64) (*models.Task, error_utils.MessageErr) {
+	var updatedTask *models.Task
+
+	err := taskRepo.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		updatedTask, err = updateTask(tx, task.ID, task.Version, actorID, models.TaskEventStatusChanged, models.Task{
+			Status:      task.Status,
+			CompletedAt: task.CompletedAt,
+		}, "status", "completed_at")
+		return err
+	})
+
+	if err != nil {
+		return nil, parseTaskError(err)
+	}
+
+	return updatedTask, nil
+}
+
+func (taskRepo *taskRepo) Assign(task *models.Task, assigneeID uint64, assignedBy uint64) (*models.Task, error_utils.MessageErr) {
+	var updatedTask *models.Task
+	assignment := models.TaskAssignment{
+		TaskID:             task.ID,
+		AssigneeID:         assigneeID,
+		PreviousAssigneeID: task.AssigneeID,
+		AssignedBy:         assignedBy,
+	}
+
+	err := taskRepo.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		updatedTask, err = updateTask(tx, task.ID, task.Version, assignedBy, models.TaskEventAssigned, models.Task{AssigneeID: &assigneeID})
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&assignment).Error
+	})
+
+	if err != nil {
+		return nil, parseTaskError(err)
+	}
+
+	return updatedTask, nil
+}
+
+// History lists the changes of the task even once deleted, with sites the task has to be located in them.
+func (taskRepo *taskRepo) History(taskID uint64, siteIDs []uint64) ([]models.TaskEvent, error_utils.MessageErr) {
+	if len(siteIDs) > 0 {
+		task := &models.Task{}
+		if err := taskRepo.db.Unscoped().Select("id", "location_id").First(task, taskID).Error; err != nil {
+			return nil, error_formats.ParseError(err)
+		}
+
+		located, err := isInSites(taskRepo.db, task.LocationID, siteIDs)
+		if err != nil {
+			return nil, error_formats.ParseError(err)
+		}
+
+		if !located {
+			return nil, parseTaskError(errTaskOutOfSites)
+		}
+	}
+
+	events := []models.TaskEvent{}
+	result := taskRepo.db.Where(&models.TaskEvent{TaskID: taskID}).Order("created_at, id").Find(&events)
 
 	if result.Error != nil {
 		return nil, error_formats.ParseError(result.Error)
 	}
 
+	return events, nil
+}
+
+// Restore takes the task out of the trash, with sites the task has to be located in them.
+func (taskRepo *taskRepo) Restore(taskID uint64, actorID uint64, siteIDs []uint64) (*models.Task, error_utils.MessageErr) {
+	task := &models.Task{}
+
+	err := taskRepo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(task, taskID).Error; err != nil {
+			return err
+		}
+
+		located, err := isInSites(tx, task.LocationID, siteIDs)
+		if err != nil {
+			return err
+		}
+
+		if !located {
+			return errTaskOutOfSites
+		}
+
+		if err := tx.Unscoped().Model(task).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		return recordTaskEvent(tx, taskID, actorID, models.TaskEventRestored, models.DiffTasks(nil, task))
+	})
+
+	if err != nil {
+		return nil, parseTaskError(err)
+	}
+
+	task.DeletedAt = gorm.DeletedAt{}
+
 	return task, nil
 }
 
-func (taskRepo *taskRepo) GetAll() []models.Task {
-	var tasks []models.Task
-	taskRepo.db.Find(&tasks)
+func (taskRepo *taskRepo) PurgeTrash(deletedBefore time.Time) (int64, error_utils.MessageErr) {
+	var purged int64
+	var storageKeys []string
 
-	return tasks
+	err := taskRepo.db.Transaction(func(tx *gorm.DB) error {
+		var taskIDs []uint64
+		if err := tx.Unscoped().Model(&models.Task{}).Where("deleted_at < ?", deletedBefore).Pluck("id", &taskIDs).Error; err != nil {
+			return err
+		}
+
+		if len(taskIDs) == 0 {
+			return nil
+		}
+
+		result := tx.Unscoped().Delete(&models.Task{}, taskIDs)
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+
+		if err := tx.Where("task_id IN ?", taskIDs).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("task_id IN ?", taskIDs).Delete(&models.ChecklistItem{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("task_id IN ? OR blocked_by_id IN ?", taskIDs, taskIDs).Delete(&models.TaskDependency{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("task_id IN ?", taskIDs).Delete(&models.WorkLog{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("task_id IN ?", taskIDs).Delete(&models.TaskTag{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Attachment{}).Where("task_id IN ?", taskIDs).Pluck("storage_key", &storageKeys).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("task_id IN ?", taskIDs).Delete(&models.Attachment{}).Error; err != nil {
+			return err
+		}
+
+		// the purge is done by the system so the events have no actor
+		events := make([]models.TaskEvent, len(taskIDs))
+		for i, taskID := range taskIDs {
+			events[i] = models.TaskEvent{TaskID: taskID, Action: models.TaskEventPurged, Changes: models.TaskChanges{}}
+		}
+
+		return tx.Create(&events).Error
+	})
+
+	if err != nil {
+		return 0, error_formats.ParseError(err)
+	}
+
+	// the files are only removed once the rows are gone, a file left behind is logged rather than failing the purge
+	for _, storageKey := range storageKeys {
+		if err := storage.Files.Delete(context.Background(), storageKey); err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+			log.Println("it's not possible to remove the file", storageKey, "of a purged task", err)
+		}
+	}
+
+	return purged, nil
 }
 
-func (taskRepo *taskRepo) GetAllByUserID(userID uint64) []models.Task {
-	var tasks []models.Task
-	taskRepo.db.Where(&models.Task{UserID: userID}).Find(&tasks)
+// MarkOverdue flags the pending tasks past their due date that weren't notified yet and returns them,
+// rows locked by another replica are skipped so each breach is only notified once.
+func (taskRepo *taskRepo) MarkOverdue(now time.Time) ([]models.Task, error_utils.MessageErr) {
+	tasks := []models.Task{}
+
+	err := taskRepo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("due_at < ? AND status NOT IN ?", now, []string{models.TaskStatusDone, models.TaskStatusCancelled}).
+			Where("overdue_notified_at IS NULL OR overdue_notified_at < due_at").
+			Find(&tasks)
+		if result.Error != nil || len(tasks) == 0 {
+			return result.Error
+		}
+
+		taskIDs := make([]uint64, len(tasks))
+		for i, task := range tasks {
+			taskIDs[i] = task.ID
+		}
+
+		return tx.Model(&models.Task{}).Where("id IN ?", taskIDs).UpdateColumn("overdue_notified_at", now).Error
+	})
+
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+
+	return tasks, nil
+}
+
+// SLABreaches counts per technician the tasks still pending after their due date and the ones completed late,
+// with sites only the tasks located in them are counted.
+func (taskRepo *taskRepo) SLABreaches(now time.Time, siteIDs []uint64) ([]models.SLABreachCount, error_utils.MessageErr) {
+	counts := []models.SLABreachCount{}
+	closed := []string{models.TaskStatusDone, models.TaskStatusCancelled}
+
+	result := inSites(taskRepo.db.Model(&models.Task{}), siteIDs).
+		Select("COALESCE(assignee_id, user_id) AS technician_id, "+
+			"SUM(CASE WHEN status NOT IN ? AND due_at < ? THEN 1 ELSE 0 END) AS overdue, "+
+			"SUM(CASE WHEN completed_at > due_at THEN 1 ELSE 0 END) AS completed_late", closed, now).
+		Where("due_at IS NOT NULL").
+		Group("technician_id").
+		Having("overdue + completed_late > 0").
+		Order("technician_id").
+		Scan(&counts)
 
-	return tasks
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	for i := range counts {
+		counts[i].Breached = counts[i].Overdue + counts[i].CompletedLate
+	}
+
+	return counts, nil
 }
 
-func (taskRepo *taskRepo) Delete(taskId uint64) error_utils.MessageErr {
-	result := taskRepo.db.Delete(&models.Task{}, taskId)
+// updateTask applies the non zero fields of the changes to the task, or the given columns even when zero, and records the resulting diff,
+// it must run inside a transaction. The update only succeeds while the task is still at the expected version, zero expects the version that was just read.
+// A new status must follow the transition graph from the stored one, every status change goes through here.
+func updateTask(tx *gorm.DB, taskID uint64, expectedVersion uint64, actorID uint64, action string, changes models.Task, columns ...string) (*models.Task, error) {
+	var before models.Task
+	if err := tx.First(&before, taskID).Error; err != nil {
+		return nil, err
+	}
+
+	if expectedVersion == 0 {
+		expectedVersion = before.Version
+	}
+
+	if before.Version != expectedVersion {
+		return nil, errStaleTask
+	}
+
+	if changesStatus(changes, columns) {
+		if err := models.CheckStatusTransition(before.Status, changes.Status); err != nil {
+			return nil, err
+		}
+	}
+
+	after := before
+	changes.Version = before.Version + 1
+
+	db := tx.Model(&after).Where("version = ?", expectedVersion)
+	if len(columns) > 0 {
+		db = db.Select(append(columns, "version", "updated_at"))
+	}
+
+	result := db.Updates(changes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, errStaleTask
+	}
+
+	diff := models.DiffTasks(&before, &after)
+	delete(diff, "version")
+	if len(diff) == 0 {
+		return &after, nil
+	}
+
+	return &after, recordTaskEvent(tx, taskID, actorID, action, diff)
+}
+
+// changesStatus reports whether updateTask writes the status, the zero value is only written when the column is selected.
+func changesStatus(changes models.Task, columns []string) bool {
+	if len(columns) == 0 {
+		return len(changes.Status) > 0
+	}
+
+	for _, column := range columns {
+		if column == "status" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTaskError turns a lost optimistic lock into a 412, a forbidden status change into a 409 and a task out of the sites into a 403,
+// every other error is parsed as usual.
+func parseTaskError(err error) error_utils.MessageErr {
+	if errors.Is(err, errStaleTask) {
+		return error_utils.NewPreconditionFailedError(err.Error())
+	}
+
+	if errors.Is(err, models.ErrInvalidStatusTransition) {
+		return error_utils.NewConflictError(err.Error())
+	}
+
+	if errors.Is(err, errTaskOutOfSites) {
+		return error_utils.NewForbiddenError(err.Error())
+	}
+
+	return error_formats.ParseError(err)
+}
+
+func recordTaskEvent(tx *gorm.DB, taskID uint64, actorID uint64, action string, changes models.TaskChanges) error {
+	event := models.TaskEvent{
+		TaskID:  taskID,
+		ActorID: actorID,
+		Action:  action,
+		Changes: changes,
+	}
+
+	return tx.Create(&event).Error
+}
+
+func (taskRepo *taskRepo) List(query models.TaskQuery) (*models.TaskPage, error_utils.MessageErr) {
+	page := &models.TaskPage{Data: []models.Task{}}
+
+	if result := filterTasks(taskRepo.db.Model(&models.Task{}), query).Count(&page.Total); result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	tasks := []models.Task{}
+	result := paginateTasks(filterTasks(taskRepo.db, query), query).Find(&tasks)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	if len(tasks) > query.Limit {
+		tasks = tasks[:query.Limit]
+		page.NextCursor = models.NewTaskCursor(tasks[len(tasks)-1]).Encode()
+	}
+
+	page.Data = tasks
+
+	return page, nil
+}
+
+func (taskRepo *taskRepo) Search(query models.TaskSearchQuery) ([]models.TaskSearchResult, error_utils.MessageErr) {
+	results := []models.TaskSearchResult{}
+	db := taskRepo.db.Model(&models.Task{}).
+		Select("tasks.*, MATCH(summary) AGAINST (? IN NATURAL LANGUAGE MODE) AS relevance", query.Text).
+		Where("MATCH(summary) AGAINST (? IN NATURAL LANGUAGE MODE)", query.Text)
+
+	if query.UserID != 0 {
+		db = db.Where("assignee_id = ? OR (assignee_id IS NULL AND user_id = ?)", query.UserID, query.UserID)
+	}
+
+	db = inSites(db, query.SiteIDs)
+
+	result := db.Order("relevance DESC").Limit(query.Limit).Scan(&results)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return results, nil
+}
+
+// filterTasks applies the TaskQuery filters, it is shared by the listing and the total count.
+func filterTasks(db *gorm.DB, query models.TaskQuery) *gorm.DB {
+	if query.Trashed {
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if query.UserID != 0 {
+		db = db.Where("assignee_id = ? OR (assignee_id IS NULL AND user_id = ?)", query.UserID, query.UserID)
+	}
+
+	if len(query.Status) > 0 {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+
+	if query.To != nil {
+		db = db.Where("created_at <= ?", *query.To)
+	}
+
+	if len(query.Text) > 0 {
+		db = db.Where("summary LIKE ?", "%"+escapeLike(query.Text)+"%")
+	}
+
+	if query.Overdue {
+		db = db.Where("due_at < ? AND status NOT IN ?", time.Now(), []string{models.TaskStatusDone, models.TaskStatusCancelled})
+	}
+
+	// with the all mode a task needs every tag, with any of them is enough
+	if len(query.TagIDs) > 0 {
+		tagged := db.Session(&gorm.Session{NewDB: true}).Model(&models.TaskTag{}).Select("task_id").Where("tag_id IN ?", query.TagIDs)
+		if query.TagMode == models.TagModeAll {
+			tagged = tagged.Group("task_id").Having("COUNT(DISTINCT tag_id) = ?", len(query.TagIDs))
+		}
+		db = db.Where("id IN (?)", tagged)
+	}
+
+	if query.LocationID != 0 {
+		db = db.Where("location_id IN (?)", locationSubtree(db, []uint64{query.LocationID}))
+	}
+
+	// a manager scoped to sites only sees the tasks located in them
+	db = inSites(db, query.SiteIDs)
+
+	return db
+}
+
+// paginateTasks applies the keyset condition, the ordering and the page size, one extra row is fetched to know if there is a next page.
+func paginateTasks(db *gorm.DB, query models.TaskQuery) *gorm.DB {
+	comparison := ">"
+	if query.IsDescending() {
+		comparison = "<"
+	}
+
+	if query.SortsByID() {
+		if query.Cursor != nil {
+			db = db.Where(fmt.Sprintf("id %s ?", comparison), query.Cursor.ID)
+		}
+
+		return orderTasks(db, query).Limit(query.Limit + 1)
+	}
+
+	if query.Cursor != nil {
+		db = db.Where(
+			fmt.Sprintf("created_at %s ? OR (created_at = ? AND id %s ?)", comparison, comparison),
+			query.Cursor.CreatedAt, query.Cursor.CreatedAt, query.Cursor.ID,
+		)
+	}
+
+	return orderTasks(db, query).Limit(query.Limit + 1)
+}
+
+// orderTasks sorts the tasks the way the query asks, the id breaks the ties between tasks created at the same time.
+func orderTasks(db *gorm.DB, query models.TaskQuery) *gorm.DB {
+	direction := "ASC"
+	if query.IsDescending() {
+		direction = "DESC"
+	}
+
+	if query.SortsByID() {
+		return db.Order(fmt.Sprintf("id %s", direction))
+	}
+
+	return db.Order(fmt.Sprintf("created_at %s, id %s", direction, direction))
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func (taskRepo *taskRepo) Delete(task *models.Task, actorID uint64) error_utils.MessageErr {
+	err := taskRepo.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Task
+		if err := tx.First(&before, task.ID).Error; err != nil {
+			return err
+		}
+
+		if task.Version != 0 && before.Version != task.Version {
+			return errStaleTask
+		}
+
+		result := tx.Where("version = ?", before.Version).Delete(&models.Task{}, task.ID)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	if result.Error != nil || result.RowsAffected == 0 {
 		if result.RowsAffected == 0 {
-			return error_formats.ParseError(errors.New("record not found"))
+			return errStaleTask
 		}
 
-		return error_formats.ParseError(result.Error)
+		return recordTaskEvent(tx, task.ID, actorID, models.TaskEventDeleted, models.DiffTasks(&before, nil))
+	})
+
+	if err != nil {
+		return parseTaskError(err)
 	}
 
 	return nil
@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errBulkAborted stops an all or nothing batch at its first failure so the whole transaction is rolled back.
+var errBulkAborted = errors.New("the bulk operations were rolled back")
+
+// bulkOperationError is the failure of a single operation, already in the shape its single task endpoint would answer.
+type bulkOperationError struct {
+	err error_utils.MessageErr
+}
+
+func (err *bulkOperationError) Error() string {
+	return err.err.Message()
+}
+
+// Bulk runs the operations in a single transaction, each one in its own savepoint so a best effort batch only loses the failed ones.
+// Without an owner any task can be changed, otherwise only the tasks assigned to the owner. With sites only the tasks located in them can be changed.
+func (taskRepo *taskRepo) Bulk(request models.TaskBulkRequest, actorID uint64, ownerID uint64, siteIDs []uint64) (*models.TaskBulkResponse, error_utils.MessageErr) {
+	response := &models.TaskBulkResponse{Mode: request.Mode, Results: make([]models.TaskBulkResult, len(request.Operations))}
+	for i, operation := range request.Operations {
+		response.Results[i] = models.TaskBulkResult{Index: i, TaskID: operation.TaskID, Action: operation.Action}
+	}
+	failedAt := -1
+
+	err := taskRepo.db.Transaction(func(tx *gorm.DB) error {
+		for i, operation := range request.Operations {
+			result := &response.Results[i]
+			errOperation := tx.Transaction(func(savepoint *gorm.DB) error {
+				return applyBulkOperation(savepoint, operation, actorID, ownerID, siteIDs, result)
+			})
+
+			if errOperation == nil {
+				result.Status = http.StatusOK
+				continue
+			}
+
+			errParsed := parseBulkError(errOperation)
+			if errParsed.Status() == http.StatusInternalServerError {
+				return errOperation
+			}
+
+			result.Status = errParsed.Status()
+			result.Error = errParsed.Message()
+			result.Task = nil
+
+			if request.Mode == models.TaskBulkModeAtomic {
+				failedAt = i
+				return errBulkAborted
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errBulkAborted) {
+		return nil, error_formats.ParseError(err)
+	}
+
+	response.Committed = err == nil
+	for i := range response.Results {
+		result := &response.Results[i]
+
+		if failedAt >= 0 && i != failedAt {
+			result.Status = http.StatusFailedDependency
+			result.Task = nil
+			result.PreviousAssigneeID = nil
+			if i < failedAt {
+				result.Error = fmt.Sprintf("rolled back as the operation %d failed", failedAt)
+			} else {
+				result.Error = fmt.Sprintf("not run as the operation %d failed", failedAt)
+			}
+		}
+
+		if result.Status == http.StatusOK {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	return response, nil
+}
+
+// applyBulkOperation runs one operation with the same rules as its single task endpoint, it must run inside a transaction.
+func applyBulkOperation(tx *gorm.DB, operation models.TaskBulkOperation, actorID uint64, ownerID uint64, siteIDs []uint64, result *models.TaskBulkResult) error {
+	var task models.Task
+	if err := tx.First(&task, operation.TaskID).Error; err != nil {
+		return err
+	}
+
+	if ownerID != 0 && !task.IsAssignedTo(ownerID) {
+		return &bulkOperationError{err: error_utils.NewForbiddenError(fmt.Sprintf("the task %d doesn't belong to you", task.ID))}
+	}
+
+	located, err := isInSites(tx, task.LocationID, siteIDs)
+	if err != nil {
+		return err
+	}
+
+	if !located {
+		return &bulkOperationError{err: error_utils.NewForbiddenError(fmt.Sprintf("the task %d isn't located in your sites", task.ID))}
+	}
+
+	switch operation.Action {
+	case models.TaskBulkActionDelete:
+		deleted := tx.Where("version = ?", task.Version).Delete(&models.Task{}, task.ID)
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+
+		if deleted.RowsAffected == 0 {
+			return errStaleTask
+		}
+
+		return recordTaskEvent(tx, task.ID, actorID, models.TaskEventDeleted, models.DiffTasks(&task, nil))
+
+	case models.TaskBulkActionUpdateStatus:
+		if err := task.TransitionTo(operation.Status); err != nil {
+			return &bulkOperationError{err: error_utils.NewConflictError(err.Error())}
+		}
+
+		if task.Status == models.TaskStatusDone {
+			var pending int64
+			if err := tx.Model(&models.ChecklistItem{}).
+				Where("task_id = ? AND mandatory = ? AND done = ?", task.ID, true, false).
+				Count(&pending).Error; err != nil {
+				return err
+			}
+
+			if pending > 0 {
+				return &bulkOperationError{err: error_utils.NewConflictError(fmt.Sprintf("the task still has %d mandatory checklist items unchecked", pending))}
+			}
+		}
+
+		updatedTask, err := updateTask(tx, task.ID, task.Version, actorID, models.TaskEventStatusChanged, models.Task{
+			Status:      task.Status,
+			CompletedAt: task.CompletedAt,
+		}, "status", "completed_at")
+		if err != nil {
+			return err
+		}
+
+		result.Task = updatedTask
+		return nil
+
+	case models.TaskBulkActionAssign:
+		var assignee models.User
+		if err := tx.First(&assignee, operation.AssigneeID).Error; err != nil {
+			return err
+		}
+
+		if assignee.Type != "Technician" {
+			return &bulkOperationError{err: error_utils.NewBadRequestError("a task can only be assigned to a Technician")}
+		}
+
+		previousAssignee := task.UserID
+		if task.AssigneeID != nil {
+			previousAssignee = *task.AssigneeID
+		}
+
+		updatedTask, err := updateTask(tx, task.ID, task.Version, actorID, models.TaskEventAssigned, models.Task{AssigneeID: &assignee.ID})
+		if err != nil {
+			return err
+		}
+
+		assignment := models.TaskAssignment{
+			TaskID:             task.ID,
+			AssigneeID:         assignee.ID,
+			PreviousAssigneeID: task.AssigneeID,
+			AssignedBy:         actorID,
+		}
+		if err := tx.Create(&assignment).Error; err != nil {
+			return err
+		}
+
+		result.Task = updatedTask
+		result.PreviousAssigneeID = &previousAssignee
+		return nil
+
+	case models.TaskBulkActionTag:
+		if err := checkTagsExist(tx, operation.TagIDs); err != nil {
+			return err
+		}
+
+		// the tags are added to the ones the task already has
+		taskTags := make([]models.TaskTag, len(operation.TagIDs))
+		for i, tagID := range operation.TagIDs {
+			taskTags[i] = models.TaskTag{TaskID: task.ID, TagID: tagID}
+		}
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&taskTags).Error; err != nil {
+			return err
+		}
+
+		result.Task = &task
+		return nil
+	}
+
+	return nil
+}
+
+// parseBulkError turns the failure of an operation into the error its single task endpoint would answer.
+func parseBulkError(err error) error_utils.MessageErr {
+	var errOperation *bulkOperationError
+	if errors.As(err, &errOperation) {
+		return errOperation.err
+	}
+
+	if errors.Is(err, errUnknownTag) {
+		return error_utils.NewBadRequestError(err.Error())
+	}
+
+	return parseTaskError(err)
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+)
+
+// the names come from subqueries instead of joins so the filters keep using the columns of the tasks unqualified
+const taskExportColumns = "tasks.id, tasks.summary, tasks.status, tasks.priority, tasks.user_id, " +
+	"(SELECT users.name FROM users WHERE users.id = tasks.user_id) AS user_name, tasks.assignee_id, " +
+	"(SELECT users.name FROM users WHERE users.id = tasks.assignee_id) AS assignee_name, " +
+	"tasks.asset_id, tasks.location_id, tasks.due_at, tasks.completed_at, tasks.created_at, tasks.updated_at"
+
+// Export reads the tasks matching the query one row at a time from the cursor and hands each one to write,
+// so an export never holds more than a row in memory. The first error returned by write stops the export.
+func (taskRepo *taskRepo) Export(query models.TaskQuery, write func(row *models.TaskExportRow) error) error_utils.MessageErr {
+	db := orderTasks(filterTasks(taskRepo.db.Model(&models.Task{}), query), query).Select(taskExportColumns)
+
+	rows, err := db.Rows()
+	if err != nil {
+		return error_formats.ParseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := &models.TaskExportRow{}
+		if err := taskRepo.db.ScanRows(rows, row); err != nil {
+			return error_formats.ParseError(err)
+		}
+
+		if err := write(row); err != nil {
+			return error_formats.ParseError(err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return error_formats.ParseError(err)
+	}
+
+	return nil
+}
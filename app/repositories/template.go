@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var TemplateRepo TemplateRepoInterface = &templateRepo{}
+
+type TemplateRepoInterface interface {
+	Get(templateID uint64) (*models.TaskTemplate, error_utils.MessageErr)
+	GetAll() ([]models.TaskTemplate, error_utils.MessageErr)
+	Create(template *models.TaskTemplate) (*models.TaskTemplate, error_utils.MessageErr)
+	Update(template *models.TaskTemplate, actorID uint64) (*models.TaskTemplate, error_utils.MessageErr)
+	Delete(templateID uint64) error_utils.MessageErr
+	Versions(templateID uint64) ([]models.TaskTemplateVersion, error_utils.MessageErr)
+	Instantiate(task *models.Task, tagIDs []uint64) (*models.Task, error_utils.MessageErr)
+	Init()
+}
+
+type templateRepo struct {
+	db *gorm.DB
+}
+
+func (templateRepo *templateRepo) Init() {
+	templateRepo.db = database.Database
+}
+
+func NewTemplateRepository(db *gorm.DB) TemplateRepoInterface {
+	return &templateRepo{db: db}
+}
+
+func (templateRepo *templateRepo) Get(templateID uint64) (*models.TaskTemplate, error_utils.MessageErr) {
+	template := &models.TaskTemplate{}
+	result := templateRepo.db.First(template, templateID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return template, nil
+}
+
+func (templateRepo *templateRepo) GetAll() ([]models.TaskTemplate, error_utils.MessageErr) {
+	templates := []models.TaskTemplate{}
+	result := templateRepo.db.Order("name").Find(&templates)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return templates, nil
+}
+
+// Create saves the template along with its first version.
+func (templateRepo *templateRepo) Create(template *models.TaskTemplate) (*models.TaskTemplate, error_utils.MessageErr) {
+	template.Version = 1
+
+	err := templateRepo.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkTagsExist(tx, template.TagIDs); err != nil {
+			return err
+		}
+
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(template.NewVersion(template.CreatedBy)).Error
+	})
+
+	if err != nil {
+		return nil, parseTemplateError(err)
+	}
+
+	return template, nil
+}
+
+// Update changes the content of the template as a new version, the tasks keep pointing to the version they came from.
+func (templateRepo *templateRepo) Update(template *models.TaskTemplate, actorID uint64) (*models.TaskTemplate, error_utils.MessageErr) {
+	updatedTemplate := &models.TaskTemplate{}
+
+	err := templateRepo.db.Transaction(func(tx *gorm.DB) error {
+		// the row is locked so two changes at the same time can't get the same version
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(updatedTemplate, template.ID).Error; err != nil {
+			return err
+		}
+
+		if err := checkTagsExist(tx, template.TagIDs); err != nil {
+			return err
+		}
+
+		updatedTemplate.Name = template.Name
+		updatedTemplate.TaskTemplateContent = template.TaskTemplateContent
+		updatedTemplate.Version++
+
+		if err := tx.Model(updatedTemplate).Select("name", "version", "summary", "priority", "checklist", "tag_ids").
+			Updates(updatedTemplate).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(updatedTemplate.NewVersion(actorID)).Error
+	})
+
+	if err != nil {
+		return nil, parseTemplateError(err)
+	}
+
+	return updatedTemplate, nil
+}
+
+// Delete hides the template, its versions are kept for the tasks created from it.
+func (templateRepo *templateRepo) Delete(templateID uint64) error_utils.MessageErr {
+	result := templateRepo.db.Delete(&models.TaskTemplate{}, templateID)
+
+	if result.Error != nil {
+		return error_formats.ParseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return error_formats.ParseError(gorm.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+func (templateRepo *templateRepo) Versions(templateID uint64) ([]models.TaskTemplateVersion, error_utils.MessageErr) {
+	versions := []models.TaskTemplateVersion{}
+	result := templateRepo.db.Where("template_id = ?", templateID).Order("version DESC").Find(&versions)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return versions, nil
+}
+
+// Instantiate creates the task with its checklist and its tags, the tags deleted since the template was written are skipped.
+func (templateRepo *templateRepo) Instantiate(task *models.Task, tagIDs []uint64) (*models.Task, error_utils.MessageErr) {
+	if task.Version == 0 {
+		task.Version = 1
+	}
+
+	err := templateRepo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(task).Error; err != nil {
+			return err
+		}
+
+		if err := recordTaskEvent(tx, task.ID, task.UserID, models.TaskEventCreated, models.DiffTasks(nil, task)); err != nil {
+			return err
+		}
+
+		if len(task.Checklist) > 0 {
+			for i := range task.Checklist {
+				task.Checklist[i].TaskID = task.ID
+			}
+
+			if err := tx.Create(&task.Checklist).Error; err != nil {
+				return err
+			}
+		}
+
+		task.Tags = []models.Tag{}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("id IN ?", tagIDs).Order("name").Find(&task.Tags).Error; err != nil {
+			return err
+		}
+
+		if len(task.Tags) == 0 {
+			return nil
+		}
+
+		taskTags := make([]models.TaskTag, len(task.Tags))
+		for i, tag := range task.Tags {
+			taskTags[i] = models.TaskTag{TaskID: task.ID, TagID: tag.ID}
+		}
+
+		return tx.Create(&taskTags).Error
+	})
+
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+
+	return task, nil
+}
+
+// parseTemplateError turns an unknown default tag into a 400, every other error is parsed as usual.
+func parseTemplateError(err error) error_utils.MessageErr {
+	if errors.Is(err, errUnknownTag) {
+		return error_utils.NewBadRequestError(err.Error())
+	}
+
+	return error_formats.ParseError(err)
+}
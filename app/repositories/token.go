@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var TokenRepo TokenRepoInterface = &tokenRepo{}
+
+var (
+	errRefreshTokenReused   = errors.New("the refresh token was already used, the session was revoked")
+	errRefreshTokenRevoked  = errors.New("the refresh token was revoked")
+	errRefreshTokenExpired  = errors.New("the refresh token expired")
+	errRefreshTokenUser     = errors.New("the user can't sign in anymore")
+	errRefreshTokenPassword = errors.New("the password changed, sign in again")
+)
+
+type TokenRepoInterface interface {
+	Create(refreshToken *models.RefreshToken) (*models.RefreshToken, error_utils.MessageErr)
+	Rotate(tokenHash string, now time.Time, accessTTL time.Duration, issue func(user *models.User, current *models.RefreshToken) (*models.RefreshToken, error)) error_utils.MessageErr
+	RevokeSession(familyID string, now time.Time, accessTTL time.Duration) error_utils.MessageErr
+	Revoke(tokenID string, expiresAt time.Time) error_utils.MessageErr
+	IsRevoked(tokenID string) (bool, error_utils.MessageErr)
+	Purge(now time.Time) (int64, error_utils.MessageErr)
+	Init()
+}
+
+type tokenRepo struct {
+	db *gorm.DB
+}
+
+func (tokenRepo *tokenRepo) Init() {
+	tokenRepo.db = database.Database
+}
+
+func NewTokenRepository(db *gorm.DB) TokenRepoInterface {
+	return &tokenRepo{db: db}
+}
+
+func (tokenRepo *tokenRepo) Create(refreshToken *models.RefreshToken) (*models.RefreshToken, error_utils.MessageErr) {
+	result := tokenRepo.db.Create(refreshToken)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return refreshToken, nil
+}
+
+// Rotate marks the refresh token as used and stores the one issue returns in its place.
+// A token that was already used means it leaked, the whole session is revoked.
+func (tokenRepo *tokenRepo) Rotate(tokenHash string, now time.Time, accessTTL time.Duration, issue func(user *models.User, current *models.RefreshToken) (*models.RefreshToken, error)) error_utils.MessageErr {
+	var errIssue error
+	reused := false
+
+	err := tokenRepo.db.Transaction(func(tx *gorm.DB) error {
+		current := &models.RefreshToken{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(current).Error; err != nil {
+			return err
+		}
+
+		if current.RevokedAt != nil {
+			return errRefreshTokenRevoked
+		}
+
+		// the revocation has to be committed, the error is returned once the transaction is done
+		if current.UsedAt != nil {
+			reused = true
+			return revokeFamily(tx, current.FamilyID, now, accessTTL)
+		}
+
+		if !current.ExpiresAt.After(now) {
+			return errRefreshTokenExpired
+		}
+
+		user := &models.User{}
+		if err := tx.Unscoped().First(user, current.UserID).Error; err != nil {
+			return err
+		}
+
+		if user.DeletedAt.Valid || !user.IsActive() {
+			return errRefreshTokenUser
+		}
+
+		if user.IssuedBeforePasswordChange(current.CreatedAt) {
+			return errRefreshTokenPassword
+		}
+
+		if err := tx.Model(current).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		next, err := issue(user, current)
+		if err != nil {
+			errIssue = err
+			return err
+		}
+
+		return tx.Create(next).Error
+	})
+
+	if reused && err == nil {
+		err = errRefreshTokenReused
+	}
+
+	if err != nil {
+		if errIssue != nil {
+			return error_utils.NewInternalServerError(errIssue.Error())
+		}
+
+		return parseTokenError(err)
+	}
+
+	return nil
+}
+
+// RevokeSession revokes the refresh tokens of the session and the access tokens they issued.
+func (tokenRepo *tokenRepo) RevokeSession(familyID string, now time.Time, accessTTL time.Duration) error_utils.MessageErr {
+	err := tokenRepo.db.Transaction(func(tx *gorm.DB) error {
+		return revokeFamily(tx, familyID, now, accessTTL)
+	})
+
+	if err != nil {
+		return error_formats.ParseError(err)
+	}
+
+	return nil
+}
+
+// Revoke adds the access token to the revocation list, revoking it twice is fine.
+func (tokenRepo *tokenRepo) Revoke(tokenID string, expiresAt time.Time) error_utils.MessageErr {
+	result := tokenRepo.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{ID: tokenID, ExpiresAt: expiresAt})
+
+	if result.Error != nil {
+		return error_formats.ParseError(result.Error)
+	}
+
+	return nil
+}
+
+func (tokenRepo *tokenRepo) IsRevoked(tokenID string) (bool, error_utils.MessageErr) {
+	var revoked int64
+	result := tokenRepo.db.Model(&models.RevokedToken{}).Where("id = ?", tokenID).Count(&revoked)
+
+	if result.Error != nil {
+		return false, error_formats.ParseError(result.Error)
+	}
+
+	return revoked > 0, nil
+}
+
+// Purge removes the refresh tokens and the revoked access tokens that expired, they can't be used anyway.
+func (tokenRepo *tokenRepo) Purge(now time.Time) (int64, error_utils.MessageErr) {
+	var purged int64
+
+	err := tokenRepo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("expires_at <= ?", now).Delete(&models.RefreshToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged += result.RowsAffected
+
+		result = tx.Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged += result.RowsAffected
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, error_formats.ParseError(err)
+	}
+
+	return purged, nil
+}
+
+// revokeFamily revokes every refresh token of the family and the access tokens that may still be alive.
+func revokeFamily(tx *gorm.DB, familyID string, now time.Time, accessTTL time.Duration) error {
+	var tokens []models.RefreshToken
+	if err := tx.Where("family_id = ? AND created_at > ?", familyID, now.Add(-accessTTL)).Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.RefreshToken{}).Where("family_id = ? AND revoked_at IS NULL", familyID).Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	revoked := []models.RevokedToken{}
+	for _, token := range tokens {
+		if len(token.AccessTokenID) > 0 {
+			revoked = append(revoked, models.RevokedToken{ID: token.AccessTokenID, ExpiresAt: token.CreatedAt.Add(accessTTL)})
+		}
+	}
+
+	if len(revoked) == 0 {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error
+}
+
+// parseTokenError answers 401 for every refresh token that can't be used, the client has to sign in again.
+func parseTokenError(err error) error_utils.MessageErr {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return error_utils.NewUnauthorizedError("the refresh token is not valid")
+	case errors.Is(err, errRefreshTokenReused), errors.Is(err, errRefreshTokenRevoked), errors.Is(err, errRefreshTokenExpired), errors.Is(err, errRefreshTokenUser), errors.Is(err, errRefreshTokenPassword):
+		return error_utils.NewUnauthorizedError(err.Error())
+	}
+
+	return error_formats.ParseError(err)
+}
@@ -5,6 +5,9 @@ import (
 	"api/app/models"
 	"api/app/utils/error_formats"
 	"api/app/utils/error_utils"
+	"errors"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -14,7 +17,13 @@ var UserRepo UserRepoInterface = &userRepo{}
 type UserRepoInterface interface {
 	Get(uint64) (*models.User, error_utils.MessageErr)
 	GetByEmail(email string) (*models.User, error_utils.MessageErr)
+	GetWithDeleted(userID uint64) (*models.User, error_utils.MessageErr)
 	Create(*models.User) (*models.User, error_utils.MessageErr)
+	List(query models.UserQuery) (*models.UserPage, error_utils.MessageErr)
+	Update(user *models.User) (*models.User, error_utils.MessageErr)
+	SetDeactivatedAt(userID uint64, deactivatedAt *time.Time) (*models.User, error_utils.MessageErr)
+	Delete(userID uint64) error_utils.MessageErr
+	BootstrapAdmin(admin *models.User) (*models.User, error_utils.MessageErr)
 	Init()
 }
 
@@ -51,6 +60,18 @@ func (userRepo *userRepo) Get(userId uint64) (*models.User, error_utils.MessageE
 	return user, nil
 }
 
+// GetWithDeleted returns the user even when it was deleted, the DeletedAt tells if it was.
+func (userRepo *userRepo) GetWithDeleted(userID uint64) (*models.User, error_utils.MessageErr) {
+	user := &models.User{}
+	result := userRepo.db.Unscoped().First(user, userID)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return user, nil
+}
+
 func (userRepo *userRepo) GetByEmail(email string) (*models.User, error_utils.MessageErr) {
 	var user *models.User = &models.User{}
 	result := userRepo.db.Where("email = ?", email).First(&user)
@@ -61,3 +82,146 @@ func (userRepo *userRepo) GetByEmail(email string) (*models.User, error_utils.Me
 
 	return user, nil
 }
+
+func (userRepo *userRepo) List(query models.UserQuery) (*models.UserPage, error_utils.MessageErr) {
+	page := &models.UserPage{Data: []models.User{}}
+
+	if result := filterUsers(userRepo.db.Model(&models.User{}), query).Count(&page.Total); result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	users := []models.User{}
+	db := filterUsers(userRepo.db, query)
+	if query.Cursor != 0 {
+		db = db.Where("id > ?", query.Cursor)
+	}
+
+	// one extra row is fetched to know if there is a next page
+	if result := db.Order("id").Limit(query.Limit + 1).Find(&users); result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	if len(users) > query.Limit {
+		users = users[:query.Limit]
+		page.NextCursor = models.EncodeUserCursor(users[len(users)-1].ID)
+	}
+
+	page.Data = users
+
+	return page, nil
+}
+
+// filterUsers applies the UserQuery filters, it is shared by the listing and the total count.
+func filterUsers(db *gorm.DB, query models.UserQuery) *gorm.DB {
+	if len(query.Type) > 0 {
+		db = db.Where("type = ?", query.Type)
+	}
+
+	if query.Active != nil {
+		if *query.Active {
+			db = db.Where("deactivated_at IS NULL")
+		} else {
+			db = db.Where("deactivated_at IS NOT NULL")
+		}
+	}
+
+	if len(query.Text) > 0 {
+		text := "%" + escapeLike(query.Text) + "%"
+		db = db.Where("name LIKE ? OR email LIKE ?", text, text)
+	}
+
+	return db
+}
+
+// Update saves the profile of the user, its password and its type.
+func (userRepo *userRepo) Update(user *models.User) (*models.User, error_utils.MessageErr) {
+	result := userRepo.db.Model(user).Select("name", "email", "password", "type", "password_changed_at").Updates(user)
+
+	if result.Error != nil {
+		return nil, parseUserError(result.Error, user)
+	}
+
+	return user, nil
+}
+
+// SetDeactivatedAt deactivates the user at the given date, without a date the user is active again.
+func (userRepo *userRepo) SetDeactivatedAt(userID uint64, deactivatedAt *time.Time) (*models.User, error_utils.MessageErr) {
+	user := &models.User{}
+
+	err := userRepo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(user, userID).Error; err != nil {
+			return err
+		}
+
+		user.DeactivatedAt = deactivatedAt
+		return tx.Model(user).Select("deactivated_at").Updates(user).Error
+	})
+
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+
+	return user, nil
+}
+
+// Delete hides the user, the tasks and the history it is part of are kept.
+func (userRepo *userRepo) Delete(userID uint64) error_utils.MessageErr {
+	result := userRepo.db.Delete(&models.User{}, userID)
+
+	if result.Error != nil {
+		return error_formats.ParseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return error_formats.ParseError(gorm.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// parseUserError turns an email already taken into a 409, every other error is parsed as usual.
+func parseUserError(err error, user *models.User) error_utils.MessageErr {
+	if error_formats.IsDuplicateEntry(err) {
+		return error_utils.NewConflictError(fmt.Sprintf("the email %s is already taken", user.Email))
+	}
+
+	return error_formats.ParseError(err)
+}
+
+// BootstrapAdmin makes the user of the admin email an Admin while there is no Admin yet, the admin is created when the email is unknown.
+// Nothing changes once an Admin exists, the returned user is then nil.
+func (userRepo *userRepo) BootstrapAdmin(admin *models.User) (*models.User, error_utils.MessageErr) {
+	var bootstrapped *models.User
+
+	err := userRepo.db.Transaction(func(tx *gorm.DB) error {
+		var admins int64
+		if err := tx.Model(&models.User{}).Where("type = ?", "Admin").Count(&admins).Error; err != nil {
+			return err
+		}
+
+		if admins > 0 {
+			return nil
+		}
+
+		user := &models.User{}
+		err := tx.Where("email = ?", admin.Email).First(user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			bootstrapped = admin
+			return tx.Create(admin).Error
+		}
+
+		if err != nil {
+			return err
+		}
+
+		user.Type = admin.Type
+		bootstrapped = user
+		return tx.Model(user).Select("type").Updates(user).Error
+	})
+
+	if err != nil {
+		return nil, error_formats.ParseError(err)
+	}
+
+	return bootstrapped, nil
+}
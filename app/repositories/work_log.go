@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"api/app/database"
+	"api/app/models"
+	"api/app/utils/error_formats"
+	"api/app/utils/error_utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var WorkLogRepo WorkLogRepoInterface = &workLogRepo{}
+
+var errNoRunningTimer = errors.New("there is no timer running on the task")
+
+// runningTimerError tells on which task the technician already has a timer running.
+type runningTimerError struct {
+	taskID uint64
+}
+
+func (err *runningTimerError) Error() string {
+	return fmt.Sprintf("a timer is already running on the task %d, stop it first", err.taskID)
+}
+
+type WorkLogRepoInterface interface {
+	GetAllByTaskID(taskID uint64) ([]models.WorkLog, error_utils.MessageErr)
+	Create(*models.WorkLog) (*models.WorkLog, error_utils.MessageErr)
+	StartTimer(*models.WorkLog) (*models.WorkLog, error_utils.MessageErr)
+	StopTimer(taskID uint64, technicianID uint64, now time.Time, note string) (*models.WorkLog, error_utils.MessageErr)
+	TotalByTaskID(taskID uint64) (int64, error_utils.MessageErr)
+	GetAllByTechnician(technicianID uint64, from time.Time, to time.Time) ([]models.WorkLog, error_utils.MessageErr)
+	Init()
+}
+
+type workLogRepo struct {
+	db *gorm.DB
+}
+
+func (workLogRepo *workLogRepo) Init() {
+	workLogRepo.db = database.Database
+}
+
+func NewWorkLogRepository(db *gorm.DB) WorkLogRepoInterface {
+	return &workLogRepo{db: db}
+}
+
+func (workLogRepo *workLogRepo) GetAllByTaskID(taskID uint64) ([]models.WorkLog, error_utils.MessageErr) {
+	workLogs := []models.WorkLog{}
+	result := workLogRepo.db.Where(&models.WorkLog{TaskID: taskID}).Order("started_at, id").Find(&workLogs)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return workLogs, nil
+}
+
+func (workLogRepo *workLogRepo) Create(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr) {
+	result := workLogRepo.db.Create(workLog)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return workLog, nil
+}
+
+// StartTimer starts a timer for the technician unless one is already running, the technician row is locked so two
+// requests at once can't both start one.
+func (workLogRepo *workLogRepo) StartTimer(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr) {
+	err := workLogRepo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, workLog.TechnicianID).Error; err != nil {
+			return err
+		}
+
+		running := models.WorkLog{}
+		result := tx.Where("technician_id = ? AND ended_at IS NULL", workLog.TechnicianID).Limit(1).Find(&running)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected > 0 {
+			return &runningTimerError{taskID: running.TaskID}
+		}
+
+		workLog.EndedAt = nil
+		workLog.DurationSeconds = 0
+		return tx.Create(workLog).Error
+	})
+
+	if err != nil {
+		var errRunning *runningTimerError
+		if errors.As(err, &errRunning) {
+			return nil, error_utils.NewConflictError(err.Error())
+		}
+		return nil, error_formats.ParseError(err)
+	}
+
+	return workLog, nil
+}
+
+// StopTimer stops the timer the technician has running on the task.
+func (workLogRepo *workLogRepo) StopTimer(taskID uint64, technicianID uint64, now time.Time, note string) (*models.WorkLog, error_utils.MessageErr) {
+	workLog := &models.WorkLog{}
+
+	err := workLogRepo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("task_id = ? AND technician_id = ? AND ended_at IS NULL", taskID, technicianID).
+			Limit(1).Find(workLog)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errNoRunningTimer
+		}
+
+		workLog.Stop(now, note)
+
+		return tx.Model(workLog).Select("ended_at", "duration_seconds", "note").Updates(workLog).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, errNoRunningTimer) {
+			return nil, error_utils.NewNotFoundError(err.Error())
+		}
+		return nil, error_formats.ParseError(err)
+	}
+
+	return workLog, nil
+}
+
+// TotalByTaskID sums the time logged on the task, the running timers aren't counted.
+func (workLogRepo *workLogRepo) TotalByTaskID(taskID uint64) (int64, error_utils.MessageErr) {
+	var total int64
+	result := workLogRepo.db.Model(&models.WorkLog{}).
+		Where("task_id = ? AND ended_at IS NOT NULL", taskID).
+		Select("COALESCE(SUM(duration_seconds), 0)").
+		Scan(&total)
+
+	if result.Error != nil {
+		return 0, error_formats.ParseError(result.Error)
+	}
+
+	return total, nil
+}
+
+func (workLogRepo *workLogRepo) GetAllByTechnician(technicianID uint64, from time.Time, to time.Time) ([]models.WorkLog, error_utils.MessageErr) {
+	workLogs := []models.WorkLog{}
+	result := workLogRepo.db.
+		Where("technician_id = ? AND started_at BETWEEN ? AND ?", technicianID, from, to).
+		Order("started_at, id").
+		Find(&workLogs)
+
+	if result.Error != nil {
+		return nil, error_formats.ParseError(result.Error)
+	}
+
+	return workLogs, nil
+}
@@ -8,20 +8,108 @@ import (
 )
 
 func InitializeRoutes(router *gin.Engine) {
+	// the public keys that verify the tokens
+	router.GET("/.well-known/jwks.json", controllers.GetJWKS)
+
 	v1 := router.Group("/v1")
 	{
 		// Login route
 		v1.POST("/login", controllers.Login)
+		v1.POST("/token/refresh", controllers.RefreshToken)
+		v1.POST("/logout", middleware.AuthUser(), controllers.Logout)
+		v1.POST("/password/forgot", controllers.ForgotPassword)
+		v1.POST("/password/reset", controllers.ResetPassword)
 
-		// User route
+		// User routes
 		v1.POST("/users", controllers.CreateUser)
+		v1.GET("/users", middleware.AuthUser(), controllers.GetUsers)
+		v1.GET("/users/:id", middleware.AuthUser(), controllers.GetUser)
+		v1.PUT("/users/me/password", middleware.AuthUser(), controllers.ChangePassword)
+		v1.PUT("/users/:id", middleware.AuthUser(), controllers.UpdateUser)
+		v1.DELETE("/users/:id", middleware.AuthUser(), controllers.DeleteUser)
+		v1.POST("/users/:id/deactivate", middleware.AuthUser(), controllers.DeactivateUser)
+		v1.POST("/users/:id/activate", middleware.AuthUser(), controllers.ActivateUser)
+
+		// Invitations routes
+		v1.POST("/invitations", middleware.AuthUser(), controllers.CreateInvitation)
+		v1.GET("/invitations", middleware.AuthUser(), controllers.GetInvitations)
+		v1.DELETE("/invitations/:id", middleware.AuthUser(), controllers.DeleteInvitation)
+		v1.POST("/invitations/accept", controllers.AcceptInvitation)
 
 		// Tasks routes
 		v1.POST("/tasks", middleware.AuthUser(), controllers.CreateTask)
+		v1.POST("/tasks/from-template/:templateId", middleware.AuthUser(), controllers.CreateTaskFromTemplate)
+		v1.POST("/tasks/bulk", middleware.AuthUser(), controllers.BulkTasks)
 		v1.GET("/tasks", middleware.AuthUser(), controllers.GetAllTasks)
+		v1.GET("/tasks/search", middleware.AuthUser(), controllers.SearchTasks)
+		v1.GET("/tasks/export", middleware.AuthUser(), controllers.ExportTasks)
+		v1.GET("/tasks/trash", middleware.AuthUser(), controllers.GetTrashTasks)
+		v1.GET("/tasks/sla_breaches", middleware.AuthUser(), controllers.GetSLABreaches)
 		v1.GET("/tasks/:id", middleware.AuthUser(), controllers.GetTask)
 		v1.PUT("/tasks/:id", middleware.AuthUser(), controllers.UpdateTask)
 		v1.DELETE("/tasks/:id", middleware.AuthUser(), controllers.DeleteTasks)
+		v1.PATCH("/tasks/:id/status", middleware.AuthUser(), controllers.UpdateTaskStatus)
+		v1.POST("/tasks/:id/assign", middleware.AuthUser(), controllers.AssignTask)
+		v1.GET("/tasks/:id/history", middleware.AuthUser(), controllers.GetTaskHistory)
+		v1.POST("/tasks/:id/restore", middleware.AuthUser(), controllers.RestoreTask)
+		v1.POST("/tasks/:id/comments", middleware.AuthUser(), controllers.CreateComment)
+		v1.GET("/tasks/:id/comments", middleware.AuthUser(), controllers.GetComments)
+		v1.PUT("/tasks/:id/comments/:commentId", middleware.AuthUser(), controllers.UpdateComment)
+		v1.DELETE("/tasks/:id/comments/:commentId", middleware.AuthUser(), controllers.DeleteComment)
+		v1.POST("/tasks/:id/attachments", middleware.AuthUser(), controllers.UploadAttachment)
+		v1.GET("/tasks/:id/attachments", middleware.AuthUser(), controllers.GetAttachments)
+		v1.GET("/tasks/:id/attachments/:attachmentId", middleware.AuthUser(), controllers.DownloadAttachment)
+		v1.DELETE("/tasks/:id/attachments/:attachmentId", middleware.AuthUser(), controllers.DeleteAttachment)
+		v1.GET("/tasks/:id/checklist", middleware.AuthUser(), controllers.GetChecklist)
+		v1.POST("/tasks/:id/checklist", middleware.AuthUser(), controllers.CreateChecklistItem)
+		v1.PATCH("/tasks/:id/checklist/:itemId", middleware.AuthUser(), controllers.UpdateChecklistItem)
+		v1.DELETE("/tasks/:id/checklist/:itemId", middleware.AuthUser(), controllers.DeleteChecklistItem)
+		v1.GET("/tasks/:id/dependencies", middleware.AuthUser(), controllers.GetDependencies)
+		v1.POST("/tasks/:id/dependencies", middleware.AuthUser(), controllers.LinkTask)
+		v1.DELETE("/tasks/:id/dependencies/:blockerId", middleware.AuthUser(), controllers.UnlinkTask)
+		v1.PUT("/tasks/:id/tags", middleware.AuthUser(), controllers.SetTaskTags)
+		v1.POST("/tasks/:id/timer/start", middleware.AuthUser(), controllers.StartTimer)
+		v1.POST("/tasks/:id/timer/stop", middleware.AuthUser(), controllers.StopTimer)
+		v1.POST("/tasks/:id/worklogs", middleware.AuthUser(), controllers.CreateWorkLog)
+		v1.GET("/tasks/:id/worklogs", middleware.AuthUser(), controllers.GetWorkLogs)
 		v1.GET("/user_tasks", middleware.AuthUser(), controllers.GetTasksByUser)
+		v1.GET("/timesheets", middleware.AuthUser(), controllers.GetTimesheet)
+
+		// Assets routes
+		v1.POST("/assets", middleware.AuthUser(), controllers.CreateAsset)
+		v1.GET("/assets", middleware.AuthUser(), controllers.GetAssets)
+		v1.GET("/assets/:id", middleware.AuthUser(), controllers.GetAsset)
+		v1.PUT("/assets/:id", middleware.AuthUser(), controllers.UpdateAsset)
+		v1.DELETE("/assets/:id", middleware.AuthUser(), controllers.DeleteAsset)
+		v1.GET("/assets/:id/tasks", middleware.AuthUser(), controllers.GetAssetTasks)
+
+		// Locations routes
+		v1.POST("/locations", middleware.AuthUser(), controllers.CreateLocation)
+		v1.GET("/locations", middleware.AuthUser(), controllers.GetLocations)
+		v1.GET("/locations/:id", middleware.AuthUser(), controllers.GetLocation)
+		v1.PUT("/locations/:id", middleware.AuthUser(), controllers.UpdateLocation)
+		v1.DELETE("/locations/:id", middleware.AuthUser(), controllers.DeleteLocation)
+		v1.PUT("/users/:id/sites", middleware.AuthUser(), controllers.SetUserSites)
+
+		// Templates routes
+		v1.POST("/templates", middleware.AuthUser(), controllers.CreateTemplate)
+		v1.GET("/templates", middleware.AuthUser(), controllers.GetTemplates)
+		v1.GET("/templates/:id", middleware.AuthUser(), controllers.GetTemplate)
+		v1.PUT("/templates/:id", middleware.AuthUser(), controllers.UpdateTemplate)
+		v1.DELETE("/templates/:id", middleware.AuthUser(), controllers.DeleteTemplate)
+		v1.GET("/templates/:id/versions", middleware.AuthUser(), controllers.GetTemplateVersions)
+
+		// Tags routes
+		v1.POST("/tags", middleware.AuthUser(), controllers.CreateTag)
+		v1.GET("/tags", middleware.AuthUser(), controllers.GetTags)
+		v1.PUT("/tags/:id", middleware.AuthUser(), controllers.UpdateTag)
+		v1.DELETE("/tags/:id", middleware.AuthUser(), controllers.DeleteTag)
+
+		// Schedules routes
+		v1.POST("/schedules", middleware.AuthUser(), controllers.CreateSchedule)
+		v1.GET("/schedules", middleware.AuthUser(), controllers.GetSchedules)
+		v1.GET("/schedules/:id", middleware.AuthUser(), controllers.GetSchedule)
+		v1.PUT("/schedules/:id", middleware.AuthUser(), controllers.UpdateSchedule)
+		v1.DELETE("/schedules/:id", middleware.AuthUser(), controllers.DeleteSchedule)
 	}
 }
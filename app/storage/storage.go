@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"api/app/config"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// Files is the storage used for the task attachments, it is set by Init from the configuration.
+var Files Storage
+
+var (
+	ErrObjectNotFound = errors.New("the file doesn't exist in the storage")
+	ErrInvalidKey     = errors.New("the file key is invalid")
+)
+
+// Storage keeps the content of the uploaded files, objects are addressed by a slash separated key.
+type Storage interface {
+	// Put streams the body into the object and returns how many bytes were written,
+	// the object is only created when the whole body was read without error.
+	Put(ctx context.Context, key string, body io.Reader, contentType string) (int64, error)
+	// Get returns a reader over the object content, the caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+func Init() {
+	switch config.STORAGE_DRIVER {
+	case "s3":
+		Files = NewS3Storage(S3Options{
+			Endpoint:        config.S3_ENDPOINT,
+			Region:          config.S3_REGION,
+			Bucket:          config.S3_BUCKET,
+			AccessKeyID:     config.S3_ACCESS_KEY_ID,
+			SecretAccessKey: config.S3_SECRET_ACCESS_KEY,
+		})
+	default:
+		Files = NewLocalStorage(config.STORAGE_LOCAL_PATH)
+	}
+}
+
+// validateKey refuses keys that could escape the storage root like absolute paths or parent segments.
+func validateKey(key string) error {
+	if len(key) == 0 || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package cron_utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expression is a parsed five fields cron expression: minute, hour, day of month, month and day of week.
+type Expression struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	// when both days and weekdays are restricted a time matching either of them is due, like in crontab
+	anyDay     bool
+	anyWeekday bool
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteField  = field{"minute", 0, 59}
+	hourField    = field{"hour", 0, 23}
+	dayField     = field{"day of month", 1, 31}
+	monthField   = field{"month", 1, 12}
+	weekdayField = field{"day of week", 0, 7}
+)
+
+var macros = map[string]string{
+	"@yearly":    "0 0 1 1 *",
+	"@annually":  "0 0 1 1 *",
+	"@quarterly": "0 0 1 1,4,7,10 *",
+	"@monthly":   "0 0 1 * *",
+	"@weekly":    "0 0 * * 0",
+	"@daily":     "0 0 * * *",
+	"@hourly":    "0 * * * *",
+}
+
+// searchLimit bounds the search of the next occurrence, an expression like "0 0 31 2 *" never matches.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Parse reads a cron expression like "0 8 1 */3 *" or one of the macros like "@monthly".
+func Parse(spec string) (*Expression, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("the recurrence %q should have 5 fields: minute, hour, day of month, month and day of week", spec)
+	}
+
+	expression := &Expression{
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+
+	var err error
+	if expression.minutes, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if expression.hours, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if expression.days, err = parseField(fields[2], dayField); err != nil {
+		return nil, err
+	}
+	if expression.months, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if expression.weekdays, err = parseField(fields[4], weekdayField); err != nil {
+		return nil, err
+	}
+
+	// sunday can be written 0 or 7
+	if expression.weekdays&(1<<7) != 0 {
+		expression.weekdays |= 1
+	}
+
+	return expression, nil
+}
+
+// Next returns the first time strictly after the given one matching the expression, in the location of the given time,
+// the zero time when nothing matches.
+func (expression *Expression) Next(after time.Time) time.Time {
+	location := after.Location()
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(searchLimit)
+
+	for next.Before(limit) {
+		if !has(expression.months, int(next.Month())) {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		if !expression.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		if !has(expression.hours, next.Hour()) {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+
+		if !has(expression.minutes, next.Minute()) {
+			next = next.Add(time.Minute)
+			continue
+		}
+
+		return next
+	}
+
+	return time.Time{}
+}
+
+func (expression *Expression) matchesDay(t time.Time) bool {
+	day := has(expression.days, t.Day())
+	weekday := has(expression.weekdays, int(t.Weekday()))
+
+	switch {
+	case expression.anyDay && expression.anyWeekday:
+		return true
+	case expression.anyDay:
+		return weekday
+	case expression.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<uint(value)) != 0
+}
+
+// parseField turns a field made of comma separated values, ranges and steps into the set of its values.
+func parseField(value string, f field) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			parsedStep, err := strconv.Atoi(part[i+1:])
+			if err != nil || parsedStep <= 0 {
+				return 0, fmt.Errorf("the step %q of the %s is not valid", part[i+1:], f.name)
+			}
+			rangePart, step = part[:i], parsedStep
+		}
+
+		start, end := f.min, f.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+
+			var err error
+			if start, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+
+			end = start
+			if len(bounds) == 2 {
+				if end, err = parseValue(bounds[1], f); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// "5/15" means from 5 to the end every 15
+				end = f.max
+			}
+
+			if end < start {
+				return 0, fmt.Errorf("the range %q of the %s is not valid", rangePart, f.name)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+func parseValue(value string, f field) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("the value %q of the %s should be between %d and %d", value, f.name, f.min, f.max)
+	}
+
+	return v, nil
+}
@@ -2,6 +2,7 @@ package error_formats
 
 import (
 	"api/app/utils/error_utils"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -25,3 +26,9 @@ func ParseError(err error) error_utils.MessageErr {
 	}
 	return error_utils.NewInternalServerError(fmt.Sprintf("error when processing request %s", err.Error()))
 }
+
+// IsDuplicateEntry tells if the error comes from a unique index violation.
+func IsDuplicateEntry(err error) bool {
+	var sqlErr *mysql.MySQLError
+	return errors.As(err, &sqlErr) && sqlErr.Number == 1062
+}
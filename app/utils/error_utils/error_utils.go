@@ -85,3 +85,43 @@ func NewForbiddenError(msg string) MessageErr {
 		ErrError: "forbidden",
 	} 
 }
+
+func NewConflictError(msg string) MessageErr {
+	return &messageErr{
+		ErrMessage: msg,
+		ErrStatus: http.StatusConflict,
+		ErrError: "conflict",
+	}
+}
+
+func NewPreconditionFailedError(msg string) MessageErr {
+	return &messageErr{
+		ErrMessage: msg,
+		ErrStatus: http.StatusPreconditionFailed,
+		ErrError: "precondition_failed",
+	}
+}
+
+func NewPreconditionRequiredError(msg string) MessageErr {
+	return &messageErr{
+		ErrMessage: msg,
+		ErrStatus: http.StatusPreconditionRequired,
+		ErrError: "precondition_required",
+	}
+}
+
+func NewPayloadTooLargeError(msg string) MessageErr {
+	return &messageErr{
+		ErrMessage: msg,
+		ErrStatus: http.StatusRequestEntityTooLarge,
+		ErrError: "payload_too_large",
+	}
+}
+
+func NewUnsupportedMediaTypeError(msg string) MessageErr {
+	return &messageErr{
+		ErrMessage: msg,
+		ErrStatus: http.StatusUnsupportedMediaType,
+		ErrError: "unsupported_media_type",
+	}
+}
@@ -0,0 +1,94 @@
+package search_utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	highlightOpen  = "<mark>"
+	highlightClose = "</mark>"
+	ellipsis       = "…"
+)
+
+// Terms splits a free text query into the words used to highlight the results.
+func Terms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Snippet returns the part of the text around the first matching term, HTML escaped and with every term wrapped in <mark> tags.
+func Snippet(text string, query string, radius int) string {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return leading(text, 2*radius)
+	}
+
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	matcher := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+
+	first := matcher.FindStringIndex(text)
+	if first == nil {
+		return leading(text, 2*radius)
+	}
+
+	start, end := first[0]-radius, first[1]+radius
+	window := truncate(text, start, end)
+
+	var snippet strings.Builder
+	last := 0
+	for _, match := range matcher.FindAllStringIndex(window, -1) {
+		snippet.WriteString(html.EscapeString(window[last:match[0]]))
+		snippet.WriteString(highlightOpen)
+		snippet.WriteString(html.EscapeString(window[match[0]:match[1]]))
+		snippet.WriteString(highlightClose)
+		last = match[1]
+	}
+	snippet.WriteString(html.EscapeString(window[last:]))
+
+	result := snippet.String()
+	if start > 0 {
+		result = ellipsis + result
+	}
+	if end < len(text) {
+		result = result + ellipsis
+	}
+
+	return result
+}
+
+// leading returns the beginning of the text when none of the terms is found verbatim.
+func leading(text string, size int) string {
+	result := html.EscapeString(truncate(text, 0, size))
+	if size < len(text) {
+		result = result + ellipsis
+	}
+
+	return result
+}
+
+// truncate cuts the text between the byte offsets without splitting a multi-byte character.
+func truncate(text string, start int, end int) string {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	return text[start:end]
+}
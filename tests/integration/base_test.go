@@ -41,7 +41,7 @@ func (s *SuiteTest) SetupSuite() {
 func (s *SuiteTest) TearDownSuite() {
 	p, _ := os.FindProcess(syscall.Getpid())
 	p.Signal(syscall.SIGINT)
-	database.Database.Migrator().DropTable(&models.User{}, &models.Task{})
+	database.Database.Migrator().DropTable(&models.User{}, &models.Task{}, &models.TaskAssignment{}, &models.TaskEvent{}, &models.Comment{}, &models.Attachment{}, &models.ChecklistItem{}, &models.TaskDependency{}, &models.Schedule{}, &models.JobLease{}, &models.WorkLog{}, &models.Tag{}, &models.TaskTag{}, &models.Asset{}, &models.Location{}, &models.UserSite{}, &models.TaskTemplate{}, &models.TaskTemplateVersion{}, &models.Invitation{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.PasswordReset{})
 }
 
 func (s *SuiteTest) SetupTest() {
@@ -49,7 +49,7 @@ func (s *SuiteTest) SetupTest() {
 }
 
 func (s *SuiteTest) TearDownTest() {
-	s.NoError(database.Database.Migrator().DropTable(&models.User{}, &models.Task{}))
+	s.NoError(database.Database.Migrator().DropTable(&models.User{}, &models.Task{}, &models.TaskAssignment{}, &models.TaskEvent{}, &models.Comment{}, &models.Attachment{}, &models.ChecklistItem{}, &models.TaskDependency{}, &models.Schedule{}, &models.JobLease{}, &models.WorkLog{}, &models.Tag{}, &models.TaskTag{}, &models.Asset{}, &models.Location{}, &models.UserSite{}, &models.TaskTemplate{}, &models.TaskTemplateVersion{}, &models.Invitation{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.PasswordReset{}))
 }
 
 func (s *SuiteTest) seedOneUserTech() {
@@ -74,6 +74,12 @@ func (s *SuiteTest) seedOneUserManager() {
 	repository.Create(&user)
 }
 
+// seedTokenUsers stores the users of technician_token and manager_token, the middleware refuses the tokens of unknown users
+func (s *SuiteTest) seedTokenUsers() {
+	s.seedOneUserTech()
+	s.seedOneUserManager()
+}
+
 func (s *SuiteTest) seedOneTask() {
 	task := models.Task{
 		ID:      1,
@@ -12,7 +12,7 @@ import (
 )
 
 func (s *SuiteTest) TestCreateTask_Success() {
-	s.seedOneUserTech()
+	s.seedTokenUsers()
 	jsonBody := `{"summary": "This is a summary test"}`
 	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/tasks", baseURL), bytes.NewBufferString(jsonBody))
 	s.NoError(err)
@@ -39,6 +39,7 @@ func (s *SuiteTest) TestCreateTask_Success() {
 }
 
 func (s *SuiteTest) TestCreateTask_WrongJSONFormat() {
+	s.seedTokenUsers()
 	jsonBody := `{"summary": }`
 	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/tasks", baseURL), bytes.NewBufferString(jsonBody))
 	s.NoError(err)
@@ -84,6 +85,7 @@ func (s *SuiteTest) TestCreateTask_WithoutToken() {
 }
 
 func (s *SuiteTest) TestCreateTask_WithoutSummary() {
+	s.seedTokenUsers()
 	jsonBody := `{"summary": ""}`
 	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/tasks", baseURL), bytes.NewBufferString(jsonBody))
 	s.NoError(err)
@@ -107,6 +109,7 @@ func (s *SuiteTest) TestCreateTask_WithoutSummary() {
 }
 
 func (s *SuiteTest) TestCreateTask_Over2500Characters() {
+	s.seedTokenUsers()
 	longString := strings.Repeat("#", 2501)
 	jsonBody := fmt.Sprintf(`{"summary": "%s"}`, longString)
 	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/tasks", baseURL), bytes.NewBufferString(jsonBody))
@@ -131,6 +134,7 @@ func (s *SuiteTest) TestCreateTask_Over2500Characters() {
 }
 
 func (s *SuiteTest) TestCreateTask_WrongPermission() {
+	s.seedTokenUsers()
 	jsonBody := `{"summary": "This is a summary test"}`
 	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/tasks", baseURL), bytes.NewBufferString(jsonBody))
 	s.NoError(err)
@@ -154,7 +158,7 @@ func (s *SuiteTest) TestCreateTask_WrongPermission() {
 }
 
 func (s *SuiteTest) TestUpdateTask_Success() {
-	s.seedOneUserTech()
+	s.seedTokenUsers()
 	s.seedOneTask()
 	jsonBody := `{"id": 1, "summary": "This is a summary test updated", "userId": 1}`
 	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/tasks/1", baseURL), bytes.NewBufferString(jsonBody))
@@ -162,16 +166,43 @@ func (s *SuiteTest) TestUpdateTask_Success() {
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", technician_token)
+	req.Header.Set("If-Match", `"1"`)
 
 	client := http.Client{}
 	resp, err := client.Do(req)
 	s.NoError(err)
 
 	s.Equal(http.StatusNoContent, resp.StatusCode)
+	s.Equal(`"2"`, resp.Header.Get("ETag"))
+}
+
+func (s *SuiteTest) TestUpdateTask_StaleVersion() {
+	s.seedTokenUsers()
+	s.seedOneTask()
+	jsonBody := `{"summary": "This is a summary test updated"}`
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/tasks/1", baseURL), bytes.NewBufferString(jsonBody))
+	s.NoError(err)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", technician_token)
+	req.Header.Set("If-Match", `"2"`)
+
+	client := http.Client{}
+	resp, err := client.Do(req)
+	s.NoError(err)
+
+	byteBody, err := ioutil.ReadAll(resp.Body)
+	s.NoError(err)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(byteBody)
+	s.Nil(err)
+	s.NotNil(apiErr)
+	s.Equal(http.StatusPreconditionFailed, apiErr.Status())
+	s.Equal("precondition_failed", apiErr.Error())
 }
 
 func (s *SuiteTest) TestUpdateTask_WrongJSONFormat() {
-	s.seedOneUserTech()
+	s.seedTokenUsers()
 	s.seedOneTask()
 	jsonBody := `{"id": "2", "summary": "This is a summary test", "userId": 1}`
 	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/tasks/1", baseURL), bytes.NewBufferString(jsonBody))
@@ -218,7 +249,7 @@ func (s *SuiteTest) TestUpdateTask_WithoutToken() {
 }
 
 func (s *SuiteTest) TestUpdateTask_WithoutSummary() {
-	s.seedOneUserTech()
+	s.seedTokenUsers()
 	s.seedOneTask()
 	jsonBody := `{"id": 2, "summary": "", "userId": 1}`
 	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/tasks/1", baseURL), bytes.NewBufferString(jsonBody))
@@ -243,7 +274,7 @@ func (s *SuiteTest) TestUpdateTask_WithoutSummary() {
 }
 
 func (s *SuiteTest) TestUpdateTask_Over2500Characters() {
-	s.seedOneUserTech()
+	s.seedTokenUsers()
 	s.seedOneTask()
 	longString := strings.Repeat("#", 2501)
 	jsonBody := fmt.Sprintf(`{"id": 2, "summary": "%s", "userId": 1}`, longString)
@@ -269,7 +300,7 @@ func (s *SuiteTest) TestUpdateTask_Over2500Characters() {
 }
 
 func (s *SuiteTest) TestUpdateTask_WrongPermission() {
-	s.seedOneUserManager()
+	s.seedTokenUsers()
 	jsonBody := `{"id": 2, "summary": "This is a summary test", "userId": 1}`
 	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/tasks/1", baseURL), bytes.NewBufferString(jsonBody))
 	s.NoError(err)
@@ -293,6 +324,7 @@ func (s *SuiteTest) TestUpdateTask_WrongPermission() {
 }
 
 func (s *SuiteTest) TestUpdateTask_InvalidID() {
+	s.seedTokenUsers()
 	jsonBody := `{"id": 1, "summary": "This is a summary test", "userId: 1}`
 	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/tasks/abs", baseURL), bytes.NewBufferString(jsonBody))
 	s.NoError(err)
@@ -316,8 +348,7 @@ func (s *SuiteTest) TestUpdateTask_InvalidID() {
 }
 
 func (s *SuiteTest) TestUpdateTask_DifferentUser() {
-	s.seedOneUserTech()
-	s.seedOneUserManager()
+	s.seedTokenUsers()
 	s.seedMultipleTasks()
 	jsonBody := `{"id": 3, "summary": "This is a summary test", "userId": 2}`
 	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/tasks/3", baseURL), bytes.NewBufferString(jsonBody))
@@ -342,6 +373,7 @@ func (s *SuiteTest) TestUpdateTask_DifferentUser() {
 }
 
 func (s *SuiteTest) TestUpdateTask_NotFoundTask() {
+	s.seedTokenUsers()
 	jsonBody := `{"id": 1, "summary": "This is a summary test", "userId": 2}`
 	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/tasks/99", baseURL), bytes.NewBufferString(jsonBody))
 	s.NoError(err)
@@ -365,7 +397,7 @@ func (s *SuiteTest) TestUpdateTask_NotFoundTask() {
 }
 
 func (s *SuiteTest) TestGetTask_Success() {
-	s.seedOneUserTech()
+	s.seedTokenUsers()
 	s.seedOneTask()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/tasks/1", baseURL), nil)
 	s.NoError(err)
@@ -413,6 +445,7 @@ func (s *SuiteTest) TestGetTask_WithoutToken() {
 }
 
 func (s *SuiteTest) TestGetTask_WrongPermission() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/tasks/1", baseURL), nil)
 	s.NoError(err)
 
@@ -435,6 +468,7 @@ func (s *SuiteTest) TestGetTask_WrongPermission() {
 }
 
 func (s *SuiteTest) TestGetTask_InvalidID() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/tasks/abc", baseURL), nil)
 	s.NoError(err)
 
@@ -457,8 +491,7 @@ func (s *SuiteTest) TestGetTask_InvalidID() {
 }
 
 func (s *SuiteTest) TestGetTask_DifferentUser() {
-	s.seedOneUserTech()
-	s.seedOneUserManager()
+	s.seedTokenUsers()
 	s.seedMultipleTasks()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/tasks/3", baseURL), nil)
 	s.NoError(err)
@@ -482,6 +515,7 @@ func (s *SuiteTest) TestGetTask_DifferentUser() {
 }
 
 func (s *SuiteTest) TestGetTask_NotFoundTask() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/tasks/99", baseURL), nil)
 	s.NoError(err)
 
@@ -504,8 +538,7 @@ func (s *SuiteTest) TestGetTask_NotFoundTask() {
 }
 
 func (s *SuiteTest) TestGetTasksByUser_Success() {
-	s.seedOneUserTech()
-	s.seedOneUserManager()
+	s.seedTokenUsers()
 	s.seedMultipleTasks()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/user_tasks", baseURL), nil)
 	s.NoError(err)
@@ -520,12 +553,13 @@ func (s *SuiteTest) TestGetTasksByUser_Success() {
 	byteBody, err := ioutil.ReadAll(resp.Body)
 	s.NoError(err)
 
-	var tasks []models.Task
-	errUnmarshal := json.Unmarshal(byteBody, &tasks)
+	var page models.TaskPage
+	errUnmarshal := json.Unmarshal(byteBody, &page)
 	s.Nil(errUnmarshal)
-	s.NotNil(tasks)
+	s.NotNil(page.Data)
 	s.Equal(http.StatusOK, resp.StatusCode)
-	s.Equal(2, len(tasks))
+	s.Equal(2, len(page.Data))
+	s.Equal(int64(2), page.Total)
 }
 
 func (s *SuiteTest) TestGetTasksByUser_WithoutToken() {
@@ -550,6 +584,7 @@ func (s *SuiteTest) TestGetTasksByUser_WithoutToken() {
 }
 
 func (s *SuiteTest) TestGetTasksByUser_WrongPermission() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/user_tasks", baseURL), nil)
 	s.NoError(err)
 
@@ -572,6 +607,7 @@ func (s *SuiteTest) TestGetTasksByUser_WrongPermission() {
 }
 
 func (s *SuiteTest) TestGetTasksByUser_EmptyList() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/user_tasks", baseURL), nil)
 	s.NoError(err)
 
@@ -585,17 +621,17 @@ func (s *SuiteTest) TestGetTasksByUser_EmptyList() {
 	byteBody, err := ioutil.ReadAll(resp.Body)
 	s.NoError(err)
 
-	var tasks []models.Task
-	errUnmarshal := json.Unmarshal(byteBody, &tasks)
+	var page models.TaskPage
+	errUnmarshal := json.Unmarshal(byteBody, &page)
 	s.Nil(errUnmarshal)
-	s.NotNil(tasks)
+	s.NotNil(page.Data)
 	s.Equal(http.StatusOK, resp.StatusCode)
-	s.Equal(0, len(tasks))
+	s.Equal(0, len(page.Data))
+	s.Equal(int64(0), page.Total)
 }
 
 func (s *SuiteTest) TestGetTasks_Success() {
-	s.seedOneUserTech()
-	s.seedOneUserManager()
+	s.seedTokenUsers()
 	s.seedMultipleTasks()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/tasks", baseURL), nil)
 	s.NoError(err)
@@ -610,15 +646,17 @@ func (s *SuiteTest) TestGetTasks_Success() {
 	byteBody, err := ioutil.ReadAll(resp.Body)
 	s.NoError(err)
 
-	var tasks []models.Task
-	errUnmarshal := json.Unmarshal(byteBody, &tasks)
+	var page models.TaskPage
+	errUnmarshal := json.Unmarshal(byteBody, &page)
 	s.Nil(errUnmarshal)
-	s.NotNil(tasks)
+	s.NotNil(page.Data)
 	s.Equal(http.StatusOK, resp.StatusCode)
-	s.Equal(3, len(tasks))
+	s.Equal(3, len(page.Data))
+	s.Equal(int64(3), page.Total)
 }
 
 func (s *SuiteTest) TestGetTasks_WrongPermission() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/tasks", baseURL), nil)
 	s.NoError(err)
 
@@ -641,6 +679,7 @@ func (s *SuiteTest) TestGetTasks_WrongPermission() {
 }
 
 func (s *SuiteTest) TestGetTasks_EmptyList() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/tasks", baseURL), nil)
 	s.NoError(err)
 
@@ -654,22 +693,24 @@ func (s *SuiteTest) TestGetTasks_EmptyList() {
 	byteBody, err := ioutil.ReadAll(resp.Body)
 	s.NoError(err)
 
-	var tasks []models.Task
-	errUnmarshal := json.Unmarshal(byteBody, &tasks)
+	var page models.TaskPage
+	errUnmarshal := json.Unmarshal(byteBody, &page)
 	s.Nil(errUnmarshal)
-	s.NotNil(tasks)
+	s.NotNil(page.Data)
 	s.Equal(http.StatusOK, resp.StatusCode)
-	s.Equal(0, len(tasks))
+	s.Equal(0, len(page.Data))
+	s.Equal(int64(0), page.Total)
 }
 
 func (s *SuiteTest) TestDeleteTask_Success() {
-	s.seedOneUserTech()
+	s.seedTokenUsers()
 	s.seedOneTask()
 	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/tasks/1", baseURL), nil)
 	s.NoError(err)
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", manager_token)
+	req.Header.Set("If-Match", `"1"`)
 
 	client := http.Client{}
 	resp, err := client.Do(req)
@@ -679,6 +720,7 @@ func (s *SuiteTest) TestDeleteTask_Success() {
 }
 
 func (s *SuiteTest) TestDeleteTask_WrongPermission() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/tasks/1", baseURL), nil)
 	s.NoError(err)
 
@@ -701,6 +743,7 @@ func (s *SuiteTest) TestDeleteTask_WrongPermission() {
 }
 
 func (s *SuiteTest) TestDeleteTask_InvalidID() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/tasks/abc", baseURL), nil)
 	s.NoError(err)
 
@@ -723,11 +766,13 @@ func (s *SuiteTest) TestDeleteTask_InvalidID() {
 }
 
 func (s *SuiteTest) TestDeleteTask_NotFoundTask() {
+	s.seedTokenUsers()
 	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/tasks/1", baseURL), nil)
 	s.NoError(err)
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", manager_token)
+	req.Header.Set("If-Match", `"1"`)
 
 	client := http.Client{}
 	resp, err := client.Do(req)
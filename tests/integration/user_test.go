@@ -163,7 +163,7 @@ func (s *SuiteTest) TestCreateUser_InvalidUserType() {
 	s.Nil(err)
 	s.NotNil(apiErr)
 	s.Equal(http.StatusBadRequest, apiErr.Status())
-	s.Equal("the field type should be Technician or Manager", apiErr.Message())
+	s.Equal("the field type should be Technician, Manager or Admin", apiErr.Message())
 	s.Equal("bad_request", apiErr.Error())
 }
 
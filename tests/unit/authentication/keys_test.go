@@ -0,0 +1,233 @@
+package authentication
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenRepoMock answers that no token is revoked, the revocation is tested with the middleware.
+type tokenRepoMock struct {
+	repositories.TokenRepoInterface
+}
+
+func (tokenRepo *tokenRepoMock) IsRevoked(tokenID string) (bool, error_utils.MessageErr) {
+	return false, nil
+}
+
+func writeKey(t *testing.T, privateKey interface{}) string {
+	content, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: content}), 0600))
+
+	return path
+}
+
+func useKeys(t *testing.T, entries ...string) {
+	config.JWT_SIGNING_KEYS = entries
+	require.NoError(t, authentication.InitKeys())
+
+	t.Cleanup(func() {
+		config.JWT_SIGNING_KEYS = []string{}
+		authentication.Keys = nil
+	})
+}
+
+func tokenContext(token string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	return c
+}
+
+func tokenHeader(t *testing.T, token string) map[string]interface{} {
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+
+	return parsed.Header
+}
+
+func TestSigningKeys_Algorithms(t *testing.T) {
+	repositories.TokenRepo = &tokenRepoMock{}
+	config.ACCESS_TOKEN_TTL = 15 * time.Minute
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		key       interface{}
+		algorithm string
+	}{
+		{rsaKey, "RS256"},
+		{ecKey, "ES256"},
+		{edKey, "EdDSA"},
+	} {
+		useKeys(t, "key-"+test.algorithm+":"+writeKey(t, test.key))
+
+		token, err := authentication.CreateToken(7, "Technician")
+		require.NoError(t, err)
+
+		header := tokenHeader(t, token)
+		assert.Equal(t, test.algorithm, header["alg"])
+		assert.Equal(t, "key-"+test.algorithm, header["kid"])
+
+		c := tokenContext(token)
+		assert.Nil(t, authentication.ValidateToken(c))
+		userID, err := authentication.ExtractUserId(c)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(7), userID)
+	}
+}
+
+func TestSigningKeys_Rotation(t *testing.T) {
+	repositories.TokenRepo = &tokenRepoMock{}
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	oldPath, newPath := writeKey(t, oldKey), writeKey(t, newKey)
+
+	// the new key is published before it signs
+	useKeys(t, "old:"+oldPath, "new:"+newPath+"@"+time.Now().Add(time.Hour).Format(time.RFC3339))
+
+	oldToken, err := authentication.CreateToken(1, "Manager")
+	require.NoError(t, err)
+	assert.Equal(t, "old", tokenHeader(t, oldToken)["kid"])
+	assert.Len(t, authentication.Keys.JWKS().Keys, 2)
+
+	// once the new key is active it signs and the old one still verifies
+	useKeys(t, "old:"+oldPath, "new:"+newPath+"@"+time.Now().Add(-time.Minute).Format(time.RFC3339))
+
+	newToken, err := authentication.CreateToken(1, "Manager")
+	require.NoError(t, err)
+	assert.Equal(t, "new", tokenHeader(t, newToken)["kid"])
+	assert.Nil(t, authentication.ValidateToken(tokenContext(oldToken)))
+	assert.Nil(t, authentication.ValidateToken(tokenContext(newToken)))
+
+	// a removed key doesn't verify anymore
+	useKeys(t, "new:"+newPath)
+
+	err = authentication.ValidateToken(tokenContext(oldToken))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown signing key old")
+}
+
+func TestSigningKeys_RejectsOtherAlgorithms(t *testing.T) {
+	repositories.TokenRepo = &tokenRepoMock{}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	useKeys(t, "rsa:"+writeKey(t, rsaKey))
+
+	// an HMAC token keyed with the public key must not pass as the RSA key
+	publicKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = "rsa"
+	forged, err := token.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+	require.NoError(t, err)
+
+	err = authentication.ValidateToken(tokenContext(forged))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unexpected signature method")
+
+	// the tokens signed with the secret key aren't accepted once the keys are configured
+	config.SECRETKEY = "mySecretK3y"
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(time.Hour).Unix()}).SignedString([]byte(config.SECRETKEY))
+	require.NoError(t, err)
+	assert.NotNil(t, authentication.ValidateToken(tokenContext(hmacToken)))
+}
+
+func TestSigningKeys_JWKSVerifiesTheTokens(t *testing.T) {
+	repositories.TokenRepo = &tokenRepoMock{}
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	useKeys(t, "ec:"+writeKey(t, ecKey)+"@"+time.Now().Add(-time.Hour).Format(time.RFC3339), "ed:"+writeKey(t, edKey))
+
+	jwks := authentication.Keys.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	decode := func(value string) []byte {
+		content, err := base64.RawURLEncoding.DecodeString(value)
+		require.NoError(t, err)
+		return content
+	}
+
+	// the keys without activation come first, so ed is older and ec signs
+	ed, ec := jwks.Keys[0], jwks.Keys[1]
+	assert.Equal(t, "OKP", ed.KeyType)
+	assert.Equal(t, "Ed25519", ed.Curve)
+	assert.Equal(t, "EdDSA", ed.Algorithm)
+	assert.Equal(t, "EC", ec.KeyType)
+	assert.Equal(t, "P-256", ec.Curve)
+	assert.Equal(t, "ES256", ec.Algorithm)
+	assert.Equal(t, "sig", ec.Use)
+
+	token, err := authentication.CreateToken(3, "Technician")
+	require.NoError(t, err)
+
+	// a verifier only knows the published key
+	publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(decode(ec.X)), Y: new(big.Int).SetBytes(decode(ec.Y))}
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	})
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+
+	edPublic := ed25519.PublicKey(decode(ed.X))
+	signature, err := authentication.SigningMethodEdDSA.Sign("header.payload", edKey)
+	require.NoError(t, err)
+	assert.Nil(t, authentication.SigningMethodEdDSA.Verify("header.payload", signature, edPublic))
+	assert.NotNil(t, authentication.SigningMethodEdDSA.Verify("header.other", signature, edPublic))
+}
+
+func TestInitKeys_InvalidEntries(t *testing.T) {
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	edPath := writeKey(t, edKey)
+
+	for entries, message := range map[string]string{
+		"missing-path":                   "should be kid:path",
+		"small:" + writeKey(t, smallKey): "at least 2048 bits",
+		"ed:" + edPath + "@tomorrow":     "activation of the signing key ed is invalid",
+		"ed:" + edPath + ",ed:" + edPath: "the kid ed is used by more than one signing key",
+	} {
+		config.JWT_SIGNING_KEYS = strings.Split(entries, ",")
+		err := authentication.InitKeys()
+		assert.NotNil(t, err, entries)
+		if err != nil {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+
+	config.JWT_SIGNING_KEYS = []string{}
+	assert.Nil(t, authentication.InitKeys())
+	assert.Nil(t, authentication.Keys)
+}
@@ -0,0 +1,344 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getAssetRepository      func(id uint64) (*models.Asset, error_utils.MessageErr)
+	getAssetsRepository     func(category string) ([]models.Asset, error_utils.MessageErr)
+	createAssetRepository   func(asset *models.Asset) (*models.Asset, error_utils.MessageErr)
+	updateAssetRepository   func(asset *models.Asset) (*models.Asset, error_utils.MessageErr)
+	deleteAssetRepository   func(id uint64) error_utils.MessageErr
+	getAssetTasksRepository func(id uint64, query models.TaskQuery) ([]models.Task, error_utils.MessageErr)
+	assetStatsRepository    func(assetIDs []uint64) (map[uint64]models.AssetStats, error_utils.MessageErr)
+	handlerCreateAsset      = controllers.CreateAsset
+	handlerGetAssets        = controllers.GetAssets
+	handlerDeleteAsset      = controllers.DeleteAsset
+	handlerGetAssetTasks    = controllers.GetAssetTasks
+)
+
+type assetRepoMock struct{}
+
+func (assetRepo *assetRepoMock) Get(assetID uint64) (*models.Asset, error_utils.MessageErr) {
+	return getAssetRepository(assetID)
+}
+
+func (assetRepo *assetRepoMock) GetAll(category string) ([]models.Asset, error_utils.MessageErr) {
+	return getAssetsRepository(category)
+}
+
+func (assetRepo *assetRepoMock) Create(asset *models.Asset) (*models.Asset, error_utils.MessageErr) {
+	return createAssetRepository(asset)
+}
+
+func (assetRepo *assetRepoMock) Update(asset *models.Asset) (*models.Asset, error_utils.MessageErr) {
+	return updateAssetRepository(asset)
+}
+
+func (assetRepo *assetRepoMock) Delete(assetID uint64) error_utils.MessageErr {
+	return deleteAssetRepository(assetID)
+}
+
+func (assetRepo *assetRepoMock) Tasks(assetID uint64, query models.TaskQuery) ([]models.Task, error_utils.MessageErr) {
+	return getAssetTasksRepository(assetID, query)
+}
+
+func (assetRepo *assetRepoMock) Stats(assetIDs []uint64) (map[uint64]models.AssetStats, error_utils.MessageErr) {
+	return assetStatsRepository(assetIDs)
+}
+
+func (assetRepo *assetRepoMock) Init() {}
+
+func TestCreateAsset_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.AssetRepo = &assetRepoMock{}
+
+	createAssetRepository = func(asset *models.Asset) (*models.Asset, error_utils.MessageErr) {
+		asset.ID = 1
+		return asset, nil
+	}
+
+	jsonBody := `{"name": "Boiler", "serialNumber": " BX-2041 ", "location": "Basement", "category": "HVAC"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/assets", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/assets", handlerCreateAsset)
+	r.ServeHTTP(rr, req)
+
+	var asset models.Asset
+	err := json.Unmarshal(rr.Body.Bytes(), &asset)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "BX-2041", asset.SerialNumber)
+	assert.Equal(t, uint64(2), asset.CreatedBy)
+}
+
+func TestCreateAsset_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.AssetRepo = &assetRepoMock{}
+
+	jsonBody := `{"name": "Boiler", "serialNumber": "BX-2041"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/assets", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/assets", handlerCreateAsset)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "The user doesn't have the right permission to create an asset", apiErr.Message())
+}
+
+func TestGetAssets_WithStats(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.AssetRepo = &assetRepoMock{}
+
+	serviced := time.Date(2022, 2, 1, 10, 0, 0, 0, time.UTC)
+	getAssetsRepository = func(category string) ([]models.Asset, error_utils.MessageErr) {
+		return []models.Asset{
+			{ID: 1, Name: "Boiler", SerialNumber: "BX-2041", Category: category},
+			{ID: 2, Name: "Chiller", SerialNumber: "CH-7", Category: category},
+		}, nil
+	}
+
+	assetStatsRepository = func(assetIDs []uint64) (map[uint64]models.AssetStats, error_utils.MessageErr) {
+		return map[uint64]models.AssetStats{1: {AssetID: 1, LastServicedAt: &serviced, OpenTasks: 2}}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/assets?category=HVAC", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/assets", handlerGetAssets)
+	r.ServeHTTP(rr, req)
+
+	var assets []models.Asset
+	err := json.Unmarshal(rr.Body.Bytes(), &assets)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, assets, 2)
+	assert.Equal(t, "HVAC", assets[0].Category)
+	assert.Equal(t, int64(2), assets[0].OpenTasks)
+	assert.True(t, serviced.Equal(*assets[0].LastServicedAt))
+	assert.Equal(t, int64(0), assets[1].OpenTasks)
+	assert.Nil(t, assets[1].LastServicedAt)
+}
+
+func TestDeleteAsset_InUse(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.AssetRepo = &assetRepoMock{}
+
+	deleteAssetRepository = func(id uint64) error_utils.MessageErr {
+		return error_utils.NewConflictError("the asset is linked to 3 tasks, it can't be deleted")
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodDelete, "/assets/1", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.DELETE("/assets/:id", handlerDeleteAsset)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, apiErr.Status())
+}
+
+func TestGetAssetTasks_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.AssetRepo = &assetRepoMock{}
+
+	getAssetRepository = func(id uint64) (*models.Asset, error_utils.MessageErr) {
+		return &models.Asset{ID: id, Name: "Boiler", SerialNumber: "BX-2041"}, nil
+	}
+
+	getAssetTasksRepository = func(id uint64, query models.TaskQuery) ([]models.Task, error_utils.MessageErr) {
+		assert.Equal(t, uint64(0), query.UserID)
+		return []models.Task{
+			{ID: 2, Summary: "Replace the pump", Status: models.TaskStatusOpen, AssetID: &id},
+			{ID: 1, Summary: "Yearly inspection", Status: models.TaskStatusDone, AssetID: &id},
+		}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/assets/1/tasks", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/assets/:id/tasks", handlerGetAssetTasks)
+	r.ServeHTTP(rr, req)
+
+	var tasks []models.Task
+	err := json.Unmarshal(rr.Body.Bytes(), &tasks)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, uint64(1), *tasks[1].AssetID)
+}
+
+func TestGetAssetTasks_TechnicianOnlyOwnTasks(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.AssetRepo = &assetRepoMock{}
+
+	getAssetRepository = func(id uint64) (*models.Asset, error_utils.MessageErr) {
+		return &models.Asset{ID: id, Name: "Boiler", SerialNumber: "BX-2041"}, nil
+	}
+
+	// the asset also has a task of the technician 4, only the repository filter keeps it out
+	otherTechnician := uint64(4)
+	getAssetTasksRepository = func(id uint64, query models.TaskQuery) ([]models.Task, error_utils.MessageErr) {
+		assert.Equal(t, uint64(3), query.UserID)
+		tasks := []models.Task{
+			{ID: 2, Summary: "Replace the pump", Status: models.TaskStatusOpen, AssetID: &id, UserID: 1, AssigneeID: &otherTechnician},
+			{ID: 1, Summary: "Yearly inspection", Status: models.TaskStatusDone, AssetID: &id, UserID: 3},
+		}
+
+		visible := []models.Task{}
+		for _, task := range tasks {
+			if task.IsAssignedTo(query.UserID) {
+				visible = append(visible, task)
+			}
+		}
+		return visible, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/assets/1/tasks", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(3, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/assets/:id/tasks", handlerGetAssetTasks)
+	r.ServeHTTP(rr, req)
+
+	var tasks []models.Task
+	err := json.Unmarshal(rr.Body.Bytes(), &tasks)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, uint64(1), tasks[0].ID)
+}
+
+func TestGetAssetTasks_ScopedToSites(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.AssetRepo = &assetRepoMock{}
+
+	getUserSitesRepository = func(userID uint64) ([]uint64, error_utils.MessageErr) {
+		return []uint64{5}, nil
+	}
+	defer func() { getUserSitesRepository = nil }()
+
+	getAssetRepository = func(id uint64) (*models.Asset, error_utils.MessageErr) {
+		return &models.Asset{ID: id, Name: "Boiler", SerialNumber: "BX-2041"}, nil
+	}
+
+	getAssetTasksRepository = func(id uint64, query models.TaskQuery) ([]models.Task, error_utils.MessageErr) {
+		assert.Equal(t, uint64(0), query.UserID)
+		assert.Equal(t, []uint64{5}, query.SiteIDs)
+		return []models.Task{}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/assets/1/tasks", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/assets/:id/tasks", handlerGetAssetTasks)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestCreateTask_UnknownAsset(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.AssetRepo = &assetRepoMock{}
+
+	getAssetRepository = func(id uint64) (*models.Asset, error_utils.MessageErr) {
+		return nil, error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	jsonBody := `{"summary": "Replace the pump", "assetId": 9}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks", handlerCreateTask)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the asset 9 doesn't exist", apiErr.Message())
+}
@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/storage"
+	"api/app/utils/error_utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getAttachmentRepository    func(id uint64) (*models.Attachment, error_utils.MessageErr)
+	getAttachmentsRepository   func(taskID uint64) ([]models.Attachment, error_utils.MessageErr)
+	createAttachmentRepository func(attachment *models.Attachment) (*models.Attachment, error_utils.MessageErr)
+	deleteAttachmentRepository func(id uint64) error_utils.MessageErr
+	handlerUploadAttachment    = controllers.UploadAttachment
+	handlerDownloadAttachment  = controllers.DownloadAttachment
+	handlerDeleteAttachment    = controllers.DeleteAttachment
+)
+
+// pngHeader is enough for the content sniffing to recognize a PNG image.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type attachmentRepoMock struct{}
+
+func (attachmentRepo *attachmentRepoMock) Get(attachmentID uint64) (*models.Attachment, error_utils.MessageErr) {
+	return getAttachmentRepository(attachmentID)
+}
+
+func (attachmentRepo *attachmentRepoMock) GetAllByTaskID(taskID uint64) ([]models.Attachment, error_utils.MessageErr) {
+	return getAttachmentsRepository(taskID)
+}
+
+func (attachmentRepo *attachmentRepoMock) Create(attachment *models.Attachment) (*models.Attachment, error_utils.MessageErr) {
+	return createAttachmentRepository(attachment)
+}
+
+func (attachmentRepo *attachmentRepoMock) Delete(attachmentID uint64) error_utils.MessageErr {
+	return deleteAttachmentRepository(attachmentID)
+}
+
+func (attachmentRepo *attachmentRepoMock) Init() {}
+
+func setupAttachmentTest(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	config.ATTACHMENT_MAX_SIZE = 1024
+	config.ATTACHMENT_ALLOWED_TYPES = []string{"image/png", "application/pdf"}
+	storage.Files = storage.NewLocalStorage(t.TempDir())
+	repositories.TaskRepo = &taskRepoMock{}
+	repositories.AttachmentRepo = &attachmentRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+}
+
+func newUploadRequest(t *testing.T, fileName string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", fileName)
+	assert.Nil(t, err)
+	part.Write(content)
+	writer.Close()
+
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/attachments", body)
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	req.Header = map[string][]string{
+		"Content-Type":  {writer.FormDataContentType()},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	return req
+}
+
+func TestUploadAttachment_Success(t *testing.T) {
+	setupAttachmentTest(t)
+
+	var stored *models.Attachment
+	createAttachmentRepository = func(attachment *models.Attachment) (*models.Attachment, error_utils.MessageErr) {
+		attachment.ID = 1
+		stored = attachment
+		return attachment, nil
+	}
+
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 100)...)
+	req := newUploadRequest(t, `C:\photos\pump "old".png`, content)
+
+	rr := httptest.NewRecorder()
+	r := gin.Default()
+	r.POST("/tasks/:id/attachments", handlerUploadAttachment)
+	r.ServeHTTP(rr, req)
+
+	var attachment models.Attachment
+	err := json.Unmarshal(rr.Body.Bytes(), &attachment)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "pump old.png", attachment.FileName)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, int64(len(content)), attachment.Size)
+	assert.True(t, strings.HasPrefix(stored.StorageKey, "tasks/1/"))
+
+	file, errGet := storage.Files.Get(context.Background(), stored.StorageKey)
+	assert.Nil(t, errGet)
+	file.Close()
+}
+
+func TestUploadAttachment_UnsupportedType(t *testing.T) {
+	setupAttachmentTest(t)
+
+	// the declared name doesn't matter, the content is sniffed
+	req := newUploadRequest(t, "photo.png", []byte("#!/bin/sh\necho this is not an image\n"))
+
+	rr := httptest.NewRecorder()
+	r := gin.Default()
+	r.POST("/tasks/:id/attachments", handlerUploadAttachment)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusUnsupportedMediaType, apiErr.Status())
+	assert.Equal(t, "the file type text/plain is not allowed, use one of image/png, application/pdf", apiErr.Message())
+}
+
+func TestUploadAttachment_TooLarge(t *testing.T) {
+	setupAttachmentTest(t)
+
+	createAttachmentRepository = func(attachment *models.Attachment) (*models.Attachment, error_utils.MessageErr) {
+		t.Error("a file over the limit should not be recorded")
+		return attachment, nil
+	}
+
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 2048)...)
+	req := newUploadRequest(t, "photo.png", content)
+
+	rr := httptest.NewRecorder()
+	r := gin.Default()
+	r.POST("/tasks/:id/attachments", handlerUploadAttachment)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, apiErr.Status())
+	assert.Equal(t, "the file is too large, the maximum size is 1024 bytes", apiErr.Message())
+}
+
+func TestUploadAttachment_DifferentUser(t *testing.T) {
+	setupAttachmentTest(t)
+
+	req := newUploadRequest(t, "photo.png", pngHeader)
+	req.Header.Set("Authorization", newToken(4, "Technician"))
+
+	rr := httptest.NewRecorder()
+	r := gin.Default()
+	r.POST("/tasks/:id/attachments", handlerUploadAttachment)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+}
+
+func TestDownloadAttachment_Success(t *testing.T) {
+	setupAttachmentTest(t)
+
+	content := append(append([]byte{}, pngHeader...), []byte("pixels")...)
+	_, err := storage.Files.Put(context.Background(), "tasks/1/abc", bytes.NewReader(content), "image/png")
+	assert.Nil(t, err)
+
+	getAttachmentRepository = func(id uint64) (*models.Attachment, error_utils.MessageErr) {
+		return &models.Attachment{ID: id, TaskID: 1, UploaderID: 1, FileName: "pump.png", ContentType: "image/png", Size: int64(len(content)), StorageKey: "tasks/1/abc"}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/tasks/1/attachments/1", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/tasks/:id/attachments/:attachmentId", handlerDownloadAttachment)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=pump.png`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, content, rr.Body.Bytes())
+}
+
+func TestDeleteAttachment_NotTheUploader(t *testing.T) {
+	setupAttachmentTest(t)
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		assignee := uint64(4)
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1, AssigneeID: &assignee}, nil
+	}
+
+	getAttachmentRepository = func(id uint64) (*models.Attachment, error_utils.MessageErr) {
+		return &models.Attachment{ID: id, TaskID: 1, UploaderID: 1, StorageKey: "tasks/1/abc"}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodDelete, "/tasks/1/attachments/1", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(4, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.DELETE("/tasks/:id/attachments/:attachmentId", handlerDeleteAttachment)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "Only the uploader can delete a file", apiErr.Message())
+}
+
+func TestDeleteAttachment_Success(t *testing.T) {
+	setupAttachmentTest(t)
+
+	_, err := storage.Files.Put(context.Background(), "tasks/1/abc", bytes.NewReader(pngHeader), "image/png")
+	assert.Nil(t, err)
+
+	getAttachmentRepository = func(id uint64) (*models.Attachment, error_utils.MessageErr) {
+		return &models.Attachment{ID: id, TaskID: 1, UploaderID: 1, StorageKey: "tasks/1/abc"}, nil
+	}
+
+	deleteAttachmentRepository = func(id uint64) error_utils.MessageErr {
+		return nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodDelete, "/tasks/1/attachments/1", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.DELETE("/tasks/:id/attachments/:attachmentId", handlerDeleteAttachment)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	_, errGet := storage.Files.Get(context.Background(), "tasks/1/abc")
+	assert.Equal(t, storage.ErrObjectNotFound, errGet)
+}
+
+func TestUploadAttachment_OutOfSites(t *testing.T) {
+	setupAttachmentTest(t)
+	scopeToAnotherSite(t)
+
+	req := newUploadRequest(t, "photo.png", pngHeader)
+	req.Header.Set("Authorization", newToken(2, "Manager"))
+
+	rr := httptest.NewRecorder()
+	r := gin.Default()
+	r.POST("/tasks/:id/attachments", handlerUploadAttachment)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+}
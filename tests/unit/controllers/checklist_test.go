@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getChecklistItemRepository      func(id uint64) (*models.ChecklistItem, error_utils.MessageErr)
+	getChecklistRepository          func(taskID uint64) ([]models.ChecklistItem, error_utils.MessageErr)
+	createChecklistItemRepository   func(item *models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr)
+	updateChecklistItemRepository   func(item *models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr)
+	deleteChecklistItemRepository   func(id uint64) error_utils.MessageErr
+	countPendingMandatoryRepository func(taskID uint64) (int64, error_utils.MessageErr)
+	handlerGetChecklist             = controllers.GetChecklist
+	handlerCreateChecklistItem      = controllers.CreateChecklistItem
+	handlerUpdateChecklistItem      = controllers.UpdateChecklistItem
+	handlerDeleteChecklistItem      = controllers.DeleteChecklistItem
+)
+
+// the task handlers read the checklist too, so the mock is installed for the whole package
+func init() {
+	repositories.ChecklistRepo = &checklistRepoMock{}
+}
+
+type checklistRepoMock struct{}
+
+func (checklistRepo *checklistRepoMock) Get(itemID uint64) (*models.ChecklistItem, error_utils.MessageErr) {
+	return getChecklistItemRepository(itemID)
+}
+
+func (checklistRepo *checklistRepoMock) GetAllByTaskID(taskID uint64) ([]models.ChecklistItem, error_utils.MessageErr) {
+	if getChecklistRepository == nil {
+		return []models.ChecklistItem{}, nil
+	}
+	return getChecklistRepository(taskID)
+}
+
+func (checklistRepo *checklistRepoMock) Create(item *models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr) {
+	return createChecklistItemRepository(item)
+}
+
+func (checklistRepo *checklistRepoMock) Update(item *models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr) {
+	return updateChecklistItemRepository(item)
+}
+
+func (checklistRepo *checklistRepoMock) Delete(itemID uint64) error_utils.MessageErr {
+	return deleteChecklistItemRepository(itemID)
+}
+
+func (checklistRepo *checklistRepoMock) CountPendingMandatory(taskID uint64) (int64, error_utils.MessageErr) {
+	if countPendingMandatoryRepository == nil {
+		return 0, nil
+	}
+	return countPendingMandatoryRepository(taskID)
+}
+
+func (checklistRepo *checklistRepoMock) Init() {}
+
+func TestCreateChecklistItem_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	createChecklistItemRepository = func(item *models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr) {
+		item.ID = 1
+		item.Position = 1
+		return item, nil
+	}
+
+	jsonBody := `{"title": "  Turn off the power  ", "mandatory": true}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/checklist", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/checklist", handlerCreateChecklistItem)
+	r.ServeHTTP(rr, req)
+
+	var item models.ChecklistItem
+	err := json.Unmarshal(rr.Body.Bytes(), &item)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, uint64(1), item.TaskID)
+	assert.Equal(t, "Turn off the power", item.Title)
+	assert.True(t, item.Mandatory)
+	assert.False(t, item.Done)
+	assert.Nil(t, item.CompletedAt)
+}
+
+func TestCreateChecklistItem_EmptyTitle(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	jsonBody := `{"title": "   "}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/checklist", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/checklist", handlerCreateChecklistItem)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the field title is required can't be empty", apiErr.Message())
+}
+
+func TestCreateChecklistItem_DifferentUser(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	jsonBody := `{"title": "Turn off the power"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/checklist", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(3, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/checklist", handlerCreateChecklistItem)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "Not possible to change the checklist of a task that does not belong to you", apiErr.Message())
+}
+
+func TestUpdateChecklistItem_Check(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	getChecklistItemRepository = func(id uint64) (*models.ChecklistItem, error_utils.MessageErr) {
+		return &models.ChecklistItem{ID: 5, TaskID: 1, Title: "Turn off the power", Position: 1, Mandatory: true}, nil
+	}
+
+	updateChecklistItemRepository = func(item *models.ChecklistItem) (*models.ChecklistItem, error_utils.MessageErr) {
+		return item, nil
+	}
+
+	jsonBody := `{"done": true}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPatch, "/tasks/1/checklist/5", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PATCH("/tasks/:id/checklist/:itemId", handlerUpdateChecklistItem)
+	r.ServeHTTP(rr, req)
+
+	var item models.ChecklistItem
+	err := json.Unmarshal(rr.Body.Bytes(), &item)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, item.Done)
+	assert.Equal(t, "Turn off the power", item.Title)
+	assert.NotNil(t, item.CompletedAt)
+	assert.Equal(t, uint64(1), *item.CompletedBy)
+}
+
+func TestUpdateChecklistItem_OtherTask(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	getChecklistItemRepository = func(id uint64) (*models.ChecklistItem, error_utils.MessageErr) {
+		return &models.ChecklistItem{ID: 5, TaskID: 2, Title: "Turn off the power"}, nil
+	}
+
+	jsonBody := `{"done": true}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPatch, "/tasks/1/checklist/5", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PATCH("/tasks/:id/checklist/:itemId", handlerUpdateChecklistItem)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status())
+}
+
+func TestDeleteChecklistItem_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	getChecklistItemRepository = func(id uint64) (*models.ChecklistItem, error_utils.MessageErr) {
+		return &models.ChecklistItem{ID: 5, TaskID: 1, Title: "Turn off the power"}, nil
+	}
+
+	deleteChecklistItemRepository = func(id uint64) error_utils.MessageErr {
+		return nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodDelete, "/tasks/1/checklist/5", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.DELETE("/tasks/:id/checklist/:itemId", handlerDeleteChecklistItem)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestGetTask_ChecklistProgress(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1, Version: 1}, nil
+	}
+
+	getChecklistRepository = func(taskID uint64) ([]models.ChecklistItem, error_utils.MessageErr) {
+		return []models.ChecklistItem{
+			{ID: 1, TaskID: 1, Title: "Turn off the power", Position: 1, Done: true},
+			{ID: 2, TaskID: 1, Title: "Replace the filter", Position: 2, Done: true},
+			{ID: 3, TaskID: 1, Title: "Test the unit", Position: 3},
+			{ID: 4, TaskID: 1, Title: "Clean the area", Position: 4},
+		}, nil
+	}
+	defer func() { getChecklistRepository = nil }()
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/tasks/1", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/tasks/:id", handlerGetTask)
+	r.ServeHTTP(rr, req)
+
+	var task models.Task
+	err := json.Unmarshal(rr.Body.Bytes(), &task)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, task.Checklist, 4)
+	assert.Equal(t, 50, *task.Progress)
+}
+
+func TestUpdateTaskStatus_PendingMandatoryItems(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", Status: models.TaskStatusInProgress, UserID: 1}, nil
+	}
+
+	countPendingMandatoryRepository = func(taskID uint64) (int64, error_utils.MessageErr) {
+		return 2, nil
+	}
+	defer func() { countPendingMandatoryRepository = nil }()
+
+	jsonBody := `{"status": "done"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPatch, "/tasks/1/status", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PATCH("/tasks/:id/status", handlerUpdateTaskStatus)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, apiErr.Status())
+	assert.Equal(t, "the task still has 2 mandatory checklist items unchecked", apiErr.Message())
+}
+
+func TestCreateChecklistItem_OutOfSites(t *testing.T) {
+	repositories.TaskRepo = &taskRepoMock{}
+	scopeToAnotherSite(t)
+
+	rr := userRequest(t, http.MethodPost, "/tasks/:id/checklist", "/tasks/1/checklist", `{"title": "Turn off the power"}`, newToken(2, "Manager"), handlerCreateChecklistItem)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
@@ -0,0 +1,367 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getCommentRepository    func(id uint64) (*models.Comment, error_utils.MessageErr)
+	getCommentsRepository   func(taskID uint64) ([]models.Comment, error_utils.MessageErr)
+	createCommentRepository func(comment *models.Comment) (*models.Comment, error_utils.MessageErr)
+	updateCommentRepository func(comment *models.Comment) (*models.Comment, error_utils.MessageErr)
+	deleteCommentRepository func(id uint64) error_utils.MessageErr
+	handlerCreateComment    = controllers.CreateComment
+	handlerGetComments      = controllers.GetComments
+	handlerUpdateComment    = controllers.UpdateComment
+	handlerDeleteComment    = controllers.DeleteComment
+)
+
+type commentRepoMock struct{}
+
+func (commentRepo *commentRepoMock) Get(commentID uint64) (*models.Comment, error_utils.MessageErr) {
+	return getCommentRepository(commentID)
+}
+
+func (commentRepo *commentRepoMock) GetAllByTaskID(taskID uint64) ([]models.Comment, error_utils.MessageErr) {
+	return getCommentsRepository(taskID)
+}
+
+func (commentRepo *commentRepoMock) Create(comment *models.Comment) (*models.Comment, error_utils.MessageErr) {
+	return createCommentRepository(comment)
+}
+
+func (commentRepo *commentRepoMock) Update(comment *models.Comment) (*models.Comment, error_utils.MessageErr) {
+	return updateCommentRepository(comment)
+}
+
+func (commentRepo *commentRepoMock) Delete(commentID uint64) error_utils.MessageErr {
+	return deleteCommentRepository(commentID)
+}
+
+func (commentRepo *commentRepoMock) Init() {}
+
+func TestCreateComment_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+	repositories.CommentRepo = &commentRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	createCommentRepository = func(comment *models.Comment) (*models.Comment, error_utils.MessageErr) {
+		comment.ID = 1
+		return comment, nil
+	}
+
+	jsonBody := `{"body": "  The spare part is on its way  "}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/comments", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/comments", handlerCreateComment)
+	r.ServeHTTP(rr, req)
+
+	var comment models.Comment
+	err := json.Unmarshal(rr.Body.Bytes(), &comment)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, uint64(1), comment.TaskID)
+	assert.Equal(t, uint64(1), comment.AuthorID)
+	assert.Equal(t, "The spare part is on its way", comment.Body)
+}
+
+func TestCreateComment_ManagerOnAnyTask(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+	repositories.CommentRepo = &commentRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	createCommentRepository = func(comment *models.Comment) (*models.Comment, error_utils.MessageErr) {
+		comment.ID = 1
+		return comment, nil
+	}
+
+	jsonBody := `{"body": "Please check the filter too"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/comments", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/comments", handlerCreateComment)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
+
+func TestCreateComment_DifferentUser(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+	repositories.CommentRepo = &commentRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	jsonBody := `{"body": "Not my task"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/comments", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(4, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/comments", handlerCreateComment)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "Not possible to comment a task that does not belong to you", apiErr.Message())
+}
+
+func TestCreateComment_WithoutBody(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+	repositories.CommentRepo = &commentRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	jsonBody := `{"body": "   "}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/comments", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/comments", handlerCreateComment)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the field body is required can't be empty", apiErr.Message())
+}
+
+func TestGetComments_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+	repositories.CommentRepo = &commentRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	getCommentsRepository = func(taskID uint64) ([]models.Comment, error_utils.MessageErr) {
+		return []models.Comment{
+			{ID: 1, TaskID: taskID, AuthorID: 1, Body: "First", CreatedAt: tm},
+			{ID: 2, TaskID: taskID, AuthorID: 2, Body: "Second", CreatedAt: tm},
+		}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/tasks/1/comments", nil)
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/tasks/:id/comments", handlerGetComments)
+	r.ServeHTTP(rr, req)
+
+	var comments []models.Comment
+	err := json.Unmarshal(rr.Body.Bytes(), &comments)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 2, len(comments))
+}
+
+func TestUpdateComment_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.CommentRepo = &commentRepoMock{}
+
+	getCommentRepository = func(id uint64) (*models.Comment, error_utils.MessageErr) {
+		return &models.Comment{ID: id, TaskID: 1, AuthorID: 1, Body: "First"}, nil
+	}
+
+	updateCommentRepository = func(comment *models.Comment) (*models.Comment, error_utils.MessageErr) {
+		return comment, nil
+	}
+
+	jsonBody := `{"body": "First, edited"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPut, "/tasks/1/comments/3", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PUT("/tasks/:id/comments/:commentId", handlerUpdateComment)
+	r.ServeHTTP(rr, req)
+
+	var comment models.Comment
+	err := json.Unmarshal(rr.Body.Bytes(), &comment)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, uint64(3), comment.ID)
+	assert.Equal(t, "First, edited", comment.Body)
+}
+
+func TestUpdateComment_NotTheAuthor(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.CommentRepo = &commentRepoMock{}
+
+	getCommentRepository = func(id uint64) (*models.Comment, error_utils.MessageErr) {
+		return &models.Comment{ID: id, TaskID: 1, AuthorID: 1, Body: "First"}, nil
+	}
+
+	jsonBody := `{"body": "Edited by somebody else"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPut, "/tasks/1/comments/3", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PUT("/tasks/:id/comments/:commentId", handlerUpdateComment)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "Only the author can change a comment", apiErr.Message())
+}
+
+func TestDeleteComment_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.CommentRepo = &commentRepoMock{}
+
+	getCommentRepository = func(id uint64) (*models.Comment, error_utils.MessageErr) {
+		return &models.Comment{ID: id, TaskID: 1, AuthorID: 1, Body: "First"}, nil
+	}
+
+	deleteCommentRepository = func(id uint64) error_utils.MessageErr {
+		return nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodDelete, "/tasks/1/comments/3", nil)
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.DELETE("/tasks/:id/comments/:commentId", handlerDeleteComment)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestDeleteComment_FromAnotherTask(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.CommentRepo = &commentRepoMock{}
+
+	getCommentRepository = func(id uint64) (*models.Comment, error_utils.MessageErr) {
+		return &models.Comment{ID: id, TaskID: 2, AuthorID: 1, Body: "First"}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodDelete, "/tasks/1/comments/3", nil)
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.DELETE("/tasks/:id/comments/:commentId", handlerDeleteComment)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status())
+}
+
+func TestCreateComment_OutOfSites(t *testing.T) {
+	repositories.TaskRepo = &taskRepoMock{}
+	repositories.CommentRepo = &commentRepoMock{}
+	scopeToAnotherSite(t)
+
+	rr := userRequest(t, http.MethodPost, "/tasks/:id/comments", "/tasks/1/comments", `{"body": "Please check the filter too"}`, newToken(2, "Manager"), handlerCreateComment)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = userRequest(t, http.MethodGet, "/tasks/:id/comments", "/tasks/1/comments", "", newToken(2, "Manager"), handlerGetComments)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
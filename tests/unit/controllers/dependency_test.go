@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getDependenciesRepository  func(taskID uint64) ([]models.TaskDependency, error_utils.MessageErr)
+	createDependencyRepository func(dependency *models.TaskDependency) (*models.TaskDependency, error_utils.MessageErr)
+	deleteDependencyRepository func(taskID uint64, blockedByID uint64) error_utils.MessageErr
+	blockedTaskIDsRepository   func(taskIDs []uint64) (map[uint64]bool, error_utils.MessageErr)
+	unblockedRepository        func(blockerID uint64) ([]uint64, error_utils.MessageErr)
+	handlerLinkTask            = controllers.LinkTask
+	handlerUnlinkTask          = controllers.UnlinkTask
+)
+
+// the task handlers flag the blocked tasks, so the mock is installed for the whole package
+func init() {
+	repositories.DependencyRepo = &dependencyRepoMock{}
+}
+
+type dependencyRepoMock struct{}
+
+func (dependencyRepo *dependencyRepoMock) GetAllByTaskID(taskID uint64) ([]models.TaskDependency, error_utils.MessageErr) {
+	return getDependenciesRepository(taskID)
+}
+
+func (dependencyRepo *dependencyRepoMock) Create(dependency *models.TaskDependency) (*models.TaskDependency, error_utils.MessageErr) {
+	return createDependencyRepository(dependency)
+}
+
+func (dependencyRepo *dependencyRepoMock) Delete(taskID uint64, blockedByID uint64) error_utils.MessageErr {
+	return deleteDependencyRepository(taskID, blockedByID)
+}
+
+func (dependencyRepo *dependencyRepoMock) BlockedTaskIDs(taskIDs []uint64) (map[uint64]bool, error_utils.MessageErr) {
+	if blockedTaskIDsRepository == nil {
+		return map[uint64]bool{}, nil
+	}
+	return blockedTaskIDsRepository(taskIDs)
+}
+
+func (dependencyRepo *dependencyRepoMock) Unblocked(blockerID uint64) ([]uint64, error_utils.MessageErr) {
+	if unblockedRepository == nil {
+		return []uint64{}, nil
+	}
+	return unblockedRepository(blockerID)
+}
+
+func (dependencyRepo *dependencyRepoMock) Init() {}
+
+func TestLinkTask_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	createDependencyRepository = func(dependency *models.TaskDependency) (*models.TaskDependency, error_utils.MessageErr) {
+		return dependency, nil
+	}
+
+	jsonBody := `{"blockedById": 2}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/dependencies", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/dependencies", handlerLinkTask)
+	r.ServeHTTP(rr, req)
+
+	var dependency models.TaskDependency
+	err := json.Unmarshal(rr.Body.Bytes(), &dependency)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, uint64(1), dependency.TaskID)
+	assert.Equal(t, uint64(2), dependency.BlockedByID)
+	assert.Equal(t, uint64(1), dependency.CreatedBy)
+}
+
+func TestLinkTask_Itself(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	jsonBody := `{"blockedById": 1}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/dependencies", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/dependencies", handlerLinkTask)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "a task can't be blocked by itself", apiErr.Message())
+}
+
+func TestLinkTask_Cycle(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 2}, nil
+	}
+
+	createDependencyRepository = func(dependency *models.TaskDependency) (*models.TaskDependency, error_utils.MessageErr) {
+		return nil, error_utils.NewConflictError("the task can't be blocked by a task that already depends on it")
+	}
+
+	jsonBody := `{"blockedById": 2}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/dependencies", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/dependencies", handlerLinkTask)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, apiErr.Status())
+}
+
+func TestLinkTask_DifferentUser(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	jsonBody := `{"blockedById": 2}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/dependencies", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(3, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/dependencies", handlerLinkTask)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "Not possible to change the dependencies of a task that does not belong to you", apiErr.Message())
+}
+
+func TestUnlinkTask_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	var unlinked []uint64
+	deleteDependencyRepository = func(taskID uint64, blockedByID uint64) error_utils.MessageErr {
+		unlinked = []uint64{taskID, blockedByID}
+		return nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodDelete, "/tasks/1/dependencies/2", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.DELETE("/tasks/:id/dependencies/:blockerId", handlerUnlinkTask)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, []uint64{1, 2}, unlinked)
+}
+
+func TestGetTask_Blocked(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 1, Summary: "This is a summary test", UserID: 1, Version: 1}, nil
+	}
+
+	blockedTaskIDsRepository = func(taskIDs []uint64) (map[uint64]bool, error_utils.MessageErr) {
+		return map[uint64]bool{1: true}, nil
+	}
+	defer func() { blockedTaskIDsRepository = nil }()
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/tasks/1", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/tasks/:id", handlerGetTask)
+	r.ServeHTTP(rr, req)
+
+	var task models.Task
+	err := json.Unmarshal(rr.Body.Bytes(), &task)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, task.Blocked)
+}
+
+func TestUpdateTaskStatus_PublishesUnblocked(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: 2, Summary: "Order pump part", Status: models.TaskStatusInProgress, UserID: 1}, nil
+	}
+
+	updateTaskStatusRepo = func(task *models.Task) (*models.Task, error_utils.MessageErr) {
+		return task, nil
+	}
+
+	var blockerID uint64
+	unblockedRepository = func(id uint64) ([]uint64, error_utils.MessageErr) {
+		blockerID = id
+		return []uint64{1}, nil
+	}
+	defer func() { unblockedRepository = nil }()
+
+	jsonBody := `{"status": "done"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPatch, "/tasks/2/status", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PATCH("/tasks/:id/status", handlerUpdateTaskStatus)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, uint64(2), blockerID)
+}
+
+func TestLinkTask_OutOfSites(t *testing.T) {
+	repositories.TaskRepo = &taskRepoMock{}
+	scopeToAnotherSite(t)
+
+	rr := userRequest(t, http.MethodPost, "/tasks/:id/dependencies", "/tasks/1/dependencies", `{"blockedById": 2}`, newToken(2, "Manager"), handlerLinkTask)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
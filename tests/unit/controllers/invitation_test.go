@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/mailer"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	createInvitationRepository func(invitation *models.Invitation, deliver func(invitation *models.Invitation) error) (*models.Invitation, error_utils.MessageErr)
+	acceptInvitationRepository func(request models.InvitationAcceptRequest, now time.Time) (*models.User, error_utils.MessageErr)
+	handlerCreateInvitation    = controllers.CreateInvitation
+	handlerAcceptInvitation    = controllers.AcceptInvitation
+)
+
+type invitationRepoMock struct{}
+
+func (invitationRepo *invitationRepoMock) Get(invitationID uint64) (*models.Invitation, error_utils.MessageErr) {
+	return nil, error_utils.NewNotFoundError("record not found")
+}
+
+func (invitationRepo *invitationRepoMock) GetAll() ([]models.Invitation, error_utils.MessageErr) {
+	return []models.Invitation{}, nil
+}
+
+func (invitationRepo *invitationRepoMock) Create(invitation *models.Invitation, deliver func(invitation *models.Invitation) error) (*models.Invitation, error_utils.MessageErr) {
+	return createInvitationRepository(invitation, deliver)
+}
+
+func (invitationRepo *invitationRepoMock) Delete(invitationID uint64) error_utils.MessageErr {
+	return nil
+}
+
+func (invitationRepo *invitationRepoMock) Accept(request models.InvitationAcceptRequest, now time.Time) (*models.User, error_utils.MessageErr) {
+	return acceptInvitationRepository(request, now)
+}
+
+func (invitationRepo *invitationRepoMock) Init() {}
+
+// mailerMock keeps the messages instead of sending them
+type mailerMock struct {
+	messages []mailer.Message
+	err      error
+}
+
+func (mailerMock *mailerMock) Send(ctx context.Context, message mailer.Message) error {
+	if mailerMock.err != nil {
+		return mailerMock.err
+	}
+	mailerMock.messages = append(mailerMock.messages, message)
+	return nil
+}
+
+func TestCreateInvitation_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	config.INVITATION_URL = "https://app.test/accept"
+	repositories.InvitationRepo = &invitationRepoMock{}
+	mail := &mailerMock{}
+	mailer.Mail = mail
+
+	var saved *models.Invitation
+	createInvitationRepository = func(invitation *models.Invitation, deliver func(invitation *models.Invitation) error) (*models.Invitation, error_utils.MessageErr) {
+		invitation.ID = 1
+		saved = invitation
+		if err := deliver(invitation); err != nil {
+			return nil, error_utils.NewInternalServerError(err.Error())
+		}
+		return invitation, nil
+	}
+
+	jsonBody := `{"email": " new@test.com ", "type": "Manager"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/invitations", handlerCreateInvitation)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.NotContains(t, rr.Body.String(), saved.TokenHash)
+	assert.Equal(t, uint64(2), saved.InvitedBy)
+	assert.Equal(t, "new@test.com", saved.Email)
+	assert.WithinDuration(t, time.Now().Add(config.INVITATION_TTL), saved.ExpiresAt, time.Minute)
+
+	// the email carries the token whose hash was stored
+	assert.Len(t, mail.messages, 1)
+	assert.Equal(t, "new@test.com", mail.messages[0].To)
+	link := mail.messages[0].Body[strings.Index(mail.messages[0].Body, "https://app.test/accept?token="):]
+	token := strings.TrimSpace(strings.SplitN(strings.TrimPrefix(link, "https://app.test/accept?token="), "\n", 2)[0])
+	assert.Equal(t, saved.TokenHash, models.HashInvitationToken(token))
+}
+
+func TestCreateInvitation_AdminNeedsManageUsers(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.InvitationRepo = &invitationRepoMock{}
+	mailer.Mail = &mailerMock{}
+
+	jsonBody := `{"email": "new@test.com", "type": "Admin"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/invitations", handlerCreateInvitation)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "The user doesn't have the right permission to invite an Admin", apiErr.Message())
+}
+
+func TestCreateInvitation_ExpiresTooLate(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	config.INVITATION_MAX_TTL = 720 * time.Hour
+	repositories.InvitationRepo = &invitationRepoMock{}
+	mailer.Mail = &mailerMock{}
+
+	createInvitationRepository = func(invitation *models.Invitation, deliver func(invitation *models.Invitation) error) (*models.Invitation, error_utils.MessageErr) {
+		t.Error("the invitation shouldn't be created")
+		return invitation, nil
+	}
+
+	expiresAt := time.Now().AddDate(10, 0, 0).Format(time.RFC3339)
+	jsonBody := `{"email": "new@test.com", "type": "Manager", "expiresAt": "` + expiresAt + `"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/invitations", handlerCreateInvitation)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the expiresAt date should be at most 720 hours from now", apiErr.Message())
+}
+
+func TestCreateInvitation_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.InvitationRepo = &invitationRepoMock{}
+
+	jsonBody := `{"email": "new@test.com", "type": "Technician"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/invitations", handlerCreateInvitation)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "The user doesn't have the right permission to invite a user", apiErr.Message())
+}
+
+func TestCreateInvitation_MailerError(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.InvitationRepo = &invitationRepoMock{}
+	mailer.Mail = &mailerMock{err: errors.New("connection refused")}
+
+	createInvitationRepository = func(invitation *models.Invitation, deliver func(invitation *models.Invitation) error) (*models.Invitation, error_utils.MessageErr) {
+		if err := deliver(invitation); err != nil {
+			return nil, error_utils.NewInternalServerError("it's not possible to send the invitation: " + err.Error())
+		}
+		return invitation, nil
+	}
+
+	jsonBody := `{"email": "new@test.com", "type": "Technician"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Admin")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/invitations", handlerCreateInvitation)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.Status())
+	assert.Equal(t, "it's not possible to send the invitation: connection refused", apiErr.Message())
+}
+
+func TestAcceptInvitation_Success(t *testing.T) {
+	repositories.InvitationRepo = &invitationRepoMock{}
+
+	acceptInvitationRepository = func(request models.InvitationAcceptRequest, now time.Time) (*models.User, error_utils.MessageErr) {
+		assert.Equal(t, "token", request.Token)
+		assert.NotEqual(t, "123", request.Password)
+		return &models.User{ID: 3, Name: request.Name, Email: "new@test.com", Password: request.Password, Type: "Manager"}, nil
+	}
+
+	jsonBody := `{"token": "token", "name": "New user", "password": "123"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/invitations/accept", bytes.NewBufferString(jsonBody))
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/invitations/accept", handlerAcceptInvitation)
+	r.ServeHTTP(rr, req)
+
+	var user models.User
+	err := json.Unmarshal(rr.Body.Bytes(), &user)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "Manager", user.Type)
+	assert.Empty(t, user.Password)
+}
+
+func TestAcceptInvitation_AlreadyUsed(t *testing.T) {
+	repositories.InvitationRepo = &invitationRepoMock{}
+
+	acceptInvitationRepository = func(request models.InvitationAcceptRequest, now time.Time) (*models.User, error_utils.MessageErr) {
+		return nil, error_utils.NewConflictError("the invitation was already accepted")
+	}
+
+	jsonBody := `{"token": "token", "name": "New user", "password": "123"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/invitations/accept", bytes.NewBufferString(jsonBody))
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/invitations/accept", handlerAcceptInvitation)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, apiErr.Status())
+	assert.Equal(t, "the invitation was already accepted", apiErr.Message())
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/controllers"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var handlerGetJWKS = controllers.GetJWKS
+
+func getJWKS(t *testing.T) (*httptest.ResponseRecorder, authentication.JWKS) {
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/.well-known/jwks.json", handlerGetJWKS)
+	r.ServeHTTP(rr, req)
+
+	var jwks authentication.JWKS
+	err := json.Unmarshal(rr.Body.Bytes(), &jwks)
+	assert.Nil(t, err)
+
+	return rr, jwks
+}
+
+func TestGetJWKS_WithoutKeys(t *testing.T) {
+	authentication.Keys = nil
+
+	rr, jwks := getJWKS(t)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotNil(t, jwks.Keys)
+	assert.Len(t, jwks.Keys, 0)
+}
+
+func TestGetJWKS_Success(t *testing.T) {
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	keySet, err := authentication.NewKeySet([]*authentication.SigningKey{
+		{ID: "2026-10", Method: authentication.SigningMethodEdDSA, PrivateKey: privateKey, ActiveFrom: time.Now()},
+	})
+	assert.Nil(t, err)
+	authentication.Keys = keySet
+	defer func() { authentication.Keys = nil }()
+
+	rr, jwks := getJWKS(t)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "2026-10", jwks.Keys[0].KeyID)
+	assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+	assert.Empty(t, jwks.Keys[0].N)
+}
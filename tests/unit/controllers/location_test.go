@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getLocationRepository    func(id uint64) (*models.Location, error_utils.MessageErr)
+	createLocationRepository func(location *models.Location) (*models.Location, error_utils.MessageErr)
+	deleteLocationRepository func(id uint64) error_utils.MessageErr
+	getUserSitesRepository   func(userID uint64) ([]uint64, error_utils.MessageErr)
+	setUserSitesRepository   func(userID uint64, siteIDs []uint64) error_utils.MessageErr
+	isInSitesRepository      func(locationID *uint64, siteIDs []uint64) (bool, error_utils.MessageErr)
+	handlerCreateLocation    = controllers.CreateLocation
+	handlerDeleteLocation    = controllers.DeleteLocation
+	handlerSetUserSites      = controllers.SetUserSites
+)
+
+// GetAllTasks looks up the sites of the manager, so the mock is installed for the whole package
+func init() {
+	repositories.LocationRepo = &locationRepoMock{}
+}
+
+type locationRepoMock struct{}
+
+func (locationRepo *locationRepoMock) Get(locationID uint64) (*models.Location, error_utils.MessageErr) {
+	return getLocationRepository(locationID)
+}
+
+func (locationRepo *locationRepoMock) GetAll() ([]models.Location, error_utils.MessageErr) {
+	return []models.Location{}, nil
+}
+
+func (locationRepo *locationRepoMock) Create(location *models.Location) (*models.Location, error_utils.MessageErr) {
+	return createLocationRepository(location)
+}
+
+func (locationRepo *locationRepoMock) Update(location *models.Location) (*models.Location, error_utils.MessageErr) {
+	return location, nil
+}
+
+func (locationRepo *locationRepoMock) Delete(locationID uint64) error_utils.MessageErr {
+	return deleteLocationRepository(locationID)
+}
+
+func (locationRepo *locationRepoMock) GetUserSites(userID uint64) ([]uint64, error_utils.MessageErr) {
+	if getUserSitesRepository == nil {
+		return []uint64{}, nil
+	}
+	return getUserSitesRepository(userID)
+}
+
+func (locationRepo *locationRepoMock) IsInSites(locationID *uint64, siteIDs []uint64) (bool, error_utils.MessageErr) {
+	if isInSitesRepository == nil {
+		return true, nil
+	}
+	return isInSitesRepository(locationID, siteIDs)
+}
+
+func (locationRepo *locationRepoMock) SetUserSites(userID uint64, siteIDs []uint64) error_utils.MessageErr {
+	return setUserSitesRepository(userID, siteIDs)
+}
+
+func (locationRepo *locationRepoMock) Init() {}
+
+func TestCreateLocation_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	createLocationRepository = func(location *models.Location) (*models.Location, error_utils.MessageErr) {
+		location.ID = 4
+		location.Path = "/1/4/"
+		return location, nil
+	}
+
+	jsonBody := `{"kind": "building", "name": " North wing ", "parentId": 1}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/locations", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/locations", handlerCreateLocation)
+	r.ServeHTTP(rr, req)
+
+	var location models.Location
+	err := json.Unmarshal(rr.Body.Bytes(), &location)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "North wing", location.Name)
+	assert.Equal(t, "/1/4/", location.Path)
+}
+
+func TestCreateLocation_MissingParent(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	jsonBody := `{"kind": "floor", "name": "Ground floor"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/locations", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/locations", handlerCreateLocation)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "a floor needs a parent building", apiErr.Message())
+}
+
+func TestCreateLocation_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	jsonBody := `{"kind": "site", "name": "Head office"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/locations", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/locations", handlerCreateLocation)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "The user doesn't have the right permission to create a location", apiErr.Message())
+}
+
+func TestDeleteLocation_InUse(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	deleteLocationRepository = func(id uint64) error_utils.MessageErr {
+		return error_utils.NewConflictError("the location still has 2 locations, 0 tasks and 0 assets, it can't be deleted")
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodDelete, "/locations/1", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.DELETE("/locations/:id", handlerDeleteLocation)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, apiErr.Status())
+}
+
+func TestSetUserSites_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+	defer func() { getUserSitesRepository = nil }()
+
+	getUserSitesRepository = func(userID uint64) ([]uint64, error_utils.MessageErr) {
+		return []uint64{}, nil
+	}
+
+	getUserByIdRepository = func(userId uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: userId, Type: "Manager"}, nil
+	}
+
+	setUserSitesRepository = func(userID uint64, siteIDs []uint64) error_utils.MessageErr {
+		assert.Equal(t, uint64(3), userID)
+		assert.Equal(t, []uint64{1, 5}, siteIDs)
+		return nil
+	}
+
+	jsonBody := `{"siteIds": [1, 5, 1]}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPut, "/users/3/sites", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PUT("/users/:id/sites", handlerSetUserSites)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestSetUserSites_ScopedCaller(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	defer func() { getUserSitesRepository = nil }()
+
+	getUserSitesRepository = func(userID uint64) ([]uint64, error_utils.MessageErr) {
+		return []uint64{1}, nil
+	}
+
+	jsonBody := `{"siteIds": [5]}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPut, "/users/3/sites", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PUT("/users/:id/sites", handlerSetUserSites)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+}
+
+func TestGetAllTasks_ScopedToSites(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+	defer func() { getUserSitesRepository = nil }()
+
+	getUserSitesRepository = func(userID uint64) ([]uint64, error_utils.MessageErr) {
+		assert.Equal(t, uint64(2), userID)
+		return []uint64{1}, nil
+	}
+
+	listTasksRepository = func(query models.TaskQuery) (*models.TaskPage, error_utils.MessageErr) {
+		assert.Equal(t, []uint64{1}, query.SiteIDs)
+		assert.Equal(t, uint64(7), query.LocationID)
+		return &models.TaskPage{Data: []models.Task{}}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/tasks?locationId=7", nil)
+	req.Header = map[string][]string{
+		"Authorization": {manager_token},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/tasks", handlerGetAllTasks)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -21,6 +21,13 @@ var (
 
 func TestLogin_Success(t *testing.T) {
 	repositories.UserRepo = &userRepoMock{}
+	repositories.TokenRepo = &tokenRepoMock{}
+
+	var stored *models.RefreshToken
+	createRefreshTokenRepository = func(refreshToken *models.RefreshToken) (*models.RefreshToken, error_utils.MessageErr) {
+		stored = refreshToken
+		return refreshToken, nil
+	}
 
 	getUserByEmailRepository = func(email string) (*models.User, error_utils.MessageErr) {
 		return &models.User{
@@ -51,6 +58,9 @@ func TestLogin_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code)
 	assert.Equal(t, "1", authentication.ID)
 	assert.NotNil(t, authentication.Token)
+	assert.NotEmpty(t, authentication.RefreshToken)
+	assert.Equal(t, models.HashRefreshToken(authentication.RefreshToken), stored.TokenHash)
+	assert.Equal(t, uint64(1), stored.UserID)
 }
 
 func TestLogin_WrongJSONFormat(t *testing.T) {
@@ -135,3 +145,37 @@ func TestLogin_WihtoutEmail(t *testing.T) {
 	assert.Equal(t, "no record matching given the identification", apiErr.Message())
 	assert.Equal(t, "not_found", apiErr.Error())
 }
+
+func TestLogin_DeactivatedUser(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByEmailRepository = func(email string) (*models.User, error_utils.MessageErr) {
+		deactivatedAt := tm
+		return &models.User{
+			ID:            1,
+			Name:          "Test user",
+			Email:         "test@test.com",
+			Password:      "$2a$10$cdBTAX1B2KdXSbKaBdqY7utnuWDJHuw5V46TkzgEGrAQ4E1A6c6au",
+			Type:          "Technician",
+			DeactivatedAt: &deactivatedAt,
+		}, nil
+	}
+
+	jsonBody := `{"email": "test@test.com", "password": "123"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(jsonBody))
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/login", handlerLogin)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "the user is deactivated", apiErr.Message())
+}
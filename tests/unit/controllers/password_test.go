@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/mailer"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/security"
+	"api/app/utils/error_utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	createPasswordResetRepository func(reset *models.PasswordReset, recentSince time.Time, deliver func(reset *models.PasswordReset) error) error_utils.MessageErr
+	resetPasswordRepository       func(tokenHash string, hashedPassword string, now time.Time) (*models.User, error_utils.MessageErr)
+	handlerForgotPassword         = controllers.ForgotPassword
+	handlerResetPassword          = controllers.ResetPassword
+	handlerChangePassword         = controllers.ChangePassword
+)
+
+type passwordRepoMock struct{}
+
+func (passwordRepo *passwordRepoMock) CreateReset(reset *models.PasswordReset, recentSince time.Time, deliver func(reset *models.PasswordReset) error) error_utils.MessageErr {
+	return createPasswordResetRepository(reset, recentSince, deliver)
+}
+
+func (passwordRepo *passwordRepoMock) Reset(tokenHash string, hashedPassword string, now time.Time) (*models.User, error_utils.MessageErr) {
+	return resetPasswordRepository(tokenHash, hashedPassword, now)
+}
+
+func (passwordRepo *passwordRepoMock) Init() {}
+
+func TestForgotPassword_Success(t *testing.T) {
+	config.PASSWORD_RESET_TTL = time.Hour
+	config.PASSWORD_RESET_URL = "https://app.test/reset"
+	config.PASSWORD_RESET_COOLDOWN = 5 * time.Minute
+	repositories.UserRepo = &userRepoMock{}
+	repositories.PasswordRepo = &passwordRepoMock{}
+	mail := &mailerMock{}
+	mailer.Mail = mail
+
+	getUserByEmailRepository = func(email string) (*models.User, error_utils.MessageErr) {
+		assert.Equal(t, "tech@test.com", email)
+		return technicianUser(4), nil
+	}
+
+	var saved *models.PasswordReset
+	created := make(chan bool, 1)
+	createPasswordResetRepository = func(reset *models.PasswordReset, recentSince time.Time, deliver func(reset *models.PasswordReset) error) error_utils.MessageErr {
+		saved = reset
+		assert.WithinDuration(t, time.Now().Add(-5*time.Minute), recentSince, time.Minute)
+		assert.Nil(t, deliver(reset))
+		created <- true
+		return nil
+	}
+
+	rr := userRequest(t, http.MethodPost, "/password/forgot", "/password/forgot", `{"email": " tech@test.com "}`, "", handlerForgotPassword)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	// the email is sent once the request is answered
+	select {
+	case <-created:
+	case <-time.After(time.Second):
+		t.Fatal("the password reset wasn't created")
+	}
+	assert.Equal(t, uint64(4), saved.UserID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), saved.ExpiresAt, time.Minute)
+	assert.Len(t, mail.messages, 1)
+	assert.Equal(t, "tech@test.com", mail.messages[0].To)
+
+	// only the hash of the token sent by email is stored
+	start := strings.Index(mail.messages[0].Body, "https://app.test/reset?token=")
+	assert.NotEqual(t, -1, start)
+	link, err := url.Parse(strings.Fields(mail.messages[0].Body[start:])[0])
+	assert.Nil(t, err)
+	assert.Equal(t, models.HashPasswordResetToken(link.Query().Get("token")), saved.TokenHash)
+}
+
+func TestForgotPassword_UnknownEmail(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{}
+	repositories.PasswordRepo = &passwordRepoMock{}
+	mail := &mailerMock{}
+	mailer.Mail = mail
+
+	getUserByEmailRepository = func(email string) (*models.User, error_utils.MessageErr) {
+		return nil, error_utils.NewNotFoundError("record not found")
+	}
+	createPasswordResetRepository = func(reset *models.PasswordReset, recentSince time.Time, deliver func(reset *models.PasswordReset) error) error_utils.MessageErr {
+		t.Error("no reset should be created for an unknown email")
+		return nil
+	}
+
+	rr := userRequest(t, http.MethodPost, "/password/forgot", "/password/forgot", `{"email": "nobody@test.com"}`, "", handlerForgotPassword)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Empty(t, mail.messages)
+}
+
+func TestForgotPassword_DeliveryFailsIsHidden(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{}
+	repositories.PasswordRepo = &passwordRepoMock{}
+	mailer.Mail = &mailerMock{err: errors.New("connection refused")}
+
+	getUserByEmailRepository = func(email string) (*models.User, error_utils.MessageErr) {
+		return technicianUser(4), nil
+	}
+	created := make(chan bool, 1)
+	createPasswordResetRepository = func(reset *models.PasswordReset, recentSince time.Time, deliver func(reset *models.PasswordReset) error) error_utils.MessageErr {
+		assert.NotNil(t, deliver(reset))
+		created <- true
+		return error_utils.NewInternalServerError("it's not possible to send the reset email: connection refused")
+	}
+
+	rr := userRequest(t, http.MethodPost, "/password/forgot", "/password/forgot", `{"email": "tech@test.com"}`, "", handlerForgotPassword)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	select {
+	case <-created:
+	case <-time.After(time.Second):
+		t.Fatal("the password reset wasn't created")
+	}
+}
+
+func TestForgotPassword_DeactivatedUser(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{}
+	repositories.PasswordRepo = &passwordRepoMock{}
+	mail := &mailerMock{}
+	mailer.Mail = mail
+
+	getUserByEmailRepository = func(email string) (*models.User, error_utils.MessageErr) {
+		user := technicianUser(4)
+		deactivatedAt := time.Now()
+		user.DeactivatedAt = &deactivatedAt
+		return user, nil
+	}
+	createPasswordResetRepository = func(reset *models.PasswordReset, recentSince time.Time, deliver func(reset *models.PasswordReset) error) error_utils.MessageErr {
+		t.Error("no reset should be created for a deactivated user")
+		return nil
+	}
+
+	rr := userRequest(t, http.MethodPost, "/password/forgot", "/password/forgot", `{"email": "tech@test.com"}`, "", handlerForgotPassword)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Empty(t, mail.messages)
+}
+
+func TestForgotPassword_BoundedSenders(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{}
+	repositories.PasswordRepo = &passwordRepoMock{}
+	mailer.Mail = &mailerMock{}
+
+	getUserByEmailRepository = func(email string) (*models.User, error_utils.MessageErr) {
+		return technicianUser(4), nil
+	}
+
+	started := make(chan bool, 10)
+	release := make(chan bool)
+	done := make(chan bool, 10)
+	createPasswordResetRepository = func(reset *models.PasswordReset, recentSince time.Time, deliver func(reset *models.PasswordReset) error) error_utils.MessageErr {
+		started <- true
+		<-release
+		done <- true
+		return nil
+	}
+
+	// the requests made while every sender is busy are answered the same but nothing is sent
+	for i := 0; i < 6; i++ {
+		rr := userRequest(t, http.MethodPost, "/password/forgot", "/password/forgot", `{"email": "tech@test.com"}`, "", handlerForgotPassword)
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+	}
+
+	for i := 0; i < 4; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("the password reset wasn't created")
+		}
+	}
+	close(release)
+
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	assert.Len(t, started, 0)
+}
+
+func TestResetPassword_Success(t *testing.T) {
+	repositories.PasswordRepo = &passwordRepoMock{}
+
+	resetPasswordRepository = func(tokenHash string, hashedPassword string, now time.Time) (*models.User, error_utils.MessageErr) {
+		assert.Equal(t, models.HashPasswordResetToken("reset-token"), tokenHash)
+		assert.Nil(t, security.VerifyPassword(hashedPassword, "new-secret"))
+		return technicianUser(4), nil
+	}
+
+	rr := userRequest(t, http.MethodPost, "/password/reset", "/password/reset", `{"token": "reset-token", "password": "new-secret"}`, "", handlerResetPassword)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestResetPassword_Used(t *testing.T) {
+	repositories.PasswordRepo = &passwordRepoMock{}
+
+	resetPasswordRepository = func(tokenHash string, hashedPassword string, now time.Time) (*models.User, error_utils.MessageErr) {
+		return nil, error_utils.NewConflictError("the reset token was already used")
+	}
+
+	rr := userRequest(t, http.MethodPost, "/password/reset", "/password/reset", `{"token": "reset-token", "password": "new-secret"}`, "", handlerResetPassword)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Equal(t, "the reset token was already used", apiErr.Message())
+}
+
+func TestResetPassword_WithoutToken(t *testing.T) {
+	repositories.PasswordRepo = &passwordRepoMock{}
+
+	rr := userRequest(t, http.MethodPost, "/password/reset", "/password/reset", `{"password": "new-secret"}`, "", handlerResetPassword)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "the field token is required can't be empty", apiErr.Message())
+}
+
+func TestChangePassword_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+	repositories.TokenRepo = &tokenRepoMock{}
+
+	// the hash of 123
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		user := technicianUser(id)
+		user.Password = "$2a$10$cdBTAX1B2KdXSbKaBdqY7utnuWDJHuw5V46TkzgEGrAQ4E1A6c6au"
+		return user, nil
+	}
+
+	var updated *models.User
+	updateUserRepository = func(user *models.User) (*models.User, error_utils.MessageErr) {
+		updated = user
+		return user, nil
+	}
+
+	createRefreshTokenRepository = func(refreshToken *models.RefreshToken) (*models.RefreshToken, error_utils.MessageErr) {
+		return refreshToken, nil
+	}
+
+	jsonBody := `{"currentPassword": "123", "newPassword": "new-secret"}`
+	rr := userRequest(t, http.MethodPut, "/users/me/password", "/users/me/password", jsonBody, newToken(4, "Technician"), handlerChangePassword)
+
+	var authentication models.AuthenticationData
+	err := json.Unmarshal(rr.Body.Bytes(), &authentication)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, security.VerifyPassword(updated.Password, "new-secret"))
+	assert.NotNil(t, updated.PasswordChangedAt)
+	assert.NotEmpty(t, authentication.Token)
+	assert.NotEmpty(t, authentication.RefreshToken)
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		user := technicianUser(id)
+		user.Password = "$2a$10$cdBTAX1B2KdXSbKaBdqY7utnuWDJHuw5V46TkzgEGrAQ4E1A6c6au"
+		return user, nil
+	}
+	updateUserRepository = func(user *models.User) (*models.User, error_utils.MessageErr) {
+		t.Error("the password shouldn't change")
+		return user, nil
+	}
+
+	jsonBody := `{"currentPassword": "wrong", "newPassword": "new-secret"}`
+	rr := userRequest(t, http.MethodPut, "/users/me/password", "/users/me/password", jsonBody, newToken(4, "Technician"), handlerChangePassword)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "the current password is wrong", apiErr.Message())
+}
+
+func TestChangePassword_SamePassword(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	jsonBody := `{"currentPassword": "123", "newPassword": "123"}`
+	rr := userRequest(t, http.MethodPut, "/users/me/password", "/users/me/password", jsonBody, newToken(4, "Technician"), handlerChangePassword)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getScheduleRepository    func(id uint64) (*models.Schedule, error_utils.MessageErr)
+	getSchedulesRepository   func() ([]models.Schedule, error_utils.MessageErr)
+	createScheduleRepository func(schedule *models.Schedule) (*models.Schedule, error_utils.MessageErr)
+	updateScheduleRepository func(schedule *models.Schedule) (*models.Schedule, error_utils.MessageErr)
+	deleteScheduleRepository func(id uint64) error_utils.MessageErr
+	handlerCreateSchedule    = controllers.CreateSchedule
+	handlerGetSchedules      = controllers.GetSchedules
+	handlerUpdateSchedule    = controllers.UpdateSchedule
+	handlerDeleteSchedule    = controllers.DeleteSchedule
+)
+
+type scheduleRepoMock struct{}
+
+func (scheduleRepo *scheduleRepoMock) Get(scheduleID uint64) (*models.Schedule, error_utils.MessageErr) {
+	return getScheduleRepository(scheduleID)
+}
+
+func (scheduleRepo *scheduleRepoMock) GetAll() ([]models.Schedule, error_utils.MessageErr) {
+	return getSchedulesRepository()
+}
+
+func (scheduleRepo *scheduleRepoMock) Create(schedule *models.Schedule) (*models.Schedule, error_utils.MessageErr) {
+	return createScheduleRepository(schedule)
+}
+
+func (scheduleRepo *scheduleRepoMock) Update(schedule *models.Schedule) (*models.Schedule, error_utils.MessageErr) {
+	return updateScheduleRepository(schedule)
+}
+
+func (scheduleRepo *scheduleRepoMock) Delete(scheduleID uint64) error_utils.MessageErr {
+	return deleteScheduleRepository(scheduleID)
+}
+
+func (scheduleRepo *scheduleRepoMock) Materialize(now time.Time, leadTime time.Duration) ([]models.Task, error_utils.MessageErr) {
+	return []models.Task{}, nil
+}
+
+func (scheduleRepo *scheduleRepoMock) Init() {}
+
+func TestCreateSchedule_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.ScheduleRepo = &scheduleRepoMock{}
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: id, Name: "Tech user", Type: "Technician"}, nil
+	}
+
+	createScheduleRepository = func(schedule *models.Schedule) (*models.Schedule, error_utils.MessageErr) {
+		schedule.ID = 1
+		return schedule, nil
+	}
+
+	jsonBody := `{"summary": "Monthly HVAC check", "recurrence": "0 8 1 * *", "timezone": "Europe/Paris", "assigneeId": 3}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/schedules", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/schedules", handlerCreateSchedule)
+	r.ServeHTTP(rr, req)
+
+	var schedule models.Schedule
+	err := json.Unmarshal(rr.Body.Bytes(), &schedule)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, uint64(2), schedule.CreatedBy)
+	assert.Equal(t, models.TaskPriorityMedium, schedule.Priority)
+	assert.True(t, schedule.Active)
+	assert.True(t, schedule.NextRunAt.After(time.Now()))
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	nextRun := schedule.NextRunAt.In(paris)
+	assert.Equal(t, 1, nextRun.Day())
+	assert.Equal(t, 8, nextRun.Hour())
+}
+
+func TestCreateSchedule_InvalidRecurrence(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.ScheduleRepo = &scheduleRepoMock{}
+
+	jsonBody := `{"summary": "Monthly HVAC check", "recurrence": "every month", "assigneeId": 3}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/schedules", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/schedules", handlerCreateSchedule)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+}
+
+func TestCreateSchedule_AssignedToManager(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.ScheduleRepo = &scheduleRepoMock{}
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: id, Name: "Manager user", Type: "Manager"}, nil
+	}
+
+	jsonBody := `{"summary": "Monthly HVAC check", "recurrence": "@monthly", "assigneeId": 2}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/schedules", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/schedules", handlerCreateSchedule)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "a schedule can only be assigned to a Technician", apiErr.Message())
+}
+
+func TestCreateSchedule_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.ScheduleRepo = &scheduleRepoMock{}
+
+	jsonBody := `{"summary": "Monthly HVAC check", "recurrence": "@monthly", "assigneeId": 1}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/schedules", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/schedules", handlerCreateSchedule)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "The user doesn't have the right permission to create a schedule", apiErr.Message())
+}
+
+func TestGetSchedules_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.ScheduleRepo = &scheduleRepoMock{}
+
+	getSchedulesRepository = func() ([]models.Schedule, error_utils.MessageErr) {
+		return []models.Schedule{
+			{ID: 1, Summary: "Monthly HVAC check", Recurrence: "@monthly", AssigneeID: 3},
+			{ID: 2, Summary: "Quarterly fire extinguisher inspection", Recurrence: "@quarterly", AssigneeID: 3},
+		}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/schedules", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/schedules", handlerGetSchedules)
+	r.ServeHTTP(rr, req)
+
+	var schedules []models.Schedule
+	err := json.Unmarshal(rr.Body.Bytes(), &schedules)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, schedules, 2)
+}
+
+func TestUpdateSchedule_Deactivate(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.ScheduleRepo = &scheduleRepoMock{}
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: id, Name: "Tech user", Type: "Technician"}, nil
+	}
+
+	getScheduleRepository = func(id uint64) (*models.Schedule, error_utils.MessageErr) {
+		return &models.Schedule{ID: id, Summary: "Monthly HVAC check", Recurrence: "@monthly", AssigneeID: 3, Active: true}, nil
+	}
+
+	updateScheduleRepository = func(schedule *models.Schedule) (*models.Schedule, error_utils.MessageErr) {
+		return schedule, nil
+	}
+
+	jsonBody := `{"summary": "Monthly HVAC check", "recurrence": "@monthly", "assigneeId": 3, "active": false}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPut, "/schedules/1", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PUT("/schedules/:id", handlerUpdateSchedule)
+	r.ServeHTTP(rr, req)
+
+	var schedule models.Schedule
+	err := json.Unmarshal(rr.Body.Bytes(), &schedule)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, schedule.Active)
+}
+
+func TestDeleteSchedule_NotFound(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.ScheduleRepo = &scheduleRepoMock{}
+
+	deleteScheduleRepository = func(id uint64) error_utils.MessageErr {
+		return error_utils.NewNotFoundError("no record matching given the identification")
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodDelete, "/schedules/1", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.DELETE("/schedules/:id", handlerDeleteSchedule)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status())
+}
@@ -0,0 +1,360 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getTagRepository           func(id uint64) (*models.Tag, error_utils.MessageErr)
+	createTagRepository        func(tag *models.Tag) (*models.Tag, error_utils.MessageErr)
+	updateTagRepository        func(tag *models.Tag) (*models.Tag, error_utils.MessageErr)
+	deleteTagRepository        func(id uint64) error_utils.MessageErr
+	tagCountsRepository        func(userID uint64, siteIDs []uint64) ([]models.TagCount, error_utils.MessageErr)
+	getTagsByTaskIDsRepository func(taskIDs []uint64) (map[uint64][]models.Tag, error_utils.MessageErr)
+	setTaskTagsRepository      func(taskID uint64, tagIDs []uint64) ([]models.Tag, error_utils.MessageErr)
+	handlerCreateTag           = controllers.CreateTag
+	handlerGetTags             = controllers.GetTags
+	handlerUpdateTag           = controllers.UpdateTag
+	handlerSetTaskTags         = controllers.SetTaskTags
+)
+
+// the task handlers load the tags of the tasks, so the mock is installed for the whole package
+func init() {
+	repositories.TagRepo = &tagRepoMock{}
+}
+
+type tagRepoMock struct{}
+
+func (tagRepo *tagRepoMock) Get(tagID uint64) (*models.Tag, error_utils.MessageErr) {
+	return getTagRepository(tagID)
+}
+
+func (tagRepo *tagRepoMock) GetAll() ([]models.Tag, error_utils.MessageErr) {
+	return []models.Tag{}, nil
+}
+
+func (tagRepo *tagRepoMock) Create(tag *models.Tag) (*models.Tag, error_utils.MessageErr) {
+	return createTagRepository(tag)
+}
+
+func (tagRepo *tagRepoMock) Update(tag *models.Tag) (*models.Tag, error_utils.MessageErr) {
+	return updateTagRepository(tag)
+}
+
+func (tagRepo *tagRepoMock) Delete(tagID uint64) error_utils.MessageErr {
+	return deleteTagRepository(tagID)
+}
+
+func (tagRepo *tagRepoMock) Counts(userID uint64, siteIDs []uint64) ([]models.TagCount, error_utils.MessageErr) {
+	return tagCountsRepository(userID, siteIDs)
+}
+
+func (tagRepo *tagRepoMock) GetByTaskIDs(taskIDs []uint64) (map[uint64][]models.Tag, error_utils.MessageErr) {
+	if getTagsByTaskIDsRepository == nil {
+		return map[uint64][]models.Tag{}, nil
+	}
+	return getTagsByTaskIDsRepository(taskIDs)
+}
+
+func (tagRepo *tagRepoMock) SetTaskTags(taskID uint64, tagIDs []uint64) ([]models.Tag, error_utils.MessageErr) {
+	return setTaskTagsRepository(taskID, tagIDs)
+}
+
+func (tagRepo *tagRepoMock) Init() {}
+
+func TestCreateTag_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	createTagRepository = func(tag *models.Tag) (*models.Tag, error_utils.MessageErr) {
+		tag.ID = 1
+		return tag, nil
+	}
+
+	jsonBody := `{"name": "  HVAC  "}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tags", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tags", handlerCreateTag)
+	r.ServeHTTP(rr, req)
+
+	var tag models.Tag
+	err := json.Unmarshal(rr.Body.Bytes(), &tag)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "HVAC", tag.Name)
+	assert.Equal(t, uint64(2), tag.CreatedBy)
+}
+
+func TestCreateTag_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	jsonBody := `{"name": "HVAC"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tags", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tags", handlerCreateTag)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "The user doesn't have the right permission to create a tag", apiErr.Message())
+}
+
+func TestGetTags_CountsOwnTasks(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	var countedUserID uint64
+	tagCountsRepository = func(userID uint64, siteIDs []uint64) ([]models.TagCount, error_utils.MessageErr) {
+		countedUserID = userID
+		return []models.TagCount{
+			{Tag: models.Tag{ID: 1, Name: "HVAC"}, Tasks: 3},
+			{Tag: models.Tag{ID: 2, Name: "Plumbing"}, Tasks: 0},
+		}, nil
+	}
+
+	r := gin.Default()
+	r.GET("/tags", handlerGetTags)
+
+	req, _ := http.NewRequest(http.MethodGet, "/tags", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	var counts []models.TagCount
+	err := json.Unmarshal(rr.Body.Bytes(), &counts)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, counts, 2)
+	assert.Equal(t, int64(3), counts[0].Tasks)
+	assert.Equal(t, uint64(1), countedUserID)
+
+	req, _ = http.NewRequest(http.MethodGet, "/tags", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, uint64(0), countedUserID)
+}
+
+func TestUpdateTag_AlreadyExists(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	getTagRepository = func(id uint64) (*models.Tag, error_utils.MessageErr) {
+		return &models.Tag{ID: id, Name: "HVAC"}, nil
+	}
+
+	updateTagRepository = func(tag *models.Tag) (*models.Tag, error_utils.MessageErr) {
+		return nil, error_utils.NewConflictError("the tag Plumbing already exists")
+	}
+
+	jsonBody := `{"name": "Plumbing"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPut, "/tags/1", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PUT("/tags/:id", handlerUpdateTag)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, apiErr.Status())
+}
+
+func TestSetTaskTags_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	var setTagIDs []uint64
+	setTaskTagsRepository = func(taskID uint64, tagIDs []uint64) ([]models.Tag, error_utils.MessageErr) {
+		setTagIDs = tagIDs
+		return []models.Tag{{ID: 1, Name: "HVAC"}, {ID: 2, Name: "Plumbing"}}, nil
+	}
+
+	jsonBody := `{"tagIds": [2, 1, 2]}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPut, "/tasks/1/tags", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PUT("/tasks/:id/tags", handlerSetTaskTags)
+	r.ServeHTTP(rr, req)
+
+	var tags []models.Tag
+	err := json.Unmarshal(rr.Body.Bytes(), &tags)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, tags, 2)
+	assert.Equal(t, []uint64{2, 1}, setTagIDs)
+}
+
+func TestGetAllTasks_TagFilter(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	var listedQuery models.TaskQuery
+	listTasksRepository = func(query models.TaskQuery) (*models.TaskPage, error_utils.MessageErr) {
+		listedQuery = query
+		return &models.TaskPage{Data: []models.Task{{ID: 1, Summary: "This is a summary test", UserID: 1}}, Total: 1}, nil
+	}
+
+	getTagsByTaskIDsRepository = func(taskIDs []uint64) (map[uint64][]models.Tag, error_utils.MessageErr) {
+		return map[uint64][]models.Tag{1: {{ID: 1, Name: "HVAC"}, {ID: 2, Name: "Plumbing"}}}, nil
+	}
+	defer func() { getTagsByTaskIDsRepository = nil }()
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/tasks?tags=1,2&tagMode=all", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/tasks", handlerGetAllTasks)
+	r.ServeHTTP(rr, req)
+
+	var page models.TaskPage
+	err := json.Unmarshal(rr.Body.Bytes(), &page)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []uint64{1, 2}, listedQuery.TagIDs)
+	assert.Equal(t, models.TagModeAll, listedQuery.TagMode)
+	assert.Len(t, page.Data[0].Tags, 2)
+}
+
+func TestGetAllTasks_RepeatedTagFilter(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	var listedQuery models.TaskQuery
+	listTasksRepository = func(query models.TaskQuery) (*models.TaskPage, error_utils.MessageErr) {
+		listedQuery = query
+		return &models.TaskPage{Data: []models.Task{}, Total: 0}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/tasks?tags=3,3,4&tagMode=all", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/tasks", handlerGetAllTasks)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []uint64{3, 4}, listedQuery.TagIDs)
+	assert.Equal(t, models.TagModeAll, listedQuery.TagMode)
+}
+
+func TestGetAllTasks_InvalidTagMode(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/tasks?tags=1&tagMode=none", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/tasks", handlerGetAllTasks)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the tag mode none is not supported, use one of any, all", apiErr.Message())
+}
+
+func TestSetTaskTags_OutOfSites(t *testing.T) {
+	repositories.TaskRepo = &taskRepoMock{}
+	scopeToAnotherSite(t)
+
+	rr := userRequest(t, http.MethodPut, "/tasks/:id/tags", "/tasks/1/tags", `{"tagIds": [1]}`, newToken(2, "Manager"), handlerSetTaskTags)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestGetTags_ScopedManager(t *testing.T) {
+	scopeToAnotherSite(t)
+
+	var countedSiteIDs []uint64
+	tagCountsRepository = func(userID uint64, siteIDs []uint64) ([]models.TagCount, error_utils.MessageErr) {
+		countedSiteIDs = siteIDs
+		return []models.TagCount{}, nil
+	}
+
+	rr := userRequest(t, http.MethodGet, "/tags", "/tags", "", newToken(2, "Manager"), handlerGetTags)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []uint64{10}, countedSiteIDs)
+}
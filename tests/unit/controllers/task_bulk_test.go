@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var handlerBulkTasks = controllers.BulkTasks
+
+func TestBulkTasks_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	bulkTasksRepository = func(request models.TaskBulkRequest, actorID uint64, ownerID uint64, siteIDs []uint64) (*models.TaskBulkResponse, error_utils.MessageErr) {
+		assert.Equal(t, models.TaskBulkModeAtomic, request.Mode)
+		assert.Equal(t, uint64(2), actorID)
+		assert.Equal(t, uint64(0), ownerID)
+
+		previousAssignee, assignee := uint64(1), uint64(3)
+		return &models.TaskBulkResponse{
+			Mode:      request.Mode,
+			Committed: true,
+			Succeeded: 2,
+			Results: []models.TaskBulkResult{
+				{Index: 0, TaskID: 1, Action: models.TaskBulkActionDelete, Status: http.StatusOK},
+				{
+					Index: 1, TaskID: 2, Action: models.TaskBulkActionAssign, Status: http.StatusOK,
+					Task:               &models.Task{ID: 2, AssigneeID: &assignee},
+					PreviousAssigneeID: &previousAssignee,
+				},
+			},
+		}, nil
+	}
+
+	jsonBody := `{"operations": [{"action": "delete", "taskId": 1}, {"action": "assign", "taskId": 2, "assigneeId": 3}]}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/bulk", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/bulk", handlerBulkTasks)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestBulkTasks_PartialFailure(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	bulkTasksRepository = func(request models.TaskBulkRequest, actorID uint64, ownerID uint64, siteIDs []uint64) (*models.TaskBulkResponse, error_utils.MessageErr) {
+		assert.Equal(t, uint64(1), ownerID)
+		return &models.TaskBulkResponse{
+			Mode:      request.Mode,
+			Committed: true,
+			Succeeded: 1,
+			Failed:    1,
+			Results: []models.TaskBulkResult{
+				{Index: 0, TaskID: 1, Action: models.TaskBulkActionTag, Status: http.StatusOK, Task: &models.Task{ID: 1}},
+				{Index: 1, TaskID: 9, Action: models.TaskBulkActionTag, Status: http.StatusForbidden, Error: "the task 9 doesn't belong to you"},
+			},
+		}, nil
+	}
+
+	jsonBody := `{"mode": "best_effort", "operations": [{"action": "tag", "taskId": 1, "tagIds": [4]}, {"action": "tag", "taskId": 9, "tagIds": [4]}]}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/bulk", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/bulk", handlerBulkTasks)
+	r.ServeHTTP(rr, req)
+
+	var response models.TaskBulkResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.Equal(t, http.StatusForbidden, response.Results[1].Status)
+}
+
+func TestBulkTasks_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	jsonBody := `{"operations": [{"action": "tag", "taskId": 1, "tagIds": [4]}, {"action": "delete", "taskId": 1}]}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/bulk", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/bulk", handlerBulkTasks)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "The user doesn't have the right permission to run the delete operation", apiErr.Message())
+}
+
+func TestBulkTasks_InvalidOperation(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	jsonBody := `{"mode": "best_effort", "operations": [{"action": "update_status", "taskId": 1, "status": "finished"}]}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/bulk", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/bulk", handlerBulkTasks)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the operation 0 is not valid: the status finished is not a valid task status", apiErr.Message())
+}
+
+func TestBulkTasks_ScopedManager(t *testing.T) {
+	repositories.TaskRepo = &taskRepoMock{}
+	scopeToAnotherSite(t)
+
+	var receivedSiteIDs []uint64
+	bulkTasksRepository = func(request models.TaskBulkRequest, actorID uint64, ownerID uint64, siteIDs []uint64) (*models.TaskBulkResponse, error_utils.MessageErr) {
+		receivedSiteIDs = siteIDs
+		return &models.TaskBulkResponse{
+			Mode:   request.Mode,
+			Failed: 1,
+			Results: []models.TaskBulkResult{
+				{Index: 0, TaskID: 1, Action: models.TaskBulkActionDelete, Status: http.StatusForbidden, Error: "the task 1 isn't located in your sites"},
+			},
+		}, nil
+	}
+
+	rr := userRequest(t, http.MethodPost, "/tasks/bulk", "/tasks/bulk", `{"operations": [{"action": "delete", "taskId": 1}]}`, newToken(2, "Manager"), handlerBulkTasks)
+
+	assert.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.Equal(t, []uint64{10}, receivedSiteIDs)
+}
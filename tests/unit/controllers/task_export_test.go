@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var handlerExportTasks = controllers.ExportTasks
+
+func exportTaskRows() []models.TaskExportRow {
+	assigneeID, assigneeName := uint64(3), "Bob"
+	createdAt := time.Date(2022, 1, 10, 9, 0, 0, 0, time.UTC)
+
+	return []models.TaskExportRow{
+		{ID: 1, Summary: "Fix the pump", Status: models.TaskStatusOpen, Priority: models.TaskPriorityHigh, UserID: 1, UserName: "Alice",
+			AssigneeID: &assigneeID, AssigneeName: &assigneeName, CreatedAt: createdAt, UpdatedAt: createdAt},
+		{ID: 2, Summary: "=HYPERLINK(\"x\")", Status: models.TaskStatusDone, Priority: models.TaskPriorityLow, UserID: 1, UserName: "Alice",
+			CreatedAt: createdAt, UpdatedAt: createdAt},
+	}
+}
+
+func exportTasksRequest(t *testing.T, url string, token string) *httptest.ResponseRecorder {
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, url, nil)
+	req.Header = map[string][]string{
+		"Authorization": {token},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/tasks/export", handlerExportTasks)
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestExportTasks_CSV(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	exportTasksRepository = func(query models.TaskQuery, write func(row *models.TaskExportRow) error) error_utils.MessageErr {
+		assert.Equal(t, models.TaskStatusOpen, query.Status)
+		assert.Equal(t, uint64(3), query.UserID)
+
+		for _, row := range exportTaskRows() {
+			if err := write(&row); err != nil {
+				return error_utils.NewInternalServerError(err.Error())
+			}
+		}
+		return nil
+	}
+
+	rr := exportTasksRequest(t, "/tasks/export?status=open&userId=3", newToken(2, "Manager"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), ".csv")
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, models.TaskExportHeader, records[0])
+	assert.Equal(t, []string{"1", "Fix the pump", "open", "high", "1", "Alice", "3", "Bob", "", "", "", "", "2022-01-10T09:00:00Z", "2022-01-10T09:00:00Z"}, records[1])
+	assert.Equal(t, `'=HYPERLINK("x")`, records[2][1])
+	assert.Equal(t, "", records[2][7])
+}
+
+func TestExportTasks_JSON(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	exportTasksRepository = func(query models.TaskQuery, write func(row *models.TaskExportRow) error) error_utils.MessageErr {
+		for _, row := range exportTaskRows() {
+			if err := write(&row); err != nil {
+				return error_utils.NewInternalServerError(err.Error())
+			}
+		}
+		return nil
+	}
+
+	rr := exportTasksRequest(t, "/tasks/export?format=json", newToken(2, "Manager"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var rows []models.TaskExportRow
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rows))
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "Bob", *rows[0].AssigneeName)
+	assert.Nil(t, rows[1].AssigneeName)
+}
+
+func TestExportTasks_EmptyJSON(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	exportTasksRepository = func(query models.TaskQuery, write func(row *models.TaskExportRow) error) error_utils.MessageErr {
+		return nil
+	}
+
+	rr := exportTasksRequest(t, "/tasks/export?format=json", newToken(2, "Manager"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "[]\n", rr.Body.String())
+}
+
+func TestExportTasks_NDJSON(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	exportTasksRepository = func(query models.TaskQuery, write func(row *models.TaskExportRow) error) error_utils.MessageErr {
+		for _, row := range exportTaskRows() {
+			if err := write(&row); err != nil {
+				return error_utils.NewInternalServerError(err.Error())
+			}
+		}
+		return nil
+	}
+
+	rr := exportTasksRequest(t, "/tasks/export?format=ndjson", newToken(2, "Manager"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+	lines := 0
+	scanner := bufio.NewScanner(strings.NewReader(rr.Body.String()))
+	for scanner.Scan() {
+		var row models.TaskExportRow
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestExportTasks_InvalidFormat(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	rr := exportTasksRequest(t, "/tasks/export?format=xml", newToken(2, "Manager"))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestExportTasks_QueryError(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	exportTasksRepository = func(query models.TaskQuery, write func(row *models.TaskExportRow) error) error_utils.MessageErr {
+		return error_utils.NewInternalServerError("error when trying to read the database")
+	}
+
+	rr := exportTasksRequest(t, "/tasks/export", newToken(2, "Manager"))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "application/json")
+}
+
+func TestExportTasks_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	rr := exportTasksRequest(t, "/tasks/export", newToken(1, "Technician"))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
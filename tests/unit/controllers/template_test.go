@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getTemplateRepository         func(id uint64) (*models.TaskTemplate, error_utils.MessageErr)
+	createTemplateRepository      func(template *models.TaskTemplate) (*models.TaskTemplate, error_utils.MessageErr)
+	updateTemplateRepository      func(template *models.TaskTemplate, actorID uint64) (*models.TaskTemplate, error_utils.MessageErr)
+	instantiateTemplateRepository func(task *models.Task, tagIDs []uint64) (*models.Task, error_utils.MessageErr)
+	handlerCreateTemplate         = controllers.CreateTemplate
+	handlerUpdateTemplate         = controllers.UpdateTemplate
+	handlerCreateTaskFromTemplate = controllers.CreateTaskFromTemplate
+)
+
+type templateRepoMock struct{}
+
+func (templateRepo *templateRepoMock) Get(templateID uint64) (*models.TaskTemplate, error_utils.MessageErr) {
+	return getTemplateRepository(templateID)
+}
+
+func (templateRepo *templateRepoMock) GetAll() ([]models.TaskTemplate, error_utils.MessageErr) {
+	return []models.TaskTemplate{}, nil
+}
+
+func (templateRepo *templateRepoMock) Create(template *models.TaskTemplate) (*models.TaskTemplate, error_utils.MessageErr) {
+	return createTemplateRepository(template)
+}
+
+func (templateRepo *templateRepoMock) Update(template *models.TaskTemplate, actorID uint64) (*models.TaskTemplate, error_utils.MessageErr) {
+	return updateTemplateRepository(template, actorID)
+}
+
+func (templateRepo *templateRepoMock) Delete(templateID uint64) error_utils.MessageErr {
+	return nil
+}
+
+func (templateRepo *templateRepoMock) Versions(templateID uint64) ([]models.TaskTemplateVersion, error_utils.MessageErr) {
+	return []models.TaskTemplateVersion{}, nil
+}
+
+func (templateRepo *templateRepoMock) Instantiate(task *models.Task, tagIDs []uint64) (*models.Task, error_utils.MessageErr) {
+	return instantiateTemplateRepository(task, tagIDs)
+}
+
+func (templateRepo *templateRepoMock) Init() {}
+
+func boilerTemplate(id uint64) *models.TaskTemplate {
+	return &models.TaskTemplate{
+		ID:      id,
+		Name:    "Boiler service",
+		Version: 3,
+		TaskTemplateContent: models.TaskTemplateContent{
+			Summary:  "Service the boiler {{ serial }} of {{site}}",
+			Priority: models.TaskPriorityHigh,
+			Checklist: models.TemplateChecklist{
+				{Title: "Shut down {{serial}}", Mandatory: true},
+				{Title: "Clean the burner"},
+			},
+			TagIDs: models.TemplateTagIDs{2, 5},
+		},
+	}
+}
+
+func TestCreateTemplate_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TemplateRepo = &templateRepoMock{}
+
+	createTemplateRepository = func(template *models.TaskTemplate) (*models.TaskTemplate, error_utils.MessageErr) {
+		template.ID = 1
+		template.Version = 1
+		return template, nil
+	}
+
+	jsonBody := `{"name": "Boiler service", "summary": "Service the boiler {{serial}}", "checklist": [{"title": " Shut down ", "mandatory": true}], "tagIds": [2, 2, 5]}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/templates", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/templates", handlerCreateTemplate)
+	r.ServeHTTP(rr, req)
+
+	var template models.TaskTemplate
+	err := json.Unmarshal(rr.Body.Bytes(), &template)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, uint64(1), template.Version)
+	assert.Equal(t, models.TaskPriorityMedium, template.Priority)
+	assert.Equal(t, "Shut down", template.Checklist[0].Title)
+	assert.Equal(t, models.TemplateTagIDs{2, 5}, template.TagIDs)
+}
+
+func TestCreateTemplate_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TemplateRepo = &templateRepoMock{}
+
+	jsonBody := `{"name": "Boiler service", "summary": "Service the boiler"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/templates", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/templates", handlerCreateTemplate)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "The user doesn't have the right permission to create a template", apiErr.Message())
+}
+
+func TestUpdateTemplate_NewVersion(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TemplateRepo = &templateRepoMock{}
+
+	updateTemplateRepository = func(template *models.TaskTemplate, actorID uint64) (*models.TaskTemplate, error_utils.MessageErr) {
+		assert.Equal(t, uint64(1), template.ID)
+		assert.Equal(t, uint64(2), actorID)
+		template.Version = 4
+		return template, nil
+	}
+
+	jsonBody := `{"name": "Boiler service", "summary": "Service the boiler {{serial}} twice"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPut, "/templates/1", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.PUT("/templates/:id", handlerUpdateTemplate)
+	r.ServeHTTP(rr, req)
+
+	var template models.TaskTemplate
+	err := json.Unmarshal(rr.Body.Bytes(), &template)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, uint64(4), template.Version)
+}
+
+func TestCreateTaskFromTemplate_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TemplateRepo = &templateRepoMock{}
+
+	getTemplateRepository = func(id uint64) (*models.TaskTemplate, error_utils.MessageErr) {
+		return boilerTemplate(id), nil
+	}
+
+	instantiateTemplateRepository = func(task *models.Task, tagIDs []uint64) (*models.Task, error_utils.MessageErr) {
+		assert.Equal(t, []uint64{2, 5}, tagIDs)
+		task.ID = 10
+		task.Version = 1
+		return task, nil
+	}
+
+	jsonBody := `{"variables": {"serial": "BX-2041", "site": "Head office"}}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/from-template/7", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/from-template/:templateId", handlerCreateTaskFromTemplate)
+	r.ServeHTTP(rr, req)
+
+	var task models.Task
+	err := json.Unmarshal(rr.Body.Bytes(), &task)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "Service the boiler BX-2041 of Head office", task.Summary)
+	assert.Equal(t, models.TaskPriorityHigh, task.Priority)
+	assert.Equal(t, uint64(7), *task.TemplateID)
+	assert.Equal(t, uint64(3), *task.TemplateVersion)
+	assert.Equal(t, uint64(1), *task.AssigneeID)
+	assert.NotNil(t, task.DueAt)
+	assert.Len(t, task.Checklist, 2)
+	assert.Equal(t, "Shut down BX-2041", task.Checklist[0].Title)
+	assert.True(t, task.Checklist[0].Mandatory)
+}
+
+func TestCreateTaskFromTemplate_MissingVariables(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TemplateRepo = &templateRepoMock{}
+
+	getTemplateRepository = func(id uint64) (*models.TaskTemplate, error_utils.MessageErr) {
+		return boilerTemplate(id), nil
+	}
+
+	jsonBody := `{"variables": {"serial": "BX-2041"}}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/from-template/7", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/from-template/:templateId", handlerCreateTaskFromTemplate)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the variable site needs a value", apiErr.Message())
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	createRefreshTokenRepository func(refreshToken *models.RefreshToken) (*models.RefreshToken, error_utils.MessageErr)
+	rotateRefreshTokenRepository func(tokenHash string, now time.Time, issue func(user *models.User, current *models.RefreshToken) (*models.RefreshToken, error)) error_utils.MessageErr
+	revokeSessionRepository      func(familyID string) error_utils.MessageErr
+	revokeTokenRepository        func(tokenID string, expiresAt time.Time) error_utils.MessageErr
+	handlerRefreshToken          = controllers.RefreshToken
+	handlerLogout                = controllers.Logout
+)
+
+type tokenRepoMock struct{}
+
+func (tokenRepo *tokenRepoMock) Create(refreshToken *models.RefreshToken) (*models.RefreshToken, error_utils.MessageErr) {
+	return createRefreshTokenRepository(refreshToken)
+}
+
+func (tokenRepo *tokenRepoMock) Rotate(tokenHash string, now time.Time, accessTTL time.Duration, issue func(user *models.User, current *models.RefreshToken) (*models.RefreshToken, error)) error_utils.MessageErr {
+	return rotateRefreshTokenRepository(tokenHash, now, issue)
+}
+
+func (tokenRepo *tokenRepoMock) RevokeSession(familyID string, now time.Time, accessTTL time.Duration) error_utils.MessageErr {
+	return revokeSessionRepository(familyID)
+}
+
+func (tokenRepo *tokenRepoMock) Revoke(tokenID string, expiresAt time.Time) error_utils.MessageErr {
+	return revokeTokenRepository(tokenID, expiresAt)
+}
+
+func (tokenRepo *tokenRepoMock) IsRevoked(tokenID string) (bool, error_utils.MessageErr) {
+	return false, nil
+}
+
+func (tokenRepo *tokenRepoMock) Purge(now time.Time) (int64, error_utils.MessageErr) {
+	return 0, nil
+}
+
+func (tokenRepo *tokenRepoMock) Init() {}
+
+func TestRefreshToken_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TokenRepo = &tokenRepoMock{}
+
+	var next *models.RefreshToken
+	rotateRefreshTokenRepository = func(tokenHash string, now time.Time, issue func(user *models.User, current *models.RefreshToken) (*models.RefreshToken, error)) error_utils.MessageErr {
+		assert.Equal(t, models.HashRefreshToken("refresh"), tokenHash)
+
+		var err error
+		next, err = issue(&models.User{ID: 1, Type: "Technician"}, &models.RefreshToken{ID: 3, UserID: 1, FamilyID: "family"})
+		assert.Nil(t, err)
+		return nil
+	}
+
+	jsonBody := `{"refreshToken": "refresh"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBufferString(jsonBody))
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/token/refresh", handlerRefreshToken)
+	r.ServeHTTP(rr, req)
+
+	var authenticationData models.AuthenticationData
+	err := json.Unmarshal(rr.Body.Bytes(), &authenticationData)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "1", authenticationData.ID)
+	assert.NotEmpty(t, authenticationData.Token)
+	assert.NotNil(t, authenticationData.ExpiresAt)
+
+	// the new refresh token stays in the family and only its hash is stored
+	assert.Equal(t, "family", next.FamilyID)
+	assert.Equal(t, models.HashRefreshToken(authenticationData.RefreshToken), next.TokenHash)
+	assert.NotEmpty(t, next.AccessTokenID)
+}
+
+func TestRefreshToken_Reused(t *testing.T) {
+	repositories.TokenRepo = &tokenRepoMock{}
+
+	rotateRefreshTokenRepository = func(tokenHash string, now time.Time, issue func(user *models.User, current *models.RefreshToken) (*models.RefreshToken, error)) error_utils.MessageErr {
+		return error_utils.NewUnauthorizedError("the refresh token was already used, the session was revoked")
+	}
+
+	jsonBody := `{"refreshToken": "refresh"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBufferString(jsonBody))
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/token/refresh", handlerRefreshToken)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status())
+	assert.Equal(t, "the refresh token was already used, the session was revoked", apiErr.Message())
+}
+
+func TestRefreshToken_WithoutToken(t *testing.T) {
+	repositories.TokenRepo = &tokenRepoMock{}
+
+	jsonBody := `{"refreshToken": " "}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBufferString(jsonBody))
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/token/refresh", handlerRefreshToken)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the field refreshToken is required can't be empty", apiErr.Message())
+}
+
+func TestLogout_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TokenRepo = &tokenRepoMock{}
+
+	accessToken, err := authentication.CreateAccessToken(1, "Technician", "family")
+	assert.Nil(t, err)
+
+	revokedToken, revokedSession := "", ""
+	revokeTokenRepository = func(tokenID string, expiresAt time.Time) error_utils.MessageErr {
+		revokedToken = tokenID
+		assert.Equal(t, accessToken.ExpiresAt.Unix(), expiresAt.Unix())
+		return nil
+	}
+	revokeSessionRepository = func(familyID string) error_utils.MessageErr {
+		revokedSession = familyID
+		return nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/logout", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken.Token))
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/logout", handlerLogout)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, accessToken.ID, revokedToken)
+	assert.Equal(t, "family", revokedSession)
+}
+
+func TestLogout_WithoutToken(t *testing.T) {
+	repositories.TokenRepo = &tokenRepoMock{}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/logout", nil)
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/logout", handlerLogout)
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
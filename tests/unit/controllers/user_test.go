@@ -1,12 +1,14 @@
 package controllers
 
 import (
+	"api/app/config"
 	"api/app/controllers"
 	"api/app/models"
 	"api/app/repositories"
 	"api/app/utils/error_utils"
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -21,7 +23,17 @@ var (
 	getUserByEmailRepository func(email string) (*models.User, error_utils.MessageErr)
 	getUserByIdRepository    func(id uint64) (*models.User, error_utils.MessageErr)
 	createUserRepository     func(user *models.User) (*models.User, error_utils.MessageErr)
+	listUsersRepository      func(query models.UserQuery) (*models.UserPage, error_utils.MessageErr)
+	updateUserRepository     func(user *models.User) (*models.User, error_utils.MessageErr)
+	deactivateUserRepository func(userID uint64, deactivatedAt *time.Time) (*models.User, error_utils.MessageErr)
+	deleteUserRepository     func(userID uint64) error_utils.MessageErr
 	handlerCreateUser        = controllers.CreateUser
+	handlerGetUsers          = controllers.GetUsers
+	handlerGetUser           = controllers.GetUser
+	handlerUpdateUser        = controllers.UpdateUser
+	handlerDeactivateUser    = controllers.DeactivateUser
+	handlerActivateUser      = controllers.ActivateUser
+	handlerDeleteUser        = controllers.DeleteUser
 )
 
 type userRepoMock struct{}
@@ -38,6 +50,30 @@ func (userRepo *userRepoMock) Get(userId uint64) (*models.User, error_utils.Mess
 	return getUserByIdRepository(userId)
 }
 
+func (userRepo *userRepoMock) GetWithDeleted(userId uint64) (*models.User, error_utils.MessageErr) {
+	return getUserByIdRepository(userId)
+}
+
+func (userRepo *userRepoMock) List(query models.UserQuery) (*models.UserPage, error_utils.MessageErr) {
+	return listUsersRepository(query)
+}
+
+func (userRepo *userRepoMock) Update(user *models.User) (*models.User, error_utils.MessageErr) {
+	return updateUserRepository(user)
+}
+
+func (userRepo *userRepoMock) SetDeactivatedAt(userID uint64, deactivatedAt *time.Time) (*models.User, error_utils.MessageErr) {
+	return deactivateUserRepository(userID, deactivatedAt)
+}
+
+func (userRepo *userRepoMock) Delete(userID uint64) error_utils.MessageErr {
+	return deleteUserRepository(userID)
+}
+
+func (userRepo *userRepoMock) BootstrapAdmin(admin *models.User) (*models.User, error_utils.MessageErr) {
+	return admin, nil
+}
+
 func (userRepo *userRepoMock) Init() {}
 
 func TestCreateUser_Success(t *testing.T) {
@@ -56,7 +92,7 @@ func TestCreateUser_Success(t *testing.T) {
 		}, nil
 	}
 
-	jsonBody := `{"name": "Test user", "email": "test@test.com", "password": "123", "type": "Manager"}`
+	jsonBody := `{"name": "Test user", "email": "test@test.com", "password": "123", "type": "Technician"}`
 	r := gin.Default()
 	req, errRequest := http.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(jsonBody))
 
@@ -81,6 +117,52 @@ func TestCreateUser_Success(t *testing.T) {
 	assert.NotNil(t, user.UpdatedAt)
 }
 
+func TestCreateUser_ManagerNeedsInvitation(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{}
+
+	jsonBody := `{"name": "Test user", "email": "test@test.com", "password": "123", "type": "Manager"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(jsonBody))
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/users", handlerCreateUser)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "a Manager can't register, ask for an invitation", apiErr.Message())
+}
+
+func TestCreateUser_RegistrationDisabled(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{}
+	config.SELF_REGISTRATION = "disabled"
+	defer func() { config.SELF_REGISTRATION = "technician" }()
+
+	jsonBody := `{"name": "Test user", "email": "test@test.com", "password": "123", "type": "Technician"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(jsonBody))
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/users", handlerCreateUser)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "the registration is closed, ask for an invitation", apiErr.Message())
+}
+
 func TestCreateUser_WrongJSONFormat(t *testing.T) {
 	repositories.UserRepo = &userRepoMock{}
 
@@ -192,7 +274,7 @@ func TestCreateUser_InvalidUserType(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, apiErr)
 	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
-	assert.Equal(t, "the field type should be Technician or Manager", apiErr.Message())
+	assert.Equal(t, "the field type should be Technician, Manager or Admin", apiErr.Message())
 	assert.Equal(t, "bad_request", apiErr.Error())
 }
 
@@ -222,3 +304,313 @@ func TestCreateUser_MultipleErrors(t *testing.T) {
 	)
 	assert.Equal(t, "bad_request", apiErr.Error())
 }
+
+func userRequest(t *testing.T, method string, route string, url string, body string, token string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	r := gin.Default()
+
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewBufferString(body)
+	}
+
+	req, errRequest := http.NewRequest(method, url, reader)
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {token},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.Handle(method, route, handler)
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func technicianUser(id uint64) *models.User {
+	return &models.User{ID: id, Name: "Tech user", Email: "tech@test.com", Password: "$2a$10$hash", Type: "Technician", CreatedAt: tm, UpdatedAt: tm}
+}
+
+func TestGetUsers_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	listUsersRepository = func(query models.UserQuery) (*models.UserPage, error_utils.MessageErr) {
+		assert.Equal(t, "Technician", query.Type)
+		assert.False(t, *query.Active)
+		assert.Equal(t, 10, query.Limit)
+		return &models.UserPage{Data: []models.User{*technicianUser(1)}, Total: 1}, nil
+	}
+
+	rr := userRequest(t, http.MethodGet, "/users", "/users?type=Technician&active=false&limit=10", "", newToken(2, "Manager"), handlerGetUsers)
+
+	var page models.UserPage
+	err := json.Unmarshal(rr.Body.Bytes(), &page)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 1, len(page.Data))
+	assert.Empty(t, page.Data[0].Password)
+}
+
+func TestGetUsers_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	rr := userRequest(t, http.MethodGet, "/users", "/users", "", newToken(1, "Technician"), handlerGetUsers)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestGetUsers_InvalidType(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	rr := userRequest(t, http.MethodGet, "/users", "/users?type=Owner", "", newToken(2, "Manager"), handlerGetUsers)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the type Owner is not a valid user type", apiErr.Message())
+}
+
+func TestGetUser_Self(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return technicianUser(id), nil
+	}
+
+	rr := userRequest(t, http.MethodGet, "/users/:id", "/users/1", "", newToken(1, "Technician"), handlerGetUser)
+
+	var user models.User
+	err := json.Unmarshal(rr.Body.Bytes(), &user)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, uint64(1), user.ID)
+	assert.Empty(t, user.Password)
+}
+
+func TestGetUser_AnotherUserWrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	rr := userRequest(t, http.MethodGet, "/users/:id", "/users/3", "", newToken(1, "Technician"), handlerGetUser)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestUpdateUser_SelfProfile(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return technicianUser(id), nil
+	}
+
+	updateUserRepository = func(user *models.User) (*models.User, error_utils.MessageErr) {
+		assert.Equal(t, "New name", user.Name)
+		assert.Equal(t, "$2a$10$hash", user.Password)
+		return user, nil
+	}
+
+	jsonBody := `{"name": " New name "}`
+	rr := userRequest(t, http.MethodPut, "/users/:id", "/users/1", jsonBody, newToken(1, "Technician"), handlerUpdateUser)
+
+	var user models.User
+	err := json.Unmarshal(rr.Body.Bytes(), &user)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "New name", user.Name)
+	assert.Empty(t, user.Password)
+}
+
+func TestUpdateUser_SelfPassword(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return technicianUser(id), nil
+	}
+	updateUserRepository = func(user *models.User) (*models.User, error_utils.MessageErr) {
+		t.Error("the password shouldn't change without the current one")
+		return user, nil
+	}
+
+	jsonBody := `{"name": "New name", "password": "secret"}`
+	rr := userRequest(t, http.MethodPut, "/users/:id", "/users/1", jsonBody, newToken(1, "Technician"), handlerUpdateUser)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "the own password is changed with PUT /v1/users/me/password, it needs the current one", apiErr.Message())
+}
+
+func TestUpdateUser_SelfType(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return technicianUser(id), nil
+	}
+
+	jsonBody := `{"type": "Manager"}`
+	rr := userRequest(t, http.MethodPut, "/users/:id", "/users/1", jsonBody, newToken(1, "Technician"), handlerUpdateUser)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestUpdateUser_ManagerChangesTechnician(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return technicianUser(id), nil
+	}
+
+	updateUserRepository = func(user *models.User) (*models.User, error_utils.MessageErr) {
+		return user, nil
+	}
+
+	jsonBody := `{"email": "new@test.com"}`
+	rr := userRequest(t, http.MethodPut, "/users/:id", "/users/1", jsonBody, newToken(2, "Manager"), handlerUpdateUser)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestUpdateUser_ManagerChangesTechnicianPassword(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return technicianUser(id), nil
+	}
+
+	jsonBody := `{"password": "secret"}`
+	rr := userRequest(t, http.MethodPut, "/users/:id", "/users/1", jsonBody, newToken(2, "Manager"), handlerUpdateUser)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "Only an admin can change the password of another user", apiErr.Message())
+}
+
+func TestUpdateUser_ManagerChangesManager(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: id, Name: "Manager user", Type: "Manager"}, nil
+	}
+
+	jsonBody := `{"name": "Renamed"}`
+	rr := userRequest(t, http.MethodPut, "/users/:id", "/users/3", jsonBody, newToken(2, "Manager"), handlerUpdateUser)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestUpdateUser_AdminPromotes(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: id, Name: "Manager user", Type: "Manager"}, nil
+	}
+
+	updateUserRepository = func(user *models.User) (*models.User, error_utils.MessageErr) {
+		assert.Equal(t, "Admin", user.Type)
+		return user, nil
+	}
+
+	jsonBody := `{"type": "Admin"}`
+	rr := userRequest(t, http.MethodPut, "/users/:id", "/users/3", jsonBody, newToken(5, "Admin"), handlerUpdateUser)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestUpdateUser_EmptyBody(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	rr := userRequest(t, http.MethodPut, "/users/:id", "/users/1", `{}`, newToken(1, "Technician"), handlerUpdateUser)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestDeactivateUser_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return technicianUser(id), nil
+	}
+
+	deactivateUserRepository = func(userID uint64, deactivatedAt *time.Time) (*models.User, error_utils.MessageErr) {
+		assert.Equal(t, uint64(1), userID)
+		assert.NotNil(t, deactivatedAt)
+		user := technicianUser(userID)
+		user.DeactivatedAt = deactivatedAt
+		return user, nil
+	}
+
+	rr := userRequest(t, http.MethodPost, "/users/:id/deactivate", "/users/1/deactivate", "", newToken(2, "Manager"), handlerDeactivateUser)
+
+	var user models.User
+	err := json.Unmarshal(rr.Body.Bytes(), &user)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotNil(t, user.DeactivatedAt)
+}
+
+func TestDeactivateUser_Self(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	rr := userRequest(t, http.MethodPost, "/users/:id/deactivate", "/users/5/deactivate", "", newToken(5, "Admin"), handlerDeactivateUser)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestActivateUser_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	getUserByIdRepository = func(id uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: id, Name: "Manager user", Type: "Manager"}, nil
+	}
+
+	deactivateUserRepository = func(userID uint64, deactivatedAt *time.Time) (*models.User, error_utils.MessageErr) {
+		assert.Nil(t, deactivatedAt)
+		return &models.User{ID: userID, Name: "Manager user", Type: "Manager"}, nil
+	}
+
+	rr := userRequest(t, http.MethodPost, "/users/:id/activate", "/users/3/activate", "", newToken(5, "Admin"), handlerActivateUser)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestDeleteUser_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	deleteUserRepository = func(userID uint64) error_utils.MessageErr {
+		assert.Equal(t, uint64(3), userID)
+		return nil
+	}
+
+	rr := userRequest(t, http.MethodDelete, "/users/:id", "/users/3", "", newToken(5, "Admin"), handlerDeleteUser)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestDeleteUser_WrongPermission(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{}
+
+	rr := userRequest(t, http.MethodDelete, "/users/:id", "/users/1", "", newToken(2, "Manager"), handlerDeleteUser)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
@@ -0,0 +1,442 @@
+package controllers
+
+import (
+	"api/app/config"
+	"api/app/controllers"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	getWorkLogsRepository       func(taskID uint64) ([]models.WorkLog, error_utils.MessageErr)
+	createWorkLogRepository     func(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr)
+	startTimerRepository        func(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr)
+	stopTimerRepository         func(taskID uint64, technicianID uint64, now time.Time, note string) (*models.WorkLog, error_utils.MessageErr)
+	totalByTaskIDRepository     func(taskID uint64) (int64, error_utils.MessageErr)
+	getWorkLogsByTechRepository func(technicianID uint64, from time.Time, to time.Time) ([]models.WorkLog, error_utils.MessageErr)
+	handlerStartTimer           = controllers.StartTimer
+	handlerStopTimer            = controllers.StopTimer
+	handlerCreateWorkLog        = controllers.CreateWorkLog
+	handlerGetTimesheet         = controllers.GetTimesheet
+)
+
+// the task handler sums up the time spent, so the mock is installed for the whole package
+func init() {
+	repositories.WorkLogRepo = &workLogRepoMock{}
+}
+
+type workLogRepoMock struct{}
+
+func (workLogRepo *workLogRepoMock) GetAllByTaskID(taskID uint64) ([]models.WorkLog, error_utils.MessageErr) {
+	return getWorkLogsRepository(taskID)
+}
+
+func (workLogRepo *workLogRepoMock) Create(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr) {
+	return createWorkLogRepository(workLog)
+}
+
+func (workLogRepo *workLogRepoMock) StartTimer(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr) {
+	return startTimerRepository(workLog)
+}
+
+func (workLogRepo *workLogRepoMock) StopTimer(taskID uint64, technicianID uint64, now time.Time, note string) (*models.WorkLog, error_utils.MessageErr) {
+	return stopTimerRepository(taskID, technicianID, now, note)
+}
+
+func (workLogRepo *workLogRepoMock) TotalByTaskID(taskID uint64) (int64, error_utils.MessageErr) {
+	if totalByTaskIDRepository == nil {
+		return 0, nil
+	}
+	return totalByTaskIDRepository(taskID)
+}
+
+func (workLogRepo *workLogRepoMock) GetAllByTechnician(technicianID uint64, from time.Time, to time.Time) ([]models.WorkLog, error_utils.MessageErr) {
+	return getWorkLogsByTechRepository(technicianID, from, to)
+}
+
+func (workLogRepo *workLogRepoMock) Init() {}
+
+func TestStartTimer_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	startTimerRepository = func(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr) {
+		workLog.ID = 1
+		return workLog, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/timer/start", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/timer/start", handlerStartTimer)
+	r.ServeHTTP(rr, req)
+
+	var workLog models.WorkLog
+	err := json.Unmarshal(rr.Body.Bytes(), &workLog)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, uint64(1), workLog.TaskID)
+	assert.Equal(t, uint64(1), workLog.TechnicianID)
+	assert.True(t, workLog.IsRunning())
+}
+
+func TestStartTimer_AlreadyRunning(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	startTimerRepository = func(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr) {
+		return nil, error_utils.NewConflictError("a timer is already running on the task 2, stop it first")
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/timer/start", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/timer/start", handlerStartTimer)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, apiErr.Status())
+	assert.Equal(t, "a timer is already running on the task 2, stop it first", apiErr.Message())
+}
+
+func TestStartTimer_NotAssigned(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 2}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/timer/start", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/timer/start", handlerStartTimer)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "Not possible to log time on a task that does not belong to you", apiErr.Message())
+}
+
+func TestStopTimer_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	stopTimerRepository = func(taskID uint64, technicianID uint64, now time.Time, note string) (*models.WorkLog, error_utils.MessageErr) {
+		workLog := &models.WorkLog{ID: 1, TaskID: taskID, TechnicianID: technicianID, StartedAt: now.Add(-time.Hour)}
+		workLog.Stop(now, note)
+		return workLog, nil
+	}
+
+	jsonBody := `{"note": "Replaced the filter"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/timer/stop", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/timer/stop", handlerStopTimer)
+	r.ServeHTTP(rr, req)
+
+	var workLog models.WorkLog
+	err := json.Unmarshal(rr.Body.Bytes(), &workLog)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, int64(3600), workLog.DurationSeconds)
+	assert.Equal(t, "Replaced the filter", workLog.Note)
+	assert.False(t, workLog.IsRunning())
+}
+
+func TestStopTimer_NotRunning(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	stopTimerRepository = func(taskID uint64, technicianID uint64, now time.Time, note string) (*models.WorkLog, error_utils.MessageErr) {
+		return nil, error_utils.NewNotFoundError("there is no timer running on the task")
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/timer/stop", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/timer/stop", handlerStopTimer)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status())
+}
+
+func TestCreateWorkLog_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	createWorkLogRepository = func(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr) {
+		workLog.ID = 1
+		return workLog, nil
+	}
+
+	jsonBody := `{"durationSeconds": 1800, "note": "Checked the pressure", "technicianId": 5}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/worklogs", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/worklogs", handlerCreateWorkLog)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "Not possible to log time for another technician", apiErr.Message())
+
+	jsonBody = `{"durationSeconds": 1800, "note": "Checked the pressure"}`
+	req, _ = http.NewRequest(http.MethodPost, "/tasks/1/worklogs", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	var workLog models.WorkLog
+	err = json.Unmarshal(rr.Body.Bytes(), &workLog)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, uint64(1), workLog.TechnicianID)
+	assert.Equal(t, int64(1800), workLog.DurationSeconds)
+	assert.False(t, workLog.IsRunning())
+}
+
+func TestCreateWorkLog_InTheFuture(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+
+	startedAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	jsonBody := `{"durationSeconds": 1800, "startedAt": "` + startedAt + `"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/worklogs", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/worklogs", handlerCreateWorkLog)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "not possible to log time in the future", apiErr.Message())
+}
+
+func TestCreateWorkLog_HugeDuration(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+	createWorkLogRepository = func(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr) {
+		t.Error("the work log shouldn't be created")
+		return workLog, nil
+	}
+
+	// this many seconds overflow a time.Duration
+	jsonBody := `{"durationSeconds": 9223372037}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/worklogs", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/worklogs", handlerCreateWorkLog)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "the field durationSeconds should be less or equal to 86400", apiErr.Message())
+}
+
+func TestCreateWorkLog_TooOld(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TaskRepo = &taskRepoMock{}
+
+	getTaskByIdRepository = func(id uint64) (*models.Task, error_utils.MessageErr) {
+		return &models.Task{ID: id, Summary: "This is a summary test", UserID: 1}, nil
+	}
+	createWorkLogRepository = func(workLog *models.WorkLog) (*models.WorkLog, error_utils.MessageErr) {
+		t.Error("the work log shouldn't be created")
+		return workLog, nil
+	}
+
+	startedAt := time.Now().Add(-100 * 24 * time.Hour).Format(time.RFC3339)
+	jsonBody := `{"durationSeconds": 1800, "startedAt": "` + startedAt + `"}`
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodPost, "/tasks/1/worklogs", bytes.NewBufferString(jsonBody))
+	req.Header = map[string][]string{
+		"content-type":  {"application/json"},
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.POST("/tasks/:id/worklogs", handlerCreateWorkLog)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+	assert.Equal(t, "not possible to log time started more than 90 days ago", apiErr.Message())
+}
+
+func TestGetTimesheet_Success(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	getWorkLogsByTechRepository = func(technicianID uint64, from time.Time, to time.Time) ([]models.WorkLog, error_utils.MessageErr) {
+		return []models.WorkLog{
+			{ID: 1, TaskID: 1, TechnicianID: technicianID, StartedAt: from, DurationSeconds: 1800},
+			{ID: 2, TaskID: 2, TechnicianID: technicianID, StartedAt: from.Add(time.Hour), DurationSeconds: 600},
+		}, nil
+	}
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/timesheets?from=2022-02-01&to=2022-02-07&userId=3", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(2, "Manager")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/timesheets", handlerGetTimesheet)
+	r.ServeHTTP(rr, req)
+
+	var timesheet models.Timesheet
+	err := json.Unmarshal(rr.Body.Bytes(), &timesheet)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, uint64(3), timesheet.TechnicianID)
+	assert.Equal(t, int64(2400), timesheet.DurationSeconds)
+	assert.Len(t, timesheet.Entries, 2)
+}
+
+func TestGetTimesheet_AnotherUser(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+
+	r := gin.Default()
+	req, errRequest := http.NewRequest(http.MethodGet, "/timesheets?from=2022-02-01&to=2022-02-07&userId=3", nil)
+	req.Header = map[string][]string{
+		"Authorization": {newToken(1, "Technician")},
+	}
+
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	r.GET("/timesheets", handlerGetTimesheet)
+	r.ServeHTTP(rr, req)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	assert.Equal(t, "Not possible to see the timesheet of another user", apiErr.Message())
+}
+
+func TestStartTimer_OutOfSites(t *testing.T) {
+	repositories.TaskRepo = &taskRepoMock{}
+	scopeToAnotherSite(t)
+
+	rr := userRequest(t, http.MethodPost, "/tasks/:id/timer/start", "/tasks/1/timer/start", "", newToken(2, "Manager"), handlerStartTimer)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
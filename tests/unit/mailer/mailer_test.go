@@ -0,0 +1,206 @@
+package mailer
+
+import (
+	"api/app/mailer"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStandIn is a minimal SMTP server on the loopback that records what it receives
+type smtpStandIn struct {
+	listener net.Listener
+	auth     bool
+	rejectTo string
+
+	mu       sync.Mutex
+	from     string
+	to       []string
+	data     string
+	authUser string
+}
+
+func newSMTPStandIn(t *testing.T, auth bool) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &smtpStandIn{listener: listener, auth: auth}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	return server
+}
+
+func (server *smtpStandIn) port() string {
+	return strings.Split(server.listener.Addr().String(), ":")[1]
+}
+
+func (server *smtpStandIn) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handle(conn)
+	}
+}
+
+func (server *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		server.mu.Lock()
+		switch command {
+		case "EHLO", "HELO":
+			if server.auth {
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			} else {
+				reply("250 localhost")
+			}
+		case "AUTH":
+			fields := strings.Fields(line)
+			credentials, _ := base64.StdEncoding.DecodeString(fields[len(fields)-1])
+			server.authUser = strings.Split(string(credentials), "\x00")[1]
+			reply("235 authenticated")
+		case "MAIL":
+			server.from = line
+			reply("250 ok")
+		case "RCPT":
+			if len(server.rejectTo) > 0 && strings.Contains(line, server.rejectTo) {
+				reply("550 no such user")
+			} else {
+				server.to = append(server.to, line)
+				reply("250 ok")
+			}
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil || dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			server.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			server.mu.Unlock()
+			return
+		default:
+			reply("250 ok")
+		}
+		server.mu.Unlock()
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	server := newSMTPStandIn(t, false)
+	mail := mailer.NewSMTPMailer(mailer.SMTPOptions{Host: "127.0.0.1", Port: server.port(), From: "no-reply@test.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := mail.Send(ctx, mailer.Message{To: "new@test.com", Subject: "You are invited", Body: "Hello,\nchoose your password"})
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "MAIL FROM:<no-reply@test.com>", server.from)
+	assert.Equal(t, []string{"RCPT TO:<new@test.com>"}, server.to)
+	assert.Contains(t, server.data, "From: no-reply@test.com\r\n")
+	assert.Contains(t, server.data, "To: new@test.com\r\n")
+	assert.Contains(t, server.data, "Subject: You are invited\r\n")
+	assert.Contains(t, server.data, "\r\n\r\nHello,\r\nchoose your password")
+}
+
+func TestSMTPMailer_SendWithAuth(t *testing.T) {
+	server := newSMTPStandIn(t, true)
+	mail := mailer.NewSMTPMailer(mailer.SMTPOptions{Host: "127.0.0.1", Port: server.port(), Username: "mailer", Password: "secret", From: "no-reply@test.com"})
+
+	err := mail.Send(context.Background(), mailer.Message{To: "new@test.com", Subject: "Invitation", Body: "Hello"})
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "mailer", server.authUser)
+}
+
+func TestSMTPMailer_RejectedRecipient(t *testing.T) {
+	server := newSMTPStandIn(t, false)
+	server.rejectTo = "unknown@test.com"
+	mail := mailer.NewSMTPMailer(mailer.SMTPOptions{Host: "127.0.0.1", Port: server.port(), From: "no-reply@test.com"})
+
+	err := mail.Send(context.Background(), mailer.Message{To: "unknown@test.com", Subject: "Invitation", Body: "Hello"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "550")
+}
+
+func TestSMTPMailer_HeaderInjection(t *testing.T) {
+	mail := mailer.NewSMTPMailer(mailer.SMTPOptions{Host: "127.0.0.1", Port: "1", From: "no-reply@test.com"})
+
+	err := mail.Send(context.Background(), mailer.Message{To: "new@test.com\r\nBcc: other@test.com", Subject: "Invitation", Body: "Hello"})
+	assert.Equal(t, mailer.ErrInvalidHeader, err)
+
+	err = mail.Send(context.Background(), mailer.Message{To: "new@test.com", Subject: "Invitation\nBcc: other@test.com", Body: "Hello"})
+	assert.Equal(t, mailer.ErrInvalidHeader, err)
+}
+
+func TestSMTPMailer_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := strings.Split(listener.Addr().String(), ":")[1]
+	listener.Close()
+
+	mail := mailer.NewSMTPMailer(mailer.SMTPOptions{Host: "127.0.0.1", Port: port, From: "no-reply@test.com"})
+	err = mail.Send(context.Background(), mailer.Message{To: "new@test.com", Subject: "Invitation", Body: "Hello"})
+	assert.NotNil(t, err)
+}
+
+func TestLogMailer_HidesTheBody(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	err := mailer.NewLogMailer(false).Send(context.Background(), mailer.Message{To: "new@test.com", Subject: "Reset your password", Body: "the reset token is secret-token"})
+	require.NoError(t, err)
+
+	assert.Contains(t, output.String(), "new@test.com")
+	assert.Contains(t, output.String(), "Reset your password")
+	assert.NotContains(t, output.String(), "secret-token")
+}
+
+func TestLogMailer_ShowsTheBody(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	err := mailer.NewLogMailer(true).Send(context.Background(), mailer.Message{To: "new@test.com", Subject: "You are invited", Body: "accept at https://app.test/invitations/accept?token=dev-token"})
+	require.NoError(t, err)
+
+	assert.Contains(t, output.String(), "new@test.com")
+	assert.Contains(t, output.String(), "https://app.test/invitations/accept?token=dev-token")
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"api/app/authentication"
+	"api/app/config"
+	"api/app/middleware"
+	"api/app/models"
+	"api/app/repositories"
+	"api/app/utils/error_utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// userRepoMock only answers GetWithDeleted, the middleware doesn't use anything else.
+type userRepoMock struct {
+	repositories.UserRepoInterface
+	get func(userID uint64) (*models.User, error_utils.MessageErr)
+}
+
+func (userRepo *userRepoMock) GetWithDeleted(userID uint64) (*models.User, error_utils.MessageErr) {
+	return userRepo.get(userID)
+}
+
+// tokenRepoMock only answers IsRevoked, the revoked ids are listed in revoked.
+type tokenRepoMock struct {
+	repositories.TokenRepoInterface
+	revoked map[string]bool
+}
+
+func (tokenRepo *tokenRepoMock) IsRevoked(tokenID string) (bool, error_utils.MessageErr) {
+	return tokenRepo.revoked[tokenID], nil
+}
+
+func authRequest(t *testing.T, userID uint64) *httptest.ResponseRecorder {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TokenRepo = &tokenRepoMock{}
+	token, err := authentication.CreateToken(userID, "Technician")
+	assert.Nil(t, err)
+
+	return authRequestWithToken(t, token)
+}
+
+func authRequestWithToken(t *testing.T, token string) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.GET("/ping", middleware.AuthUser(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, nil)
+	})
+
+	req, errRequest := http.NewRequest(http.MethodGet, "/ping", nil)
+	if errRequest != nil {
+		t.Errorf("this is the error: %v\n", errRequest)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestAuthUser_ActiveUser(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: userID, Type: "Technician"}, nil
+	}}
+
+	rr := authRequest(t, 1)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAuthUser_DeactivatedUser(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		deactivatedAt := time.Now()
+		return &models.User{ID: userID, Type: "Technician", DeactivatedAt: &deactivatedAt}, nil
+	}}
+
+	rr := authRequest(t, 1)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status())
+	assert.Equal(t, "the user is deactivated", apiErr.Message())
+}
+
+func TestAuthUser_DeletedUser(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: userID, Type: "Technician", DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}, nil
+	}}
+
+	rr := authRequest(t, 1)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status())
+	assert.Equal(t, "the user was deleted", apiErr.Message())
+}
+
+func TestAuthUser_UnknownUser(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		return nil, error_utils.NewNotFoundError("no record matching given the identification")
+	}}
+
+	rr := authRequest(t, 1)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status())
+	assert.Equal(t, "the user of the token doesn't exist anymore", apiErr.Message())
+}
+
+func TestAuthUser_DatabaseError(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		return nil, error_utils.NewInternalServerError("error when trying to read the database")
+	}}
+
+	rr := authRequest(t, 1)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestAuthUser_RevokedToken(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: userID, Type: "Technician"}, nil
+	}}
+
+	accessToken, err := authentication.CreateAccessToken(1, "Technician", "session")
+	assert.Nil(t, err)
+	repositories.TokenRepo = &tokenRepoMock{revoked: map[string]bool{accessToken.ID: true}}
+
+	rr := authRequestWithToken(t, accessToken.Token)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status())
+	assert.Equal(t, "the token was revoked", apiErr.Message())
+}
+
+func TestAuthUser_TokenWithoutID(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: userID, Type: "Technician"}, nil
+	}}
+
+	repositories.TokenRepo = &tokenRepoMock{}
+
+	// a token signed before the tokens had an id
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"authorized":  true,
+		"exp":         time.Now().Add(time.Hour).Unix(),
+		"user_id":     1,
+		"permissions": []string{"create"},
+	}).SignedString([]byte(config.SECRETKEY))
+	assert.Nil(t, err)
+
+	rr := authRequestWithToken(t, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAuthUser_TokenIssuedBeforePasswordChange(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		passwordChangedAt := time.Now().Add(time.Minute)
+		return &models.User{ID: userID, Type: "Technician", PasswordChangedAt: &passwordChangedAt}, nil
+	}}
+
+	rr := authRequest(t, 1)
+
+	apiErr, err := error_utils.NewApiErrFromBytes(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status())
+	assert.Equal(t, "the password changed, sign in again", apiErr.Message())
+}
+
+func TestAuthUser_TokenIssuedRightBeforePasswordChange(t *testing.T) {
+	config.SECRETKEY = "mySecretK3y"
+	repositories.TokenRepo = &tokenRepoMock{}
+	token, err := authentication.CreateToken(1, "Technician")
+	assert.Nil(t, err)
+
+	// the password changes in the same second the token was issued
+	passwordChangedAt := time.Now()
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		return &models.User{ID: userID, Type: "Technician", PasswordChangedAt: &passwordChangedAt}, nil
+	}}
+
+	rr := authRequestWithToken(t, token)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestAuthUser_TokenIssuedAfterPasswordChange(t *testing.T) {
+	repositories.UserRepo = &userRepoMock{get: func(userID uint64) (*models.User, error_utils.MessageErr) {
+		passwordChangedAt := time.Now().Add(-time.Minute)
+		return &models.User{ID: userID, Type: "Technician", PasswordChangedAt: &passwordChangedAt}, nil
+	}}
+
+	rr := authRequest(t, 1)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
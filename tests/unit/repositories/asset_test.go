@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqlErrors "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type assetSuite struct {
+	suite.Suite
+	DB              *gorm.DB
+	mock            sqlmock.Sqlmock
+	assetRepository repositories.AssetRepoInterface
+}
+
+func (s *assetSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.assetRepository = repositories.NewAssetRepository(s.DB)
+}
+
+func (s *assetSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestAssetInit(t *testing.T) {
+	suite.Run(t, new(assetSuite))
+}
+
+func (s *assetSuite) TestDeleteAsset_InUse() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tasks` WHERE asset_id = ?").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	s.mock.ExpectRollback()
+
+	err := s.assetRepository.Delete(1)
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusConflict, err.Status())
+	require.Equal(s.T(), "the asset is linked to 3 tasks, it can't be deleted", err.Message())
+}
+
+func (s *assetSuite) TestDeleteAsset_Success() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tasks` WHERE asset_id = ?").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectExec("DELETE FROM `assets` WHERE `assets`.`id` = ?").
+		WithArgs(uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.assetRepository.Delete(1)
+	require.Nil(s.T(), err)
+}
+
+func (s *assetSuite) TestTasks_OwnTasks() {
+	s.mock.ExpectQuery("SELECT (.+) FROM `tasks` WHERE asset_id = (.+) AND \\(assignee_id = (.+) OR \\(assignee_id IS NULL AND user_id = (.+)\\)\\) AND `tasks`.`deleted_at` IS NULL ORDER BY created_at DESC, id DESC").
+		WithArgs(uint64(1), uint64(3), uint64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "user_id", "asset_id"}).
+			AddRow(1, "Yearly inspection", 3, 1))
+
+	tasks, err := s.assetRepository.Tasks(1, models.TaskQuery{UserID: 3})
+	require.Nil(s.T(), err)
+	require.Len(s.T(), tasks, 1)
+}
+
+func (s *assetSuite) TestStats_Success() {
+	serviced := time.Date(2022, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery("SELECT asset_id, MAX\\(CASE WHEN status = (.+) THEN completed_at END\\) AS last_serviced_at, (.+) FROM `tasks` WHERE asset_id IN (.+) GROUP BY `asset_id`").
+		WithArgs(models.TaskStatusDone, models.TaskStatusDone, models.TaskStatusCancelled, uint64(1), uint64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"asset_id", "last_serviced_at", "open_tasks"}).
+			AddRow(1, serviced, 2).
+			AddRow(2, nil, 1))
+
+	stats, err := s.assetRepository.Stats([]uint64{1, 2})
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), int64(2), stats[1].OpenTasks)
+	require.True(s.T(), serviced.Equal(*stats[1].LastServicedAt))
+	require.Nil(s.T(), stats[2].LastServicedAt)
+}
+
+func (s *assetSuite) TestCreateAsset_DuplicatedSerialNumber() {
+	asset := models.Asset{Name: "Boiler", SerialNumber: "BX-2041"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("INSERT INTO `assets`").
+		WillReturnError(&mysqlErrors.MySQLError{Number: 1062, Message: "Duplicate entry 'BX-2041'"})
+	s.mock.ExpectRollback()
+
+	_, err := s.assetRepository.Create(&asset)
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusConflict, err.Status())
+	require.Equal(s.T(), "the serial number BX-2041 is already registered", err.Message())
+}
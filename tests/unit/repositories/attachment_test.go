@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type attachmentSuite struct {
+	suite.Suite
+	DB                   *gorm.DB
+	mock                 sqlmock.Sqlmock
+	attachmentRepository repositories.AttachmentRepoInterface
+}
+
+func (s *attachmentSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.attachmentRepository = repositories.NewAttachmentRepository(s.DB)
+}
+
+func (s *attachmentSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestAttachmentInit(t *testing.T) {
+	suite.Run(t, new(attachmentSuite))
+}
+
+func (s *attachmentSuite) TestCreateAttachment_Success() {
+	attachment := models.Attachment{
+		TaskID:      1,
+		UploaderID:  2,
+		FileName:    "pump.png",
+		ContentType: "image/png",
+		Size:        2048,
+		StorageKey:  "tasks/1/abc",
+	}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("INSERT INTO `attachments`").
+		WithArgs(attachment.TaskID, attachment.UploaderID, attachment.FileName, attachment.ContentType, attachment.Size, attachment.StorageKey, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	dbAttachment, err := s.attachmentRepository.Create(&attachment)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(1), dbAttachment.ID)
+}
+
+func (s *attachmentSuite) TestGetAttachmentsByTask_Success() {
+	s.mock.ExpectQuery("SELECT (.*) FROM `attachments` WHERE `attachments`.`task_id` = (.*) ORDER BY created_at, id").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "uploader_id", "file_name", "content_type", "size", "storage_key", "created_at"}).
+			AddRow(1, 1, 2, "pump.png", "image/png", 2048, "tasks/1/abc", tm))
+
+	attachments, err := s.attachmentRepository.GetAllByTaskID(uint64(1))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, len(attachments))
+	require.Equal(s.T(), "pump.png", attachments[0].FileName)
+}
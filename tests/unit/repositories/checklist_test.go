@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type checklistSuite struct {
+	suite.Suite
+	DB                  *gorm.DB
+	mock                sqlmock.Sqlmock
+	checklistRepository repositories.ChecklistRepoInterface
+}
+
+func (s *checklistSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.checklistRepository = repositories.NewChecklistRepository(s.DB)
+}
+
+func (s *checklistSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestChecklistInit(t *testing.T) {
+	suite.Run(t, new(checklistSuite))
+}
+
+func (s *checklistSuite) TestCreateChecklistItem_AppendsAtTheEnd() {
+	item := models.ChecklistItem{TaskID: 1, Title: "Turn off the power", Mandatory: true}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT COALESCE\\(MAX\\(position\\), 0\\) FROM `checklist_items` WHERE task_id = ?").
+		WithArgs(item.TaskID).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(2))
+	s.mock.ExpectExec("INSERT INTO `checklist_items`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	dbItem, err := s.checklistRepository.Create(&item)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(1), dbItem.ID)
+	require.Equal(s.T(), 3, dbItem.Position)
+}
+
+func (s *checklistSuite) TestGetChecklistByTask_Success() {
+	s.mock.ExpectQuery("SELECT (.*) FROM `checklist_items` WHERE `checklist_items`.`task_id` = (.*) ORDER BY position, id").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "title", "position", "mandatory", "done"}).
+			AddRow(1, 1, "Turn off the power", 1, true, true).
+			AddRow(2, 1, "Replace the filter", 2, false, false))
+
+	items, err := s.checklistRepository.GetAllByTaskID(uint64(1))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, len(items))
+}
+
+func (s *checklistSuite) TestUpdateChecklistItem_Success() {
+	now := time.Now()
+	userID := uint64(1)
+	item := models.ChecklistItem{ID: 1, TaskID: 1, Title: "Turn off the power", Position: 1, Mandatory: true, Done: true, CompletedAt: &now, CompletedBy: &userID}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE `checklist_items` SET `title`=(.*)`position`=(.*)`mandatory`=(.*)`done`=(.*)`completed_at`=(.*)`completed_by`=(.*)`updated_at`").
+		WithArgs(item.Title, item.Position, item.Mandatory, item.Done, sqlmock.AnyArg(), userID, sqlmock.AnyArg(), item.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	dbItem, err := s.checklistRepository.Update(&item)
+	require.NoError(s.T(), err)
+	require.True(s.T(), dbItem.Done)
+}
+
+func (s *checklistSuite) TestCountPendingMandatory_Success() {
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `checklist_items` WHERE task_id = (.*) AND mandatory = (.*) AND done = (.*)").
+		WithArgs(uint64(1), true, false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	pending, err := s.checklistRepository.CountPendingMandatory(uint64(1))
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), int64(2), pending)
+}
+
+func (s *checklistSuite) TestDeleteChecklistItem_NotFound() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM `checklist_items`").
+		WithArgs(uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err := s.checklistRepository.Delete(uint64(1))
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, err.Status())
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type commentSuite struct {
+	suite.Suite
+	DB                *gorm.DB
+	mock              sqlmock.Sqlmock
+	commentRepository repositories.CommentRepoInterface
+}
+
+func (s *commentSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.commentRepository = repositories.NewCommentRepository(s.DB)
+}
+
+func (s *commentSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestCommentInit(t *testing.T) {
+	suite.Run(t, new(commentSuite))
+}
+
+func (s *commentSuite) TestCreateComment_Success() {
+	comment := models.Comment{TaskID: 1, AuthorID: 2, Body: "The spare part is on its way"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("INSERT INTO `comments`").
+		WithArgs(comment.TaskID, comment.AuthorID, comment.Body, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	dbComment, err := s.commentRepository.Create(&comment)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(1), dbComment.ID)
+}
+
+func (s *commentSuite) TestGetCommentsByTask_Success() {
+	s.mock.ExpectQuery("SELECT (.*) FROM `comments` WHERE `comments`.`task_id` = (.*) ORDER BY created_at, id").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "author_id", "body", "created_at", "updated_at"}).
+			AddRow(1, 1, 1, "First", tm, tm).
+			AddRow(2, 1, 2, "Second", tm, tm))
+
+	comments, err := s.commentRepository.GetAllByTaskID(uint64(1))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, len(comments))
+}
+
+func (s *commentSuite) TestUpdateComment_Success() {
+	comment := models.Comment{ID: 1, TaskID: 1, AuthorID: 2, Body: "Edited"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE `comments` SET `body`=(.*)`updated_at`").
+		WithArgs(comment.Body, sqlmock.AnyArg(), comment.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	dbComment, err := s.commentRepository.Update(&comment)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "Edited", dbComment.Body)
+}
+
+func (s *commentSuite) TestDeleteComment_NotFound() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM `comments`").
+		WithArgs(uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err := s.commentRepository.Delete(uint64(1))
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, err.Status())
+}
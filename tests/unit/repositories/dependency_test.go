@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type dependencySuite struct {
+	suite.Suite
+	DB                   *gorm.DB
+	mock                 sqlmock.Sqlmock
+	dependencyRepository repositories.DependencyRepoInterface
+}
+
+func (s *dependencySuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.dependencyRepository = repositories.NewDependencyRepository(s.DB)
+}
+
+func (s *dependencySuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestDependencyInit(t *testing.T) {
+	suite.Run(t, new(dependencySuite))
+}
+
+func (s *dependencySuite) TestCreateDependency_Success() {
+	dependency := models.TaskDependency{TaskID: 1, BlockedByID: 2, CreatedBy: 1}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT `blocked_by_id` FROM `task_dependencies` WHERE task_id IN (.*) FOR SHARE").
+		WithArgs(uint64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"blocked_by_id"}).AddRow(3))
+	s.mock.ExpectQuery("SELECT `blocked_by_id` FROM `task_dependencies` WHERE task_id IN (.*) FOR SHARE").
+		WithArgs(uint64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"blocked_by_id"}))
+	s.mock.ExpectExec("INSERT INTO `task_dependencies` (.*) ON DUPLICATE KEY UPDATE").
+		WithArgs(dependency.TaskID, dependency.BlockedByID, dependency.CreatedBy, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	dbDependency, err := s.dependencyRepository.Create(&dependency)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), uint64(2), dbDependency.BlockedByID)
+}
+
+func (s *dependencySuite) TestCreateDependency_Cycle() {
+	dependency := models.TaskDependency{TaskID: 1, BlockedByID: 2, CreatedBy: 1}
+
+	// 2 is blocked by 3 which is blocked by 1, so 1 can't be blocked by 2
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT `blocked_by_id` FROM `task_dependencies` WHERE task_id IN (.*) FOR SHARE").
+		WithArgs(uint64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"blocked_by_id"}).AddRow(3))
+	s.mock.ExpectQuery("SELECT `blocked_by_id` FROM `task_dependencies` WHERE task_id IN (.*) FOR SHARE").
+		WithArgs(uint64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"blocked_by_id"}).AddRow(1))
+	s.mock.ExpectRollback()
+
+	dbDependency, err := s.dependencyRepository.Create(&dependency)
+	require.Nil(s.T(), dbDependency)
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusConflict, err.Status())
+}
+
+func (s *dependencySuite) TestBlockedTaskIDs_Success() {
+	s.mock.ExpectQuery("SELECT DISTINCT `task_dependencies`.`task_id` FROM `task_dependencies` JOIN tasks (.*) WHERE task_dependencies.task_id IN (.*) AND tasks.status NOT IN").
+		WithArgs(uint64(1), uint64(2), models.TaskStatusDone, models.TaskStatusCancelled).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id"}).AddRow(2))
+
+	blocked, err := s.dependencyRepository.BlockedTaskIDs([]uint64{1, 2})
+	require.Nil(s.T(), err)
+	require.False(s.T(), blocked[1])
+	require.True(s.T(), blocked[2])
+}
+
+func (s *dependencySuite) TestUnblocked_Success() {
+	s.mock.ExpectQuery("SELECT `task_dependencies`.`task_id` FROM `task_dependencies` JOIN tasks (.*) WHERE task_dependencies.blocked_by_id = (.*) AND NOT EXISTS").
+		WithArgs(uint64(2), models.TaskStatusDone, models.TaskStatusCancelled).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id"}).AddRow(1))
+
+	taskIDs, err := s.dependencyRepository.Unblocked(uint64(2))
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), []uint64{1}, taskIDs)
+}
+
+func (s *dependencySuite) TestDeleteDependency_NotFound() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM `task_dependencies` WHERE task_id = (.*) AND blocked_by_id = ").
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err := s.dependencyRepository.Delete(uint64(1), uint64(2))
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, err.Status())
+}
@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type invitationSuite struct {
+	suite.Suite
+	DB                   *gorm.DB
+	mock                 sqlmock.Sqlmock
+	invitationRepository repositories.InvitationRepoInterface
+}
+
+func (s *invitationSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.invitationRepository = repositories.NewInvitationRepository(s.DB)
+}
+
+func (s *invitationSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestInvitationInit(t *testing.T) {
+	suite.Run(t, new(invitationSuite))
+}
+
+var invitationColumns = []string{"id", "email", "type", "token_hash", "invited_by", "expires_at", "accepted_at", "user_id", "created_at", "updated_at"}
+
+func (s *invitationSuite) TestCreateInvitation_Success() {
+	invitation := &models.Invitation{Email: "new@test.com", Type: "Manager", TokenHash: "hash", InvitedBy: 2, ExpiresAt: time.Now().Add(time.Hour)}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE email = (.+)").
+		WithArgs("new@test.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectExec("INSERT INTO `invitations`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	delivered := 0
+	dbInvitation, err := s.invitationRepository.Create(invitation, func(invitation *models.Invitation) error {
+		delivered++
+		return nil
+	})
+
+	s.Nil(err)
+	s.Equal(1, delivered)
+	s.Equal(uint64(1), dbInvitation.ID)
+}
+
+func (s *invitationSuite) TestCreateInvitation_EmailRegistered() {
+	invitation := &models.Invitation{Email: "user@test.com", Type: "Manager", TokenHash: "hash", InvitedBy: 2, ExpiresAt: time.Now().Add(time.Hour)}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE email = (.+)").
+		WithArgs("user@test.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectRollback()
+
+	dbInvitation, err := s.invitationRepository.Create(invitation, func(invitation *models.Invitation) error {
+		s.Fail("the invitation shouldn't be sent")
+		return nil
+	})
+
+	s.Nil(dbInvitation)
+	s.Equal(http.StatusConflict, err.Status())
+	s.Equal("the email user@test.com is already registered", err.Message())
+}
+
+func (s *invitationSuite) TestCreateInvitation_DeliveryFails() {
+	invitation := &models.Invitation{Email: "new@test.com", Type: "Technician", TokenHash: "hash", InvitedBy: 2, ExpiresAt: time.Now().Add(time.Hour)}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE email = (.+)").
+		WithArgs("new@test.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectExec("INSERT INTO `invitations`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM `invitations` WHERE `invitations`.`id` = (.+)").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	// the invitation is committed before the email is sent, then removed
+	dbInvitation, err := s.invitationRepository.Create(invitation, func(invitation *models.Invitation) error {
+		return errors.New("connection refused")
+	})
+
+	s.Nil(dbInvitation)
+	s.Equal(http.StatusInternalServerError, err.Status())
+	s.Equal("it's not possible to send the invitation: connection refused", err.Message())
+}
+
+func (s *invitationSuite) TestAcceptInvitation_Success() {
+	now := time.Now()
+	request := models.InvitationAcceptRequest{Token: "token", Name: "New user", Password: "hashed"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `invitations` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs(models.HashInvitationToken("token")).
+		WillReturnRows(sqlmock.NewRows(invitationColumns).
+			AddRow(1, "new@test.com", "Manager", models.HashInvitationToken("token"), 2, now.Add(time.Hour), nil, nil, now, now))
+	s.mock.ExpectExec("INSERT INTO `users`").
+		WithArgs("New user", "new@test.com", "hashed", "Manager", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, nil).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	s.mock.ExpectExec("UPDATE `invitations` SET `accepted_at`=(.+),`user_id`=(.+),`updated_at`=(.+) WHERE `id` = (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	user, err := s.invitationRepository.Accept(request, now)
+
+	s.Nil(err)
+	s.Equal(uint64(7), user.ID)
+	s.Equal("Manager", user.Type)
+	s.Equal("new@test.com", user.Email)
+}
+
+func (s *invitationSuite) TestAcceptInvitation_AlreadyAccepted() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `invitations` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs(models.HashInvitationToken("token")).
+		WillReturnRows(sqlmock.NewRows(invitationColumns).
+			AddRow(1, "new@test.com", "Manager", models.HashInvitationToken("token"), 2, now.Add(time.Hour), now, 7, now, now))
+	s.mock.ExpectRollback()
+
+	user, err := s.invitationRepository.Accept(models.InvitationAcceptRequest{Token: "token", Name: "New user", Password: "hashed"}, now)
+
+	s.Nil(user)
+	s.Equal(http.StatusConflict, err.Status())
+	s.Equal("the invitation was already accepted", err.Message())
+}
+
+func (s *invitationSuite) TestAcceptInvitation_Expired() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `invitations` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs(models.HashInvitationToken("token")).
+		WillReturnRows(sqlmock.NewRows(invitationColumns).
+			AddRow(1, "new@test.com", "Manager", models.HashInvitationToken("token"), 2, now.Add(-time.Minute), nil, nil, now, now))
+	s.mock.ExpectRollback()
+
+	user, err := s.invitationRepository.Accept(models.InvitationAcceptRequest{Token: "token", Name: "New user", Password: "hashed"}, now)
+
+	s.Nil(user)
+	s.Equal(http.StatusConflict, err.Status())
+	s.Equal("the invitation expired", err.Message())
+}
+
+func (s *invitationSuite) TestAcceptInvitation_UnknownToken() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `invitations` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs(models.HashInvitationToken("unknown")).
+		WillReturnRows(sqlmock.NewRows(invitationColumns))
+	s.mock.ExpectRollback()
+
+	user, err := s.invitationRepository.Accept(models.InvitationAcceptRequest{Token: "unknown", Name: "New user", Password: "hashed"}, time.Now())
+
+	s.Nil(user)
+	s.Equal(http.StatusNotFound, err.Status())
+}
+
+func (s *invitationSuite) TestDeleteInvitation_Accepted() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `invitations` WHERE `invitations`.`id` = (.+) FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(invitationColumns).
+			AddRow(1, "new@test.com", "Manager", "hash", 2, now.Add(time.Hour), now, 7, now, now))
+	s.mock.ExpectRollback()
+
+	err := s.invitationRepository.Delete(1)
+
+	s.Equal(http.StatusConflict, err.Status())
+	s.Equal("the invitation was already accepted", err.Message())
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type locationSuite struct {
+	suite.Suite
+	DB                 *gorm.DB
+	mock               sqlmock.Sqlmock
+	locationRepository repositories.LocationRepoInterface
+}
+
+func (s *locationSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.locationRepository = repositories.NewLocationRepository(s.DB)
+}
+
+func (s *locationSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestLocationInit(t *testing.T) {
+	suite.Run(t, new(locationSuite))
+}
+
+func (s *locationSuite) TestCreateLocation_Success() {
+	parentID := uint64(1)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `locations` WHERE `locations`.`id` = (.+) LIMIT 1 FOR SHARE").
+		WithArgs(parentID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "name", "path"}).AddRow(1, models.LocationKindSite, "Head office", "/1/"))
+	s.mock.ExpectExec("INSERT INTO `locations`").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	s.mock.ExpectExec("UPDATE `locations` SET `path`=(.+)").
+		WithArgs("/1/4/", sqlmock.AnyArg(), uint64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	location, err := s.locationRepository.Create(&models.Location{ParentID: &parentID, Kind: models.LocationKindBuilding, Name: "North wing"})
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), "/1/4/", location.Path)
+}
+
+func (s *locationSuite) TestCreateLocation_WrongParentKind() {
+	parentID := uint64(1)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `locations` WHERE `locations`.`id` = (.+) LIMIT 1 FOR SHARE").
+		WithArgs(parentID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "name", "path"}).AddRow(1, models.LocationKindSite, "Head office", "/1/"))
+	s.mock.ExpectRollback()
+
+	_, err := s.locationRepository.Create(&models.Location{ParentID: &parentID, Kind: models.LocationKindRoom, Name: "Server room"})
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, err.Status())
+	require.Equal(s.T(), "a room needs a parent floor, the location 1 is a site", err.Message())
+}
+
+func (s *locationSuite) TestDeleteLocation_HasChildren() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `locations` WHERE parent_id = ?").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tasks` WHERE location_id = ?").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `assets` WHERE location_id = ?").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectRollback()
+
+	err := s.locationRepository.Delete(1)
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusConflict, err.Status())
+}
+
+func (s *locationSuite) TestSetUserSites_NotOnlySites() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `locations` WHERE id IN \\((.+)\\) AND kind = ?").
+		WithArgs(uint64(1), uint64(4), models.LocationKindSite).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectRollback()
+
+	err := s.locationRepository.SetUserSites(3, []uint64{1, 4})
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, err.Status())
+	require.Equal(s.T(), "a manager can only be scoped to sites", err.Message())
+}
+
+func (s *locationSuite) TestSetUserSites_Success() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `locations` WHERE id IN \\((.+)\\) AND kind = ?").
+		WithArgs(uint64(1), models.LocationKindSite).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectExec("DELETE FROM `user_sites` WHERE user_id = ?").
+		WithArgs(uint64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("INSERT INTO `user_sites`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.locationRepository.SetUserSites(3, []uint64{1})
+	require.Nil(s.T(), err)
+}
+
+func (s *locationSuite) TestIsInSites_OutOfSites() {
+	locationID := uint64(21)
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `locations` WHERE id = (.+) AND id IN \\(SELECT subtree.id FROM locations AS subtree JOIN locations AS root ON subtree.path LIKE CONCAT\\(root.path, '%'\\) WHERE root.id IN \\((.+)\\)\\)").
+		WithArgs(locationID, uint64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	located, err := s.locationRepository.IsInSites(&locationID, []uint64{10})
+	require.Nil(s.T(), err)
+	require.False(s.T(), located)
+}
+
+func (s *locationSuite) TestIsInSites_WithoutLocation() {
+	located, err := s.locationRepository.IsInSites(nil, []uint64{10})
+	require.Nil(s.T(), err)
+	require.False(s.T(), located)
+
+	located, err = s.locationRepository.IsInSites(nil, nil)
+	require.Nil(s.T(), err)
+	require.True(s.T(), located)
+}
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type passwordSuite struct {
+	suite.Suite
+	DB                 *gorm.DB
+	mock               sqlmock.Sqlmock
+	passwordRepository repositories.PasswordRepoInterface
+}
+
+func (s *passwordSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.passwordRepository = repositories.NewPasswordRepository(s.DB)
+}
+
+func (s *passwordSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestPasswordInit(t *testing.T) {
+	suite.Run(t, new(passwordSuite))
+}
+
+var passwordResetColumns = []string{"id", "user_id", "token_hash", "expires_at", "used_at", "created_at"}
+
+// expectRecentResets expects the lock of the user and the count of its resets sent during the cooldown.
+func (s *passwordSuite) expectRecentResets(userID uint64, recent int) {
+	s.mock.ExpectQuery("SELECT `id` FROM `users` WHERE `users`.`id` = (.+) FOR UPDATE").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+	s.mock.ExpectQuery("SELECT count(.+) FROM `password_resets` WHERE user_id = (.+) AND used_at IS NULL AND created_at > (.+)").
+		WithArgs(userID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(recent))
+}
+
+func (s *passwordSuite) TestCreateReset_Success() {
+	reset := &models.PasswordReset{UserID: 1, TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+
+	s.mock.ExpectBegin()
+	s.expectRecentResets(1, 0)
+	s.mock.ExpectExec("UPDATE `password_resets` SET `used_at`=(.+) WHERE user_id = (.+) AND used_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("INSERT INTO `password_resets`").
+		WithArgs(1, "hash", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	s.mock.ExpectCommit()
+
+	delivered := false
+	err := s.passwordRepository.CreateReset(reset, time.Now().Add(-5*time.Minute), func(reset *models.PasswordReset) error {
+		delivered = true
+		return nil
+	})
+
+	s.Nil(err)
+	s.True(delivered)
+	s.Equal(uint64(2), reset.ID)
+}
+
+func (s *passwordSuite) TestCreateReset_DeliveryFails() {
+	reset := &models.PasswordReset{UserID: 1, TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+
+	s.mock.ExpectBegin()
+	s.expectRecentResets(1, 0)
+	s.mock.ExpectExec("UPDATE `password_resets` SET `used_at`=(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("INSERT INTO `password_resets`").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	s.mock.ExpectCommit()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DELETE FROM `password_resets` WHERE `password_resets`.`id` = (.+)").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.passwordRepository.CreateReset(reset, time.Now().Add(-5*time.Minute), func(reset *models.PasswordReset) error {
+		return errors.New("connection refused")
+	})
+
+	s.Equal(http.StatusInternalServerError, err.Status())
+	s.Equal("it's not possible to send the reset email: connection refused", err.Message())
+}
+
+func (s *passwordSuite) TestCreateReset_TooSoon() {
+	reset := &models.PasswordReset{UserID: 1, TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+
+	// the pending reset stays usable and nothing is sent
+	s.mock.ExpectBegin()
+	s.expectRecentResets(1, 1)
+	s.mock.ExpectRollback()
+
+	err := s.passwordRepository.CreateReset(reset, time.Now().Add(-5*time.Minute), func(reset *models.PasswordReset) error {
+		s.Fail("the reset shouldn't be sent")
+		return nil
+	})
+
+	s.Equal(http.StatusConflict, err.Status())
+	s.Equal("a reset was already sent recently", err.Message())
+}
+
+func (s *passwordSuite) TestReset_Success() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `password_resets` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(passwordResetColumns).
+			AddRow(2, 1, "hash", now.Add(time.Hour), nil, now.Add(-time.Minute)))
+	s.mock.ExpectQuery("SELECT (.+) FROM `users` WHERE `users`.`id` = (.+)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "password", "type"}).
+			AddRow(1, "Test user", "old", "Technician"))
+	s.mock.ExpectExec("UPDATE `users` SET `password`=(.+),`updated_at`=(.+),`password_changed_at`=(.+) WHERE (.+)").
+		WithArgs("new", sqlmock.AnyArg(), now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE `password_resets` SET `used_at`=(.+) WHERE `id` = (.+)").
+		WithArgs(now, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	user, err := s.passwordRepository.Reset("hash", "new", now)
+
+	s.Nil(err)
+	s.Equal("new", user.Password)
+	s.Equal(now, *user.PasswordChangedAt)
+}
+
+func (s *passwordSuite) TestReset_Used() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `password_resets` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(passwordResetColumns).
+			AddRow(2, 1, "hash", now.Add(time.Hour), now.Add(-time.Minute), now.Add(-time.Hour)))
+	s.mock.ExpectRollback()
+
+	_, err := s.passwordRepository.Reset("hash", "new", now)
+
+	s.Equal(http.StatusConflict, err.Status())
+	s.Equal("the reset token was already used", err.Message())
+}
+
+func (s *passwordSuite) TestReset_Expired() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `password_resets` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(passwordResetColumns).
+			AddRow(2, 1, "hash", now.Add(-time.Minute), nil, now.Add(-time.Hour)))
+	s.mock.ExpectRollback()
+
+	_, err := s.passwordRepository.Reset("hash", "new", now)
+
+	s.Equal(http.StatusConflict, err.Status())
+	s.Equal("the reset token expired", err.Message())
+}
+
+func (s *passwordSuite) TestReset_UnknownToken() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `password_resets` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("unknown").
+		WillReturnError(gorm.ErrRecordNotFound)
+	s.mock.ExpectRollback()
+
+	_, err := s.passwordRepository.Reset("unknown", "new", time.Now())
+
+	s.Equal(http.StatusNotFound, err.Status())
+}
@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type scheduleSuite struct {
+	suite.Suite
+	DB                 *gorm.DB
+	mock               sqlmock.Sqlmock
+	scheduleRepository repositories.ScheduleRepoInterface
+	leaseRepository    repositories.LeaseRepoInterface
+}
+
+func (s *scheduleSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.scheduleRepository = repositories.NewScheduleRepository(s.DB)
+	s.leaseRepository = repositories.NewLeaseRepository(s.DB)
+}
+
+func (s *scheduleSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestScheduleInit(t *testing.T) {
+	suite.Run(t, new(scheduleSuite))
+}
+
+func (s *scheduleSuite) TestMaterialize_CreatesTheOccurrencesWithinTheLeadTime() {
+	now := time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC)
+	nextRun := time.Date(2022, time.March, 2, 8, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) FROM `schedules` WHERE active = (.*) AND next_run_at <= (.*) FOR UPDATE SKIP LOCKED").
+		WithArgs(true, now.Add(24*time.Hour)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "recurrence", "timezone", "assignee_id", "priority", "active", "next_run_at"}).
+			AddRow(1, "Daily boiler check", "0 8 * * *", "UTC", 3, models.TaskPriorityHigh, true, nextRun))
+	s.mock.ExpectExec("INSERT INTO `tasks` (.*) ON DUPLICATE KEY UPDATE").
+		WithArgs("Daily boiler check", models.TaskStatusOpen, uint64(3), nil, nil, nil, models.TaskPriorityHigh, nextRun, nil, nil, uint64(1), nextRun, nil, nil, uint64(1), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(uint64(10), uint64(0), models.TaskEventCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("UPDATE `schedules` SET `next_run_at`=(.*) WHERE `id` = ").
+		WithArgs(nextRun.Add(24*time.Hour), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	tasks, err := s.scheduleRepository.Materialize(now, 24*time.Hour)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 1, len(tasks))
+	require.Equal(s.T(), uint64(10), tasks[0].ID)
+	require.Equal(s.T(), nextRun, *tasks[0].DueAt)
+}
+
+func (s *scheduleSuite) TestMaterialize_AlreadyCreated() {
+	now := time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC)
+	nextRun := time.Date(2022, time.March, 2, 8, 0, 0, 0, time.UTC)
+
+	// another replica created the task but couldn't move the schedule forward
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) FROM `schedules`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "recurrence", "timezone", "assignee_id", "priority", "active", "next_run_at"}).
+			AddRow(1, "Daily boiler check", "0 8 * * *", "UTC", 3, models.TaskPriorityHigh, true, nextRun))
+	s.mock.ExpectExec("INSERT INTO `tasks` (.*) ON DUPLICATE KEY UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("UPDATE `schedules` SET `next_run_at`").
+		WithArgs(nextRun.Add(24*time.Hour), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	tasks, err := s.scheduleRepository.Materialize(now, 24*time.Hour)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 0, len(tasks))
+}
+
+func (s *scheduleSuite) TestMaterialize_SkipsMissedOccurrences() {
+	now := time.Date(2022, time.March, 10, 9, 0, 0, 0, time.UTC)
+	missedRun := time.Date(2022, time.March, 2, 8, 0, 0, 0, time.UTC)
+	nextRun := time.Date(2022, time.March, 11, 8, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) FROM `schedules`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "recurrence", "timezone", "assignee_id", "priority", "active", "next_run_at"}).
+			AddRow(1, "Daily boiler check", "0 8 * * *", "UTC", 3, models.TaskPriorityHigh, true, missedRun))
+	s.mock.ExpectExec("INSERT INTO `tasks`").
+		WithArgs("Daily boiler check", models.TaskStatusOpen, uint64(3), nil, nil, nil, models.TaskPriorityHigh, nextRun, nil, nil, uint64(1), nextRun, nil, nil, uint64(1), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("UPDATE `schedules` SET `next_run_at`").
+		WithArgs(nextRun.Add(24*time.Hour), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	tasks, err := s.scheduleRepository.Materialize(now, 24*time.Hour)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 1, len(tasks))
+}
+
+func (s *scheduleSuite) TestAcquireLease_New() {
+	now := time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) FROM `job_leases` WHERE name = (.*) FOR UPDATE").
+		WithArgs("schedules").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "holder", "expires_at"}))
+	s.mock.ExpectExec("INSERT INTO `job_leases`").
+		WithArgs("schedules", "replica-1", now.Add(time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	acquired, err := s.leaseRepository.Acquire("schedules", "replica-1", now, time.Minute)
+	require.Nil(s.T(), err)
+	require.True(s.T(), acquired)
+}
+
+func (s *scheduleSuite) TestAcquireLease_HeldByAnotherReplica() {
+	now := time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) FROM `job_leases` WHERE name = (.*) FOR UPDATE").
+		WithArgs("schedules").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "holder", "expires_at"}).AddRow("schedules", "replica-2", now.Add(time.Minute)))
+	s.mock.ExpectCommit()
+
+	acquired, err := s.leaseRepository.Acquire("schedules", "replica-1", now, time.Minute)
+	require.Nil(s.T(), err)
+	require.False(s.T(), acquired)
+}
+
+func (s *scheduleSuite) TestAcquireLease_Expired() {
+	now := time.Date(2022, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) FROM `job_leases` WHERE name = (.*) FOR UPDATE").
+		WithArgs("schedules").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "holder", "expires_at"}).AddRow("schedules", "replica-2", now.Add(-time.Second)))
+	s.mock.ExpectExec("UPDATE `job_leases` SET `expires_at`=(.*),`holder`=(.*) WHERE `name` = ").
+		WithArgs(now.Add(time.Minute), "replica-1", "schedules").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	acquired, err := s.leaseRepository.Acquire("schedules", "replica-1", now, time.Minute)
+	require.Nil(s.T(), err)
+	require.True(s.T(), acquired)
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"api/app/repositories"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type tagSuite struct {
+	suite.Suite
+	DB            *gorm.DB
+	mock          sqlmock.Sqlmock
+	tagRepository repositories.TagRepoInterface
+}
+
+func (s *tagSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.tagRepository = repositories.NewTagRepository(s.DB)
+}
+
+func (s *tagSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestTagInit(t *testing.T) {
+	suite.Run(t, new(tagSuite))
+}
+
+func (s *tagSuite) TestSetTaskTags_Success() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `tags` WHERE id IN (.+) ORDER BY name").
+		WithArgs(uint64(2), uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "HVAC").AddRow(2, "Plumbing"))
+	s.mock.ExpectExec("DELETE FROM `task_tags` WHERE task_id = ?").
+		WithArgs(uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("INSERT INTO `task_tags`").
+		WithArgs(uint64(1), uint64(1), sqlmock.AnyArg(), uint64(1), uint64(2), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+
+	tags, err := s.tagRepository.SetTaskTags(1, []uint64{2, 1})
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 2, len(tags))
+	require.Equal(s.T(), "HVAC", tags[0].Name)
+}
+
+func (s *tagSuite) TestSetTaskTags_UnknownTag() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `tags` WHERE id IN (.+) ORDER BY name").
+		WithArgs(uint64(1), uint64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "HVAC"))
+	s.mock.ExpectRollback()
+
+	_, err := s.tagRepository.SetTaskTags(1, []uint64{1, 9})
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, err.Status())
+	require.Equal(s.T(), "some of the tags don't exist", err.Message())
+}
+
+func (s *tagSuite) TestCounts_OwnTasks() {
+	s.mock.ExpectQuery("SELECT tags.\\*, COUNT\\(task_tags.task_id\\) AS tasks FROM `tags` LEFT JOIN task_tags ON (.+) AND task_tags.task_id IN \\(SELECT `id` FROM `tasks` WHERE \\(assignee_id = (.+) OR \\(assignee_id IS NULL AND user_id = (.+)\\)\\) AND `tasks`.`deleted_at` IS NULL\\) GROUP BY `tags`.`id` ORDER BY tags.name").
+		WithArgs(uint64(1), uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "tasks"}).AddRow(1, "HVAC", 3).AddRow(2, "Plumbing", 0))
+
+	counts, err := s.tagRepository.Counts(1, nil)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 2, len(counts))
+	require.Equal(s.T(), int64(3), counts[0].Tasks)
+	require.Equal(s.T(), int64(0), counts[1].Tasks)
+}
+
+func (s *tagSuite) TestGetByTaskIDs_Success() {
+	s.mock.ExpectQuery("SELECT tags.\\*, task_tags.task_id FROM `tags` JOIN task_tags ON (.+) WHERE task_tags.task_id IN (.+)").
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "task_id"}).AddRow(1, "HVAC", 1).AddRow(2, "Plumbing", 1).AddRow(1, "HVAC", 2))
+
+	tagsByTask, err := s.tagRepository.GetByTaskIDs([]uint64{1, 2})
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 2, len(tagsByTask[1]))
+	require.Equal(s.T(), 1, len(tagsByTask[2]))
+}
+
+func (s *tagSuite) TestCounts_InSites() {
+	s.mock.ExpectQuery("SELECT tags.\\*, COUNT\\(task_tags.task_id\\) AS tasks FROM `tags` LEFT JOIN task_tags ON (.+) AND task_tags.task_id IN \\(SELECT `id` FROM `tasks` WHERE location_id IN \\(SELECT subtree.id FROM locations AS subtree JOIN locations AS root ON subtree.path LIKE CONCAT\\(root.path, '%'\\) WHERE root.id IN \\((.+)\\)\\) AND `tasks`.`deleted_at` IS NULL\\) GROUP BY `tags`.`id` ORDER BY tags.name").
+		WithArgs(uint64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "tasks"}).AddRow(1, "HVAC", 2))
+
+	counts, err := s.tagRepository.Counts(0, []uint64{10})
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 1, len(counts))
+	require.Equal(s.T(), int64(2), counts[0].Tasks)
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"api/app/models"
+	"net/http"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *taskSuite) TestBulk_BestEffort() {
+	request := models.TaskBulkRequest{
+		Mode: models.TaskBulkModeBestEffort,
+		Operations: []models.TaskBulkOperation{
+			{Action: models.TaskBulkActionUpdateStatus, TaskID: 1, Status: models.TaskStatusInProgress},
+			{Action: models.TaskBulkActionDelete, TaskID: 2},
+		},
+	}
+	require.NoError(s.T(), request.Prepare())
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.expectSelectTask(1, "Bulk summary", models.TaskStatusOpen)
+	s.expectSelectTask(1, "Bulk summary", models.TaskStatusOpen)
+	s.mock.ExpectExec("UPDATE `tasks` SET `status`=(.+),`completed_at`=(.+),`version`=(.+)").
+		WithArgs(models.TaskStatusInProgress, nil, uint64(2), sqlmock.AnyArg(), uint64(1), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(uint64(1), uint64(2), models.TaskEventStatusChanged, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT(.*)").
+		WithArgs(uint64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectExec("ROLLBACK TO SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	response, err := s.taskRepository.Bulk(request, 2, 0, nil)
+	require.Nil(s.T(), err)
+	require.True(s.T(), response.Committed)
+	require.Equal(s.T(), 1, response.Succeeded)
+	require.Equal(s.T(), 1, response.Failed)
+	require.Equal(s.T(), http.StatusOK, response.Results[0].Status)
+	require.Equal(s.T(), models.TaskStatusInProgress, response.Results[0].Task.Status)
+	require.Equal(s.T(), http.StatusNotFound, response.Results[1].Status)
+}
+
+func (s *taskSuite) TestBulk_AllOrNothing() {
+	request := models.TaskBulkRequest{
+		Operations: []models.TaskBulkOperation{
+			{Action: models.TaskBulkActionTag, TaskID: 1, TagIDs: []uint64{4}},
+			{Action: models.TaskBulkActionUpdateStatus, TaskID: 2, Status: models.TaskStatusDone},
+			{Action: models.TaskBulkActionDelete, TaskID: 3},
+		},
+	}
+	require.NoError(s.T(), request.Prepare())
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.expectSelectTask(1, "Bulk summary", models.TaskStatusOpen)
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tags` WHERE id IN \\((.+)\\)").
+		WithArgs(uint64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectExec("INSERT INTO `task_tags` (.+) ON DUPLICATE KEY UPDATE").
+		WithArgs(uint64(1), uint64(4), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.expectSelectTask(2, "Bulk summary", models.TaskStatusOpen)
+	s.mock.ExpectExec("ROLLBACK TO SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	response, err := s.taskRepository.Bulk(request, 2, 0, nil)
+	require.Nil(s.T(), err)
+	require.False(s.T(), response.Committed)
+	require.Equal(s.T(), models.TaskBulkModeAtomic, response.Mode)
+	require.Equal(s.T(), 0, response.Succeeded)
+	require.Equal(s.T(), 3, response.Failed)
+	require.Equal(s.T(), http.StatusFailedDependency, response.Results[0].Status)
+	require.Equal(s.T(), "rolled back as the operation 1 failed", response.Results[0].Error)
+	require.Equal(s.T(), http.StatusConflict, response.Results[1].Status)
+	require.Equal(s.T(), "not run as the operation 1 failed", response.Results[2].Error)
+	require.Nil(s.T(), response.Results[0].Task)
+}
+
+func (s *taskSuite) TestBulk_NotOwner() {
+	request := models.TaskBulkRequest{
+		Mode:       models.TaskBulkModeBestEffort,
+		Operations: []models.TaskBulkOperation{{Action: models.TaskBulkActionTag, TaskID: 1, TagIDs: []uint64{4}}},
+	}
+	require.NoError(s.T(), request.Prepare())
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.expectSelectTask(1, "Bulk summary", models.TaskStatusOpen)
+	s.mock.ExpectExec("ROLLBACK TO SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	response, err := s.taskRepository.Bulk(request, 5, 5, nil)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), http.StatusForbidden, response.Results[0].Status)
+	require.Equal(s.T(), "the task 1 doesn't belong to you", response.Results[0].Error)
+}
+
+func (s *taskSuite) TestBulk_OutOfSites() {
+	request := models.TaskBulkRequest{
+		Mode:       models.TaskBulkModeBestEffort,
+		Operations: []models.TaskBulkOperation{{Action: models.TaskBulkActionTag, TaskID: 1, TagIDs: []uint64{4}}},
+	}
+	require.NoError(s.T(), request.Prepare())
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT(.*)").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "location_id", "version", "created_at", "updated_at"}).
+			AddRow(1, "Bulk summary", models.TaskStatusOpen, 1, 21, 1, tm, tm))
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `locations` WHERE id = (.+) AND id IN \\(SELECT subtree.id FROM locations AS subtree JOIN locations AS root ON subtree.path LIKE CONCAT\\(root.path, '%'\\) WHERE root.id IN \\((.+)\\)\\)").
+		WithArgs(uint64(21), uint64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectExec("ROLLBACK TO SAVEPOINT sp(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	response, err := s.taskRepository.Bulk(request, 2, 0, []uint64{10})
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), http.StatusForbidden, response.Results[0].Status)
+	require.Equal(s.T(), "the task 1 isn't located in your sites", response.Results[0].Error)
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"api/app/models"
+	"errors"
+	"net/http"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var exportColumns = []string{"id", "summary", "status", "priority", "user_id", "user_name", "assignee_id", "assignee_name",
+	"asset_id", "location_id", "due_at", "completed_at", "created_at", "updated_at"}
+
+func (s *taskSuite) TestExport() {
+	query := models.TaskQuery{Status: models.TaskStatusOpen, Sort: "id"}
+	require.NoError(s.T(), query.Prepare())
+
+	s.mock.ExpectQuery("SELECT tasks.id, (.+)\\(SELECT users.name FROM users WHERE users.id = tasks.user_id\\) AS user_name(.+) " +
+		"FROM `tasks` WHERE status = (.+) ORDER BY id ASC$").
+		WithArgs(models.TaskStatusOpen).
+		WillReturnRows(sqlmock.NewRows(exportColumns).
+			AddRow(1, "Export summary 1", models.TaskStatusOpen, models.TaskPriorityHigh, 1, "Alice", 3, "Bob", nil, nil, nil, nil, tm, tm).
+			AddRow(2, "Export summary 2", models.TaskStatusOpen, models.TaskPriorityLow, 1, "Alice", nil, nil, nil, nil, nil, nil, tm, tm))
+
+	rows := []models.TaskExportRow{}
+	err := s.taskRepository.Export(query, func(row *models.TaskExportRow) error {
+		rows = append(rows, *row)
+		return nil
+	})
+
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 2, len(rows))
+	require.Equal(s.T(), "Alice", rows[0].UserName)
+	require.Equal(s.T(), "Bob", *rows[0].AssigneeName)
+	require.Equal(s.T(), uint64(3), *rows[0].AssigneeID)
+	require.Nil(s.T(), rows[1].AssigneeName)
+	require.Nil(s.T(), rows[1].AssigneeID)
+}
+
+func (s *taskSuite) TestExport_WriteError() {
+	query := models.TaskQuery{}
+	require.NoError(s.T(), query.Prepare())
+
+	s.mock.ExpectQuery("SELECT tasks.id, (.+) FROM `tasks` (.+) ORDER BY created_at DESC, id DESC$").
+		WillReturnRows(sqlmock.NewRows(exportColumns).
+			AddRow(1, "Export summary 1", models.TaskStatusOpen, models.TaskPriorityHigh, 1, "Alice", nil, nil, nil, nil, nil, nil, tm, tm).
+			AddRow(2, "Export summary 2", models.TaskStatusOpen, models.TaskPriorityLow, 1, "Alice", nil, nil, nil, nil, nil, nil, tm, tm))
+
+	written := 0
+	err := s.taskRepository.Export(query, func(row *models.TaskExportRow) error {
+		written++
+		return errors.New("broken pipe")
+	})
+
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusInternalServerError, err.Status())
+	require.Equal(s.T(), 1, written)
+}
@@ -3,9 +3,14 @@ package repositories
 import (
 	"api/app/models"
 	"api/app/repositories"
+	"api/app/storage"
+	"context"
 	"database/sql"
 	"errors"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
@@ -52,9 +57,20 @@ func TestTaskInit(t *testing.T) {
 	suite.Run(t, new(taskSuite))
 }
 
+// expectSelectTask expects the lookup of the current version of a task made before every change.
+func (s *taskSuite) expectSelectTask(id uint64, summary string, status string) {
+	s.mock.ExpectQuery("SELECT(.*)").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "assignee_id", "version", "created_at", "updated_at"}).
+			AddRow(id, summary, status, 1, nil, 1, tm, tm))
+}
+
 func (s *taskSuite) TestCreateTask_Success() {
 	task := models.Task{
 		Summary:   "Creating a summary",
+		Status:    models.TaskStatusOpen,
+		Priority:  models.TaskPriorityHigh,
+		DueAt:     &tm,
 		UserID:    1,
 		CreatedAt: tm,
 		UpdatedAt: tm,
@@ -62,7 +78,10 @@ func (s *taskSuite) TestCreateTask_Success() {
 
 	s.mock.ExpectBegin()
 	s.mock.ExpectExec("INSERT INTO `tasks`").
-		WithArgs(task.Summary, task.UserID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(task.Summary, task.Status, task.UserID, task.AssigneeID, nil, nil, task.Priority, tm, nil, nil, nil, nil, nil, nil, uint64(1), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(uint64(1), task.UserID, models.TaskEventCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	s.mock.ExpectCommit()
 
@@ -112,17 +131,45 @@ func (s *taskSuite) TestUpdateTask_Success() {
 	}
 
 	s.mock.ExpectBegin()
+	s.expectSelectTask(task.ID, "Original summary", models.TaskStatusOpen)
 	s.mock.ExpectExec("UPDATE `tasks`").
-		WithArgs(task.Summary, sqlmock.AnyArg(), task.ID).
+		WithArgs(task.Summary, nil, nil, nil, uint64(2), sqlmock.AnyArg(), uint64(1), task.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(task.ID, uint64(1), models.TaskEventUpdated, `{"summary":{"from":"Original summary","to":"Updating a summary"}}`, sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	s.mock.ExpectCommit()
 
-	dbTask, err := s.taskRepository.Update(&task)
+	dbTask, err := s.taskRepository.Update(&task, uint64(1))
 	require.NoError(s.T(), err)
 	require.NotEqual(s.T(), dbTask.ID, uint64(0))
 	require.Equal(s.T(), dbTask.ID, task.ID)
 }
 
+func (s *taskSuite) TestUpdateTask_ClearsFields() {
+	task := models.Task{ID: 1, Summary: "Original summary", Priority: models.TaskPriorityLow, Version: 1}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT(.*)").
+		WithArgs(task.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "asset_id", "location_id", "priority", "due_at", "version", "created_at", "updated_at"}).
+			AddRow(task.ID, task.Summary, models.TaskStatusOpen, 1, 2, 3, models.TaskPriorityHigh, tm, 1, tm, tm))
+	s.mock.ExpectExec("UPDATE `tasks` SET `summary`=(.+),`asset_id`=(.+),`location_id`=(.+),`priority`=(.+),`due_at`=(.+),`version`=(.+),`updated_at`=(.+)").
+		WithArgs(task.Summary, nil, nil, models.TaskPriorityLow, nil, uint64(2), sqlmock.AnyArg(), uint64(1), task.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(task.ID, uint64(1), models.TaskEventUpdated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	dbTask, err := s.taskRepository.Update(&task, uint64(1))
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), dbTask.AssetID)
+	require.Nil(s.T(), dbTask.LocationID)
+	require.Nil(s.T(), dbTask.DueAt)
+	require.Equal(s.T(), models.TaskPriorityLow, dbTask.Priority)
+}
+
 func (s *taskSuite) TestUpdateTask_NotFound() {
 	errorString := "no record matching given the identification"
 	task := models.Task{
@@ -135,97 +182,447 @@ func (s *taskSuite) TestUpdateTask_NotFound() {
 
 	//no record matching given the identification
 	s.mock.ExpectBegin()
-	s.mock.ExpectExec("UPDATE `tasks`").
-		WithArgs(task.Summary, sqlmock.AnyArg(), task.ID).
+	s.mock.ExpectQuery("SELECT(.*)").
+		WithArgs(task.ID).
 		WillReturnError(errors.New(errorString))
 	s.mock.ExpectRollback()
 
-	_, err := s.taskRepository.Update(&task)
+	_, err := s.taskRepository.Update(&task, uint64(1))
 	require.Error(s.T(), err, errorString)
 }
 
-func (s *taskSuite) TestGetAllTasks_Success() {
-	tasks := []models.Task{
-		{
-			ID:        1,
-			Summary:   "Recovering a summary 1",
-			UserID:    1,
-			CreatedAt: tm,
-			UpdatedAt: tm,
-		},
-		{
-			ID:        2,
-			Summary:   "Recovering a summary 2",
-			UserID:    1,
-			CreatedAt: tm,
-			UpdatedAt: tm,
-		},
+func (s *taskSuite) TestUpdateTask_StaleVersion() {
+	task := models.Task{ID: 1, Summary: "Updating a summary", Version: 2}
+
+	s.mock.ExpectBegin()
+	s.expectSelectTask(task.ID, "Original summary", models.TaskStatusOpen)
+	s.mock.ExpectRollback()
+
+	_, err := s.taskRepository.Update(&task, uint64(1))
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusPreconditionFailed, err.Status())
+}
+
+func (s *taskSuite) TestUpdateTask_ConcurrentChange() {
+	task := models.Task{ID: 1, Summary: "Updating a summary", Version: 1}
+
+	s.mock.ExpectBegin()
+	s.expectSelectTask(task.ID, "Original summary", models.TaskStatusOpen)
+	s.mock.ExpectExec("UPDATE `tasks`").
+		WithArgs(task.Summary, nil, nil, nil, uint64(2), sqlmock.AnyArg(), uint64(1), task.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	_, err := s.taskRepository.Update(&task, uint64(1))
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusPreconditionFailed, err.Status())
+}
+
+func (s *taskSuite) TestDeleteTask_Success() {
+	id := uint64(1)
+
+	s.mock.ExpectBegin()
+	s.expectSelectTask(id, "Deleting a summary", models.TaskStatusOpen)
+	s.mock.ExpectExec("UPDATE `tasks` SET `deleted_at`").WithArgs(sqlmock.AnyArg(), uint64(1), id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(id, uint64(2), models.TaskEventDeleted, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	err := s.taskRepository.Delete(&models.Task{ID: id, Version: 1}, uint64(2))
+	require.NoError(s.T(), err)
+}
+
+func (s *taskSuite) TestTaskHistory_Success() {
+	s.mock.ExpectQuery("SELECT (.*) FROM `task_events`").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "actor_id", "action", "changes", "created_at"}).
+			AddRow(1, 1, 1, models.TaskEventCreated, `{"summary":{"from":null,"to":"Creating a summary"}}`, tm).
+			AddRow(2, 1, 2, models.TaskEventDeleted, `{"summary":{"from":"Creating a summary","to":null}}`, tm))
+
+	events, err := s.taskRepository.History(uint64(1), nil)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, len(events))
+	require.Equal(s.T(), "Creating a summary", events[0].Changes["summary"].To)
+	require.Nil(s.T(), events[1].Changes["summary"].To)
+}
+
+func (s *taskSuite) TestUpdateTaskStatus_Success() {
+	task := models.Task{
+		ID:        1,
+		Summary:   "Updating a status",
+		Status:    models.TaskStatusInProgress,
+		UserID:    1,
+		CreatedAt: tm,
+		UpdatedAt: tm,
 	}
 
-	s.mock.ExpectQuery("SELECT(.*)").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "user_id", "created_at", "updated_at"}).
-			AddRow(tasks[0].ID, tasks[0].Summary, tasks[0].UserID, tasks[0].CreatedAt, tasks[0].UpdatedAt).
-			AddRow(tasks[1].ID, tasks[1].Summary, tasks[1].UserID, tasks[1].CreatedAt, tasks[1].UpdatedAt),
-		)
+	s.mock.ExpectBegin()
+	s.expectSelectTask(task.ID, task.Summary, models.TaskStatusOpen)
+	s.mock.ExpectExec("UPDATE `tasks`").
+		WithArgs(task.Status, nil, uint64(2), sqlmock.AnyArg(), uint64(1), task.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(task.ID, uint64(1), models.TaskEventStatusChanged, `{"status":{"from":"open","to":"in_progress"}}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
 
-	dbTask := s.taskRepository.GetAll()
-	require.Equal(s.T(), len(dbTask), 2)
+	dbTask, err := s.taskRepository.UpdateStatus(&task, uint64(1))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), models.TaskStatusInProgress, dbTask.Status)
 }
 
-func (s *taskSuite) TestGetAllTasks_Empty() {
-	s.mock.ExpectQuery("SELECT(.*)").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "user_id", "created_at", "updated_at"}))
-
-	dbTask := s.taskRepository.GetAll()
-	require.Equal(s.T(), len(dbTask), 0)
-}
-
-func (s *taskSuite) TestGetAllTasksByUser_Success() {
-	tasks := []models.Task{
-		{
-			ID:        1,
-			Summary:   "Recovering a summary 1",
-			UserID:    1,
-			CreatedAt: tm,
-			UpdatedAt: tm,
-		},
-		{
-			ID:        2,
-			Summary:   "Recovering a summary 2",
-			UserID:    1,
-			CreatedAt: tm,
-			UpdatedAt: tm,
-		},
+func (s *taskSuite) TestUpdateTaskStatus_InvalidTransition() {
+	// the stored task is done, it can only be opened again
+	task := models.Task{ID: 1, Status: models.TaskStatusInProgress, Version: 1}
+
+	s.mock.ExpectBegin()
+	s.expectSelectTask(task.ID, "Updating a status", models.TaskStatusDone)
+	s.mock.ExpectRollback()
+
+	_, err := s.taskRepository.UpdateStatus(&task, uint64(1))
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusConflict, err.Status())
+}
+
+func (s *taskSuite) TestListTasks_FirstPage() {
+	query := models.TaskQuery{UserID: 1, Status: models.TaskStatusOpen}
+	require.NoError(s.T(), query.Prepare())
+	query.Limit = 1
+
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tasks`").
+		WithArgs(uint64(1), uint64(1), models.TaskStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	s.mock.ExpectQuery("SELECT (.*) ORDER BY created_at DESC, id DESC LIMIT 2").
+		WithArgs(uint64(1), uint64(1), models.TaskStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "created_at", "updated_at"}).
+			AddRow(2, "Recovering a summary 2", models.TaskStatusOpen, 1, tm, tm).
+			AddRow(1, "Recovering a summary 1", models.TaskStatusOpen, 1, tm, tm))
+
+	page, err := s.taskRepository.List(query)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, len(page.Data))
+	require.Equal(s.T(), int64(2), page.Total)
+	require.NotEmpty(s.T(), page.NextCursor)
+
+	cursor, errCursor := models.DecodeTaskCursor(page.NextCursor)
+	require.NoError(s.T(), errCursor)
+	require.Equal(s.T(), uint64(2), cursor.ID)
+}
+
+func (s *taskSuite) TestListTasks_AllTags() {
+	query := models.TaskQuery{TagIDs: []uint64{1, 2}, TagMode: models.TagModeAll}
+	require.NoError(s.T(), query.Prepare())
+
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tasks` WHERE id IN \\(SELECT `task_id` FROM `task_tags` WHERE tag_id IN \\((.+)\\) GROUP BY `task_id` HAVING COUNT\\(DISTINCT tag_id\\) = (.+)\\)").
+		WithArgs(uint64(1), uint64(2), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery("SELECT (.*) FROM `tasks` WHERE id IN \\(SELECT `task_id` FROM `task_tags` (.+)\\) (.+) ORDER BY created_at DESC, id DESC").
+		WithArgs(uint64(1), uint64(2), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "created_at", "updated_at"}).
+			AddRow(1, "Recovering a summary 1", models.TaskStatusOpen, 1, tm, tm))
+
+	page, err := s.taskRepository.List(query)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, len(page.Data))
+	require.Equal(s.T(), int64(1), page.Total)
+}
+
+func (s *taskSuite) TestListTasks_LocationSubtree() {
+	query := models.TaskQuery{LocationID: 4, SiteIDs: []uint64{1}}
+	require.NoError(s.T(), query.Prepare())
+
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tasks` WHERE location_id IN \\(SELECT subtree.id FROM locations AS subtree JOIN locations AS root ON subtree.path LIKE CONCAT\\(root.path, '%'\\) WHERE root.id IN \\((.+)\\)\\) AND location_id IN (.+)").
+		WithArgs(uint64(4), uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery("SELECT (.*) FROM `tasks` WHERE location_id IN (.+) ORDER BY created_at DESC, id DESC").
+		WithArgs(uint64(4), uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "location_id", "created_at", "updated_at"}).
+			AddRow(1, "Recovering a summary 1", models.TaskStatusOpen, 1, 7, tm, tm))
+
+	page, err := s.taskRepository.List(query)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, len(page.Data))
+	require.Equal(s.T(), uint64(7), *page.Data[0].LocationID)
+}
+
+func (s *taskSuite) TestListTasks_AfterCursor() {
+	query := models.TaskQuery{Text: "50%_leak", Sort: models.TaskSortIDAsc, Cursor: &models.TaskCursor{ID: 5}}
+	require.NoError(s.T(), query.Prepare())
+
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tasks`").
+		WithArgs(`%50\%\_leak%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery("SELECT (.*) ORDER BY id ASC LIMIT 21").
+		WithArgs(`%50\%\_leak%`, uint64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "created_at", "updated_at"}).
+			AddRow(6, "Compressor at 50%_leak", models.TaskStatusOpen, 1, tm, tm))
+
+	page, err := s.taskRepository.List(query)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, len(page.Data))
+	require.Empty(s.T(), page.NextCursor)
+}
+
+func (s *taskSuite) TestAssignTask_Success() {
+	task := models.Task{
+		ID:        1,
+		Summary:   "Assigning a task",
+		Status:    models.TaskStatusOpen,
+		UserID:    1,
+		CreatedAt: tm,
+		UpdatedAt: tm,
 	}
 
-	s.mock.ExpectQuery("SELECT(.*)").
-		WithArgs(tasks[0].UserID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "user_id", "created_at", "updated_at"}).
-			AddRow(tasks[0].ID, tasks[0].Summary, tasks[0].UserID, tasks[0].CreatedAt, tasks[0].UpdatedAt).
-			AddRow(tasks[1].ID, tasks[1].Summary, tasks[1].UserID, tasks[1].CreatedAt, tasks[1].UpdatedAt),
-		)
+	s.mock.ExpectBegin()
+	s.expectSelectTask(task.ID, task.Summary, task.Status)
+	s.mock.ExpectExec("UPDATE `tasks`").
+		WithArgs(uint64(3), uint64(2), sqlmock.AnyArg(), uint64(1), task.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(task.ID, uint64(2), models.TaskEventAssigned, `{"assigneeId":{"from":null,"to":3}}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("INSERT INTO `task_assignments`").
+		WithArgs(task.ID, uint64(3), nil, uint64(2), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
 
-	dbTask := s.taskRepository.GetAllByUserID(tasks[0].UserID)
-	require.Equal(s.T(), len(dbTask), 2)
+	dbTask, err := s.taskRepository.Assign(&task, uint64(3), uint64(2))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(3), *dbTask.AssigneeID)
 }
 
-func (s *taskSuite) TestGetAllTasksByUser_Empty() {
-	s.mock.ExpectQuery("SELECT(.*)").
+func (s *taskSuite) TestSearchTasks_Success() {
+	query := models.TaskSearchQuery{Text: "compressor leak", UserID: 1}
+	require.NoError(s.T(), query.Prepare())
+
+	s.mock.ExpectQuery("SELECT tasks.\\*, MATCH\\(summary\\) AGAINST (.*) ORDER BY relevance DESC LIMIT 20").
+		WithArgs(query.Text, query.Text, uint64(1), uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "created_at", "updated_at", "relevance"}).
+			AddRow(1, "Fixed the compressor leak", models.TaskStatusDone, 1, tm, tm, 2.5))
+
+	results, err := s.taskRepository.Search(query)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, len(results))
+	require.Equal(s.T(), uint64(1), results[0].ID)
+	require.Equal(s.T(), 2.5, results[0].Relevance)
+}
+
+func (s *taskSuite) TestListTasks_Trashed() {
+	query := models.TaskQuery{Trashed: true}
+	require.NoError(s.T(), query.Prepare())
+
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tasks` WHERE deleted_at IS NOT NULL$").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery("SELECT \\* FROM `tasks` WHERE deleted_at IS NOT NULL ORDER BY").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "created_at", "updated_at", "deleted_at"}).
+			AddRow(1, "Trashed summary", models.TaskStatusOpen, 1, tm, tm, tm))
+
+	page, err := s.taskRepository.List(query)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, len(page.Data))
+	require.True(s.T(), page.Data[0].DeletedAt.Valid)
+}
+
+func (s *taskSuite) TestRestoreTask_Success() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) WHERE deleted_at IS NOT NULL AND `tasks`.`id` = ?").
 		WithArgs(uint64(1)).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "user_id", "created_at", "updated_at"}))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "created_at", "updated_at", "deleted_at"}).
+			AddRow(1, "Trashed summary", models.TaskStatusOpen, 1, tm, tm, tm))
+	s.mock.ExpectExec("UPDATE `tasks` SET `deleted_at`").
+		WithArgs(nil, sqlmock.AnyArg(), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(uint64(1), uint64(2), models.TaskEventRestored, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
 
-	dbTask := s.taskRepository.GetAllByUserID(uint64(1))
-	require.Equal(s.T(), len(dbTask), 0)
+	task, err := s.taskRepository.Restore(uint64(1), uint64(2), nil)
+	require.NoError(s.T(), err)
+	require.False(s.T(), task.DeletedAt.Valid)
 }
 
-func (s *taskSuite) TestDeleteTask_Success() {
-	id := uint64(1)
+func (s *taskSuite) TestRestoreTask_NotInTrash() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) WHERE deleted_at IS NOT NULL").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "created_at", "updated_at", "deleted_at"}))
+	s.mock.ExpectRollback()
+
+	_, err := s.taskRepository.Restore(uint64(1), uint64(2), nil)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, err.Status())
+}
+
+func (s *taskSuite) TestPurgeTrash_Success() {
+	deletedBefore := tm.Add(-time.Hour)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT `id` FROM `tasks` WHERE deleted_at < ?").
+		WithArgs(deletedBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	s.mock.ExpectExec("DELETE FROM `tasks` WHERE `tasks`.`id` IN").
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectExec("DELETE FROM `comments` WHERE task_id IN").
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mock.ExpectExec("DELETE FROM `checklist_items` WHERE task_id IN").
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE FROM `task_dependencies` WHERE task_id IN (.*) OR blocked_by_id IN").
+		WithArgs(uint64(1), uint64(2), uint64(1), uint64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE FROM `work_logs` WHERE task_id IN").
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE FROM `task_tags` WHERE task_id IN").
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT `storage_key` FROM `attachments` WHERE task_id IN").
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}))
+	s.mock.ExpectExec("DELETE FROM `attachments` WHERE task_id IN").
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	s.mock.ExpectCommit()
+
+	purged, err := s.taskRepository.PurgeTrash(deletedBefore)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(2), purged)
+}
+
+func (s *taskSuite) TestPurgeTrash_RemovesAttachments() {
+	deletedBefore := tm.Add(-time.Hour)
+	files := storage.NewLocalStorage(s.T().TempDir())
+	storage.Files = files
+	defer func() { storage.Files = nil }()
+
+	_, errPut := files.Put(context.Background(), "tasks/1/report.pdf", strings.NewReader("report"), "application/pdf")
+	require.NoError(s.T(), errPut)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT `id` FROM `tasks` WHERE deleted_at < ?").
+		WithArgs(deletedBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectExec("DELETE FROM `tasks` WHERE `tasks`.`id` = ?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("DELETE FROM `comments`").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE FROM `checklist_items`").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE FROM `task_dependencies`").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE FROM `work_logs`").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE FROM `task_tags`").WillReturnResult(sqlmock.NewResult(0, 0))
+	// the second file is already missing from the storage, the purge goes on
+	s.mock.ExpectQuery("SELECT `storage_key` FROM `attachments` WHERE task_id IN").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("tasks/1/report.pdf").AddRow("tasks/1/missing.png"))
+	s.mock.ExpectExec("DELETE FROM `attachments` WHERE task_id IN").
+		WithArgs(uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	purged, err := s.taskRepository.PurgeTrash(deletedBefore)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(1), purged)
+
+	_, errGet := files.Get(context.Background(), "tasks/1/report.pdf")
+	require.ErrorIs(s.T(), errGet, storage.ErrObjectNotFound)
+}
+
+func (s *taskSuite) TestMarkOverdue_Success() {
+	now := tm.Add(time.Hour)
 
 	s.mock.ExpectBegin()
-	s.mock.ExpectExec("DELETE FROM `tasks`").WithArgs(id).
+	s.mock.ExpectQuery("SELECT (.*) FROM `tasks` WHERE (.*) FOR UPDATE SKIP LOCKED").
+		WithArgs(now, models.TaskStatusDone, models.TaskStatusCancelled).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "priority", "due_at"}).
+			AddRow(1, "Overdue summary", models.TaskStatusOpen, 1, models.TaskPriorityHigh, tm))
+	s.mock.ExpectExec("UPDATE `tasks` SET `overdue_notified_at`").
+		WithArgs(now, uint64(1)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	s.mock.ExpectCommit()
 
-	err := s.taskRepository.Delete(id)
+	tasks, err := s.taskRepository.MarkOverdue(now)
 	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, len(tasks))
+	require.Equal(s.T(), uint64(1), tasks[0].ID)
+}
+
+func (s *taskSuite) TestMarkOverdue_NothingToNotify() {
+	now := tm.Add(time.Hour)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) FROM `tasks` WHERE (.*) FOR UPDATE SKIP LOCKED").
+		WithArgs(now, models.TaskStatusDone, models.TaskStatusCancelled).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectCommit()
+
+	tasks, err := s.taskRepository.MarkOverdue(now)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 0, len(tasks))
+}
+
+func (s *taskSuite) TestSLABreaches_Success() {
+	now := tm
+
+	s.mock.ExpectQuery("SELECT COALESCE\\(assignee_id, user_id\\) AS technician_id(.*)GROUP BY `technician_id` HAVING").
+		WithArgs(models.TaskStatusDone, models.TaskStatusCancelled, now).
+		WillReturnRows(sqlmock.NewRows([]string{"technician_id", "overdue", "completed_late"}).
+			AddRow(1, 2, 1).
+			AddRow(3, 0, 4))
+
+	counts, err := s.taskRepository.SLABreaches(now, nil)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, len(counts))
+	require.Equal(s.T(), int64(3), counts[0].Breached)
+	require.Equal(s.T(), int64(4), counts[1].Breached)
+}
+
+func (s *taskSuite) TestSLABreaches_InSites() {
+	now := tm
+
+	s.mock.ExpectQuery("SELECT COALESCE\\(assignee_id, user_id\\) AS technician_id(.*) FROM `tasks` WHERE location_id IN \\(SELECT subtree.id FROM locations AS subtree JOIN locations AS root ON subtree.path LIKE CONCAT\\(root.path, '%'\\) WHERE root.id IN \\((.+)\\)\\) AND due_at IS NOT NULL").
+		WithArgs(models.TaskStatusDone, models.TaskStatusCancelled, now, uint64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"technician_id", "overdue", "completed_late"}).
+			AddRow(1, 2, 1))
+
+	counts, err := s.taskRepository.SLABreaches(now, []uint64{10})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, len(counts))
+}
+
+func (s *taskSuite) TestTaskHistory_OutOfSites() {
+	s.mock.ExpectQuery("SELECT `id`,`location_id` FROM `tasks` WHERE `tasks`.`id` = (.+)").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "location_id"}).AddRow(1, 21))
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `locations` WHERE id = (.+) AND id IN \\(SELECT subtree.id FROM locations AS subtree JOIN locations AS root ON subtree.path LIKE CONCAT\\(root.path, '%'\\) WHERE root.id IN \\((.+)\\)\\)").
+		WithArgs(uint64(21), uint64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := s.taskRepository.History(uint64(1), []uint64{10})
+	require.Error(s.T(), err)
+	require.Equal(s.T(), http.StatusForbidden, err.Status())
+}
+
+func (s *taskSuite) TestRestoreTask_OutOfSites() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) WHERE deleted_at IS NOT NULL AND `tasks`.`id` = ?").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "summary", "status", "user_id", "location_id", "created_at", "updated_at", "deleted_at"}).
+			AddRow(1, "Trashed summary", models.TaskStatusOpen, 1, 21, tm, tm, tm))
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `locations` WHERE id = (.+) AND id IN \\(SELECT subtree.id FROM locations AS subtree JOIN locations AS root ON subtree.path LIKE CONCAT\\(root.path, '%'\\) WHERE root.id IN \\((.+)\\)\\)").
+		WithArgs(uint64(21), uint64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectRollback()
+
+	_, err := s.taskRepository.Restore(uint64(1), uint64(2), []uint64{10})
+	require.Error(s.T(), err)
+	require.Equal(s.T(), http.StatusForbidden, err.Status())
+	require.Equal(s.T(), "the task isn't located in your sites", err.Message())
 }
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type templateSuite struct {
+	suite.Suite
+	DB                 *gorm.DB
+	mock               sqlmock.Sqlmock
+	templateRepository repositories.TemplateRepoInterface
+}
+
+func (s *templateSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.templateRepository = repositories.NewTemplateRepository(s.DB)
+}
+
+func (s *templateSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestTemplateInit(t *testing.T) {
+	suite.Run(t, new(templateSuite))
+}
+
+func (s *templateSuite) TestCreateTemplate_UnknownTag() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tags` WHERE id IN \\((.+)\\)").
+		WithArgs(uint64(2), uint64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectRollback()
+
+	template := &models.TaskTemplate{Name: "Boiler service", TaskTemplateContent: models.TaskTemplateContent{
+		Summary: "Service the boiler", Priority: models.TaskPriorityHigh, TagIDs: models.TemplateTagIDs{2, 5},
+	}}
+
+	_, err := s.templateRepository.Create(template)
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, err.Status())
+}
+
+func (s *templateSuite) TestUpdateTemplate_NewVersion() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `task_templates` WHERE `task_templates`.`id` = (.+) FOR UPDATE").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "version", "summary", "priority", "checklist", "tag_ids"}).
+			AddRow(1, "Boiler service", 3, "Service the boiler", models.TaskPriorityHigh, `[{"title":"Shut down","mandatory":true}]`, `[]`))
+	s.mock.ExpectExec("UPDATE `task_templates` SET (.+)").
+		WithArgs("Boiler service", uint64(4), sqlmock.AnyArg(), "Service the boiler twice", models.TaskPriorityMedium, "[]", "[]", uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("INSERT INTO `task_template_versions`").
+		WithArgs(uint64(1), uint64(4), uint64(2), sqlmock.AnyArg(), "Service the boiler twice", models.TaskPriorityMedium, "[]", "[]").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	s.mock.ExpectCommit()
+
+	template := &models.TaskTemplate{ID: 1, Name: "Boiler service", TaskTemplateContent: models.TaskTemplateContent{
+		Summary: "Service the boiler twice", Priority: models.TaskPriorityMedium,
+	}}
+
+	updatedTemplate, err := s.templateRepository.Update(template, 2)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), uint64(4), updatedTemplate.Version)
+	require.Equal(s.T(), "Service the boiler twice", updatedTemplate.Summary)
+}
+
+func (s *templateSuite) TestInstantiate_SkipsDeletedTags() {
+	templateID, templateVersion, assigneeID := uint64(1), uint64(3), uint64(1)
+	task := &models.Task{
+		Summary:         "Service the boiler BX-2041",
+		Status:          models.TaskStatusOpen,
+		UserID:          1,
+		AssigneeID:      &assigneeID,
+		Priority:        models.TaskPriorityHigh,
+		TemplateID:      &templateID,
+		TemplateVersion: &templateVersion,
+		Checklist:       []models.ChecklistItem{{Title: "Shut down BX-2041", Position: 1, Mandatory: true}},
+	}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("INSERT INTO `tasks`").
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	s.mock.ExpectExec("INSERT INTO `task_events`").
+		WithArgs(uint64(10), uint64(1), models.TaskEventCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec("INSERT INTO `checklist_items`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectQuery("SELECT (.+) FROM `tags` WHERE id IN \\((.+)\\) ORDER BY name").
+		WithArgs(uint64(2), uint64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "HVAC"))
+	s.mock.ExpectExec("INSERT INTO `task_tags`").
+		WithArgs(uint64(10), uint64(2), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	dbTask, err := s.templateRepository.Instantiate(task, []uint64{2, 5})
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), uint64(10), dbTask.Checklist[0].TaskID)
+	require.Len(s.T(), dbTask.Tags, 1)
+}
@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type tokenSuite struct {
+	suite.Suite
+	DB              *gorm.DB
+	mock            sqlmock.Sqlmock
+	tokenRepository repositories.TokenRepoInterface
+}
+
+func (s *tokenSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.tokenRepository = repositories.NewTokenRepository(s.DB)
+}
+
+func (s *tokenSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestTokenInit(t *testing.T) {
+	suite.Run(t, new(tokenSuite))
+}
+
+var refreshTokenColumns = []string{"id", "user_id", "family_id", "token_hash", "access_token_id", "expires_at", "used_at", "revoked_at", "created_at"}
+
+func (s *tokenSuite) TestRotate_Success() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `refresh_tokens` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns).
+			AddRow(3, 1, "family", "hash", "access", now.Add(time.Hour), nil, nil, now.Add(-time.Minute)))
+	s.mock.ExpectQuery("SELECT (.+) FROM `users` WHERE `users`.`id` = (.+)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "password", "type", "created_at", "updated_at"}).
+			AddRow(1, "Test user", "hash", "Technician", now, now))
+	s.mock.ExpectExec("UPDATE `refresh_tokens` SET `used_at`=(.+) WHERE `id` = (.+)").
+		WithArgs(now, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec("INSERT INTO `refresh_tokens`").
+		WithArgs(1, "family", "next", "next-access", sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	s.mock.ExpectCommit()
+
+	err := s.tokenRepository.Rotate("hash", now, 15*time.Minute, func(user *models.User, current *models.RefreshToken) (*models.RefreshToken, error) {
+		s.Equal("Technician", user.Type)
+		return &models.RefreshToken{UserID: user.ID, FamilyID: current.FamilyID, TokenHash: "next", AccessTokenID: "next-access", ExpiresAt: now.Add(time.Hour)}, nil
+	})
+
+	s.Nil(err)
+}
+
+func (s *tokenSuite) TestRotate_ReusedRevokesTheFamily() {
+	now := time.Now()
+	usedAt := now.Add(-time.Minute)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `refresh_tokens` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns).
+			AddRow(3, 1, "family", "hash", "access", now.Add(time.Hour), usedAt, nil, now.Add(-2*time.Minute)))
+	s.mock.ExpectQuery("SELECT (.+) FROM `refresh_tokens` WHERE family_id = (.+) AND created_at > (.+)").
+		WithArgs("family", now.Add(-15*time.Minute)).
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns).
+			AddRow(3, 1, "family", "hash", "access", now.Add(time.Hour), usedAt, nil, now.Add(-2*time.Minute)).
+			AddRow(4, 1, "family", "next", "next-access", now.Add(time.Hour), nil, nil, usedAt))
+	s.mock.ExpectExec("UPDATE `refresh_tokens` SET `revoked_at`=(.+) WHERE family_id = (.+) AND revoked_at IS NULL").
+		WithArgs(now, "family").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectExec("INSERT INTO `revoked_tokens` (.+) ON DUPLICATE KEY UPDATE").
+		WithArgs("access", sqlmock.AnyArg(), sqlmock.AnyArg(), "next-access", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+
+	err := s.tokenRepository.Rotate("hash", now, 15*time.Minute, func(user *models.User, current *models.RefreshToken) (*models.RefreshToken, error) {
+		s.Fail("a reused token shouldn't be replaced")
+		return nil, nil
+	})
+
+	s.Equal(http.StatusUnauthorized, err.Status())
+	s.Equal("the refresh token was already used, the session was revoked", err.Message())
+}
+
+func (s *tokenSuite) TestRotate_Expired() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `refresh_tokens` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns).
+			AddRow(3, 1, "family", "hash", "access", now.Add(-time.Second), nil, nil, now.Add(-time.Hour)))
+	s.mock.ExpectRollback()
+
+	err := s.tokenRepository.Rotate("hash", now, 15*time.Minute, nil)
+
+	s.Equal(http.StatusUnauthorized, err.Status())
+	s.Equal("the refresh token expired", err.Message())
+}
+
+func (s *tokenSuite) TestRotate_UnknownToken() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `refresh_tokens` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns))
+	s.mock.ExpectRollback()
+
+	err := s.tokenRepository.Rotate("unknown", time.Now(), 15*time.Minute, nil)
+
+	s.Equal(http.StatusUnauthorized, err.Status())
+	s.Equal("the refresh token is not valid", err.Message())
+}
+
+func (s *tokenSuite) TestRotate_DeactivatedUser() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `refresh_tokens` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns).
+			AddRow(3, 1, "family", "hash", "access", now.Add(time.Hour), nil, nil, now.Add(-time.Minute)))
+	s.mock.ExpectQuery("SELECT (.+) FROM `users` WHERE `users`.`id` = (.+)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "type", "deactivated_at"}).
+			AddRow(1, "Test user", "Technician", now.Add(-time.Hour)))
+	s.mock.ExpectRollback()
+
+	err := s.tokenRepository.Rotate("hash", now, 15*time.Minute, nil)
+
+	s.Equal(http.StatusUnauthorized, err.Status())
+	s.Equal("the user can't sign in anymore", err.Message())
+}
+
+func (s *tokenSuite) TestRotate_PasswordChanged() {
+	now := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM `refresh_tokens` WHERE token_hash = (.+) FOR UPDATE").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns).
+			AddRow(3, 1, "family", "hash", "access", now.Add(time.Hour), nil, nil, now.Add(-time.Hour)))
+	s.mock.ExpectQuery("SELECT (.+) FROM `users` WHERE `users`.`id` = (.+)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "type", "password_changed_at"}).
+			AddRow(1, "Test user", "Technician", now.Add(-time.Minute)))
+	s.mock.ExpectRollback()
+
+	err := s.tokenRepository.Rotate("hash", now, 15*time.Minute, nil)
+
+	s.Equal(http.StatusUnauthorized, err.Status())
+	s.Equal("the password changed, sign in again", err.Message())
+}
+
+func (s *tokenSuite) TestIsRevoked() {
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `revoked_tokens` WHERE id = (.+)").
+		WithArgs("access").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	revoked, err := s.tokenRepository.IsRevoked("access")
+
+	s.Nil(err)
+	s.True(revoked)
+}
+
+func (s *tokenSuite) TestRevoke_IgnoresDuplicates() {
+	expiresAt := time.Now().Add(time.Minute)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("INSERT INTO `revoked_tokens` (.+) ON DUPLICATE KEY UPDATE").
+		WithArgs("access", expiresAt, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err := s.tokenRepository.Revoke("access", expiresAt)
+
+	s.Nil(err)
+}
@@ -5,10 +5,12 @@ import (
 	"api/app/repositories"
 	"database/sql"
 	"errors"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	mysqlErrors "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/driver/mysql"
@@ -68,7 +70,7 @@ func (s *userSuite) TestCreateUser_Success() {
 
 	s.mock.ExpectBegin()
 	s.mock.ExpectExec("INSERT INTO `users`").
-		WithArgs(user.Name, user.Email, user.Password, user.Type, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(user.Name, user.Email, user.Password, user.Type, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	s.mock.ExpectCommit()
 
@@ -90,7 +92,7 @@ func (s *userSuite) TestCreateUser_UniqueConstraint() {
 
 	s.mock.ExpectBegin()
 	s.mock.ExpectExec("INSERT INTO `users`").
-		WithArgs(user.Name, user.Email, user.Password, user.Type, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(user.Name, user.Email, user.Password, user.Type, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, nil).
 		WillReturnError(errors.New(errorString))
 	s.mock.ExpectRollback()
 
@@ -165,3 +167,169 @@ func (s *userSuite) TestGetUserByEmail_NotFound() {
 	_, err := s.userRepository.GetByEmail(email)
 	require.Error(s.T(), err, errorString)
 }
+
+func (s *userSuite) TestListUsers_FirstPage() {
+	active := true
+	query := models.UserQuery{Type: "Technician", Active: &active, Limit: 1}
+	require.NoError(s.T(), query.Prepare())
+
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE type = (.+) AND deactivated_at IS NULL AND `users`.`deleted_at` IS NULL").
+		WithArgs("Technician").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	s.mock.ExpectQuery("SELECT \\* FROM `users` WHERE type = (.+) AND deactivated_at IS NULL (.+) ORDER BY id LIMIT 2").
+		WithArgs("Technician").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "type", "created_at", "updated_at"}).
+			AddRow(uint64(1), "Tech 1", "tech1@email.com", "Technician", tm, tm).
+			AddRow(uint64(3), "Tech 3", "tech3@email.com", "Technician", tm, tm))
+
+	page, err := s.userRepository.List(query)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 1, len(page.Data))
+	require.Equal(s.T(), int64(2), page.Total)
+
+	cursor, errCursor := models.DecodeUserCursor(page.NextCursor)
+	require.NoError(s.T(), errCursor)
+	require.Equal(s.T(), uint64(1), cursor)
+}
+
+func (s *userSuite) TestListUsers_NextPage() {
+	query := models.UserQuery{Text: "tech", Cursor: 1}
+	require.NoError(s.T(), query.Prepare())
+
+	s.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE \\(name LIKE (.+) OR email LIKE (.+)\\)").
+		WithArgs("%tech%", "%tech%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	s.mock.ExpectQuery("SELECT \\* FROM `users` WHERE \\(name LIKE (.+) OR email LIKE (.+)\\) AND id > (.+) ORDER BY id LIMIT 21").
+		WithArgs("%tech%", "%tech%", uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "type", "created_at", "updated_at"}).
+			AddRow(uint64(3), "Tech 3", "tech3@email.com", "Technician", tm, tm))
+
+	page, err := s.userRepository.List(query)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 1, len(page.Data))
+	require.Empty(s.T(), page.NextCursor)
+}
+
+func (s *userSuite) TestUpdateUser_EmailTaken() {
+	user := models.User{ID: 1, Name: "name", Email: "taken@email.com", Password: "pass", Type: "Technician"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE `users` SET `name`=(.+),`email`=(.+),`password`=(.+),`type`=(.+)").
+		WithArgs(user.Name, user.Email, user.Password, user.Type, sqlmock.AnyArg(), nil, uint64(1)).
+		WillReturnError(&mysqlErrors.MySQLError{Number: 1062, Message: "Duplicate entry 'taken@email.com' for key 'users.email'"})
+	s.mock.ExpectRollback()
+
+	_, err := s.userRepository.Update(&user)
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusConflict, err.Status())
+	require.Equal(s.T(), "the email taken@email.com is already taken", err.Message())
+}
+
+func (s *userSuite) TestSetDeactivatedAt() {
+	deactivatedAt := time.Now()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT \\* FROM `users` WHERE `users`.`id` = (.+) AND `users`.`deleted_at` IS NULL").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "type", "created_at", "updated_at"}).
+			AddRow(uint64(1), "Tech 1", "tech1@email.com", "Technician", tm, tm))
+	s.mock.ExpectExec("UPDATE `users` SET `updated_at`=(.+),`deactivated_at`=(.+)").
+		WithArgs(sqlmock.AnyArg(), deactivatedAt, uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	user, err := s.userRepository.SetDeactivatedAt(1, &deactivatedAt)
+	require.Nil(s.T(), err)
+	require.False(s.T(), user.IsActive())
+}
+
+func (s *userSuite) TestDeleteUser_NotFound() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("UPDATE `users` SET `deleted_at`=(.+) WHERE `users`.`id` = (.+) AND `users`.`deleted_at` IS NULL").
+		WithArgs(sqlmock.AnyArg(), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err := s.userRepository.Delete(1)
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, err.Status())
+}
+
+func (s *userSuite) TestGetUserWithDeleted() {
+	s.mock.ExpectQuery("SELECT \\* FROM `users` WHERE `users`.`id` = (.+) ORDER BY `users`.`id` LIMIT 1$").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "type", "created_at", "updated_at", "deleted_at"}).
+			AddRow(uint64(1), "Tech 1", "tech1@email.com", "Technician", tm, tm, tm))
+
+	user, err := s.userRepository.GetWithDeleted(1)
+	require.Nil(s.T(), err)
+	require.True(s.T(), user.DeletedAt.Valid)
+}
+
+func (s *userSuite) TestBootstrapAdmin_CreatesTheAdmin() {
+	admin, errAdmin := models.NewBootstrapAdmin(" admin@email.com ")
+	require.NoError(s.T(), errAdmin)
+	require.Equal(s.T(), "admin@email.com", admin.Email)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count(.+) FROM `users` WHERE type = (.+)").
+		WithArgs("Admin").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectQuery("SELECT \\* FROM `users` WHERE email = (.+)").
+		WithArgs("admin@email.com").
+		WillReturnError(gorm.ErrRecordNotFound)
+	s.mock.ExpectExec("INSERT INTO `users`").
+		WithArgs("Admin", "admin@email.com", admin.Password, "Admin", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, nil).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	s.mock.ExpectCommit()
+
+	user, err := s.userRepository.BootstrapAdmin(admin)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), uint64(3), user.ID)
+	require.Equal(s.T(), "Admin", user.Type)
+}
+
+func (s *userSuite) TestBootstrapAdmin_PromotesTheUser() {
+	admin, errAdmin := models.NewBootstrapAdmin("tech1@email.com")
+	require.NoError(s.T(), errAdmin)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count(.+) FROM `users` WHERE type = (.+)").
+		WithArgs("Admin").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectQuery("SELECT \\* FROM `users` WHERE email = (.+)").
+		WithArgs("tech1@email.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "password", "type", "created_at", "updated_at"}).
+			AddRow(uint64(1), "Tech 1", "tech1@email.com", "hash", "Technician", tm, tm))
+	s.mock.ExpectExec("UPDATE `users` SET `type`=(.+),`updated_at`=(.+) WHERE (.+)").
+		WithArgs("Admin", sqlmock.AnyArg(), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	// the password of the user is kept
+	user, err := s.userRepository.BootstrapAdmin(admin)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), uint64(1), user.ID)
+	require.Equal(s.T(), "Admin", user.Type)
+	require.Equal(s.T(), "hash", user.Password)
+}
+
+func (s *userSuite) TestBootstrapAdmin_AdminExists() {
+	admin, errAdmin := models.NewBootstrapAdmin("admin@email.com")
+	require.NoError(s.T(), errAdmin)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT count(.+) FROM `users` WHERE type = (.+)").
+		WithArgs("Admin").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	user, err := s.userRepository.BootstrapAdmin(admin)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), user)
+}
+
+func (s *userSuite) TestBootstrapAdmin_InvalidEmail() {
+	_, err := models.NewBootstrapAdmin("not an email")
+	require.EqualError(s.T(), err, "the admin email not an email is invalid")
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"api/app/models"
+	"api/app/repositories"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type workLogSuite struct {
+	suite.Suite
+	DB                *gorm.DB
+	mock              sqlmock.Sqlmock
+	workLogRepository repositories.WorkLogRepoInterface
+}
+
+func (s *workLogSuite) SetupSuite() {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+		DriverName:                "mysql",
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+
+	require.NoError(s.T(), err)
+
+	s.workLogRepository = repositories.NewWorkLogRepository(s.DB)
+}
+
+func (s *workLogSuite) AfterTest(_, _ string) {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+}
+
+func TestWorkLogInit(t *testing.T) {
+	suite.Run(t, new(workLogSuite))
+}
+
+func (s *workLogSuite) TestStartTimer_Success() {
+	workLog := models.WorkLog{TaskID: 1, TechnicianID: 1, StartedAt: tm}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT `id` FROM `users` WHERE `users`.`id` = ? (.*) FOR UPDATE").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery("SELECT (.+) FROM `work_logs` WHERE technician_id = (.+) AND ended_at IS NULL").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectExec("INSERT INTO `work_logs`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	dbWorkLog, err := s.workLogRepository.StartTimer(&workLog)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), uint64(1), dbWorkLog.ID)
+	require.True(s.T(), dbWorkLog.IsRunning())
+}
+
+func (s *workLogSuite) TestStartTimer_AlreadyRunning() {
+	workLog := models.WorkLog{TaskID: 1, TechnicianID: 1, StartedAt: tm}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT `id` FROM `users` WHERE `users`.`id` = ? (.*) FOR UPDATE").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery("SELECT (.+) FROM `work_logs` WHERE technician_id = (.+) AND ended_at IS NULL").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "technician_id", "started_at"}).AddRow(7, 2, 1, tm))
+	s.mock.ExpectRollback()
+
+	_, err := s.workLogRepository.StartTimer(&workLog)
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusConflict, err.Status())
+	require.Equal(s.T(), "a timer is already running on the task 2, stop it first", err.Message())
+}
+
+func (s *workLogSuite) TestStopTimer_Success() {
+	now := tm.Add(90 * time.Minute)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) FROM `work_logs` WHERE (.*) FOR UPDATE").
+		WithArgs(uint64(1), uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "technician_id", "started_at"}).AddRow(7, 1, 1, tm))
+	s.mock.ExpectExec("UPDATE `work_logs` SET `ended_at`=(.+),`duration_seconds`=(.+),`note`=(.+) WHERE `id` = ?").
+		WithArgs(now, int64(5400), "Replaced the filter", sqlmock.AnyArg(), uint64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	workLog, err := s.workLogRepository.StopTimer(1, 1, now, "Replaced the filter")
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), int64(5400), workLog.DurationSeconds)
+}
+
+func (s *workLogSuite) TestStopTimer_NotRunning() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.*) FROM `work_logs` WHERE (.*) FOR UPDATE").
+		WithArgs(uint64(1), uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectRollback()
+
+	_, err := s.workLogRepository.StopTimer(1, 1, tm, "")
+	require.NotNil(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, err.Status())
+}
+
+func (s *workLogSuite) TestTotalByTaskID_Success() {
+	s.mock.ExpectQuery("SELECT COALESCE\\(SUM\\(duration_seconds\\), 0\\) FROM `work_logs` WHERE task_id = (.+) AND ended_at IS NOT NULL").
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(5400))
+
+	total, err := s.workLogRepository.TotalByTaskID(1)
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), int64(5400), total)
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"api/app/utils/cron_utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronNext_Monthly(t *testing.T) {
+	expression, err := cron_utils.Parse("0 8 1 * *")
+	assert.Nil(t, err)
+
+	next := expression.Next(time.Date(2022, time.January, 15, 10, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2022, time.February, 1, 8, 0, 0, 0, time.UTC), next)
+}
+
+func TestCronNext_Quarterly(t *testing.T) {
+	expression, err := cron_utils.Parse("30 7 1 */3 *")
+	assert.Nil(t, err)
+
+	next := expression.Next(time.Date(2022, time.January, 1, 7, 30, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2022, time.April, 1, 7, 30, 0, 0, time.UTC), next)
+}
+
+func TestCronNext_WeekdaysRange(t *testing.T) {
+	expression, err := cron_utils.Parse("0 6 * * 1-5")
+	assert.Nil(t, err)
+
+	// friday evening, the next one is monday morning
+	next := expression.Next(time.Date(2022, time.March, 4, 18, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2022, time.March, 7, 6, 0, 0, 0, time.UTC), next)
+}
+
+func TestCronNext_DayOrWeekday(t *testing.T) {
+	expression, err := cron_utils.Parse("0 0 15 * 0")
+	assert.Nil(t, err)
+
+	// the 6th is a sunday, it comes before the 15th
+	next := expression.Next(time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2022, time.March, 6, 0, 0, 0, 0, time.UTC), next)
+}
+
+func TestCronNext_Macro(t *testing.T) {
+	expression, err := cron_utils.Parse("@weekly")
+	assert.Nil(t, err)
+
+	next := expression.Next(time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2022, time.March, 6, 0, 0, 0, 0, time.UTC), next)
+}
+
+func TestCronNext_KeepsTheLocation(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.Nil(t, err)
+
+	expression, err := cron_utils.Parse("0 8 * * *")
+	assert.Nil(t, err)
+
+	// the clocks move forward on this night, 8am is still 8am local time
+	next := expression.Next(time.Date(2022, time.March, 26, 9, 0, 0, 0, paris))
+	assert.Equal(t, time.Date(2022, time.March, 27, 6, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestCronNext_NeverMatches(t *testing.T) {
+	expression, err := cron_utils.Parse("0 0 31 2 *")
+	assert.Nil(t, err)
+
+	assert.True(t, expression.Next(time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero())
+}
+
+func TestCronParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := cron_utils.Parse(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
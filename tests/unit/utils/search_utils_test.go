@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"api/app/utils/search_utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnippet_HighlightsEveryTerm(t *testing.T) {
+	snippet := search_utils.Snippet("Compressor leak fixed, the compressor is fine", "compressor", 100)
+
+	assert.Equal(t, "<mark>Compressor</mark> leak fixed, the <mark>compressor</mark> is fine", snippet)
+}
+
+func TestSnippet_CutsAroundTheFirstMatch(t *testing.T) {
+	text := strings.Repeat("a ", 50) + "pump replaced" + strings.Repeat(" b", 50)
+	snippet := search_utils.Snippet(text, "pump", 10)
+
+	assert.True(t, strings.HasPrefix(snippet, "…"))
+	assert.True(t, strings.HasSuffix(snippet, "…"))
+	assert.Contains(t, snippet, "<mark>pump</mark> replaced")
+}
+
+func TestSnippet_EscapesHTML(t *testing.T) {
+	snippet := search_utils.Snippet("<b>valve</b> stuck", "valve", 100)
+
+	assert.Equal(t, "&lt;b&gt;<mark>valve</mark>&lt;/b&gt; stuck", snippet)
+}
+
+func TestSnippet_NoMatch(t *testing.T) {
+	snippet := search_utils.Snippet("Replaced the filter", "pump", 100)
+
+	assert.Equal(t, "Replaced the filter", snippet)
+}